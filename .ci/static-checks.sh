@@ -407,11 +407,8 @@ static_check_labels()
 {
 	[ $(uname -s) != Linux ] && info "Can only check labels under Linux" && return
 
-	# Handle SLES which doesn't provide the required command.
-	[ -z "$have_yamllint_cmd" ] && info "Cannot check labels as $yamllint_cmd not available" && return
-
 	# Since this script is called from another repositories directory,
-	# ensure the utility is built before the script below (which uses it) is run.
+	# ensure the utility is built before it is run.
 	(cd "${tests_repo_dir}" && make github-labels)
 
 	tmp=$(mktemp)
@@ -420,7 +417,9 @@ static_check_labels()
 
 	info "Checking labels for repo ${repo} using temporary combined database ${tmp}"
 
-	bash -f "${tests_repo_dir}/cmd/github-labels/github-labels.sh" "generate" "${repo}" "${tmp}"
+	kata-github-labels generate \
+		--master-template "${tests_repo_dir}/cmd/github-labels/labels.yaml.in" \
+		"${repo}" "${tmp}"
 }
 
 # Ensure all files (where possible) contain an SPDX license header
@@ -29,6 +29,8 @@ func (d *Doc) linkAddrToPath(address string) (string, error) {
 	//
 	// [Foo](/absolute-link.md)
 	if strings.HasPrefix(address, absoluteLinkPrefix) {
+		docRoot := d.checker.Options.DocRoot
+
 		if !fileExists(docRoot) {
 			return "", fmt.Errorf("document root %q does not exist", docRoot)
 		}
@@ -61,9 +63,12 @@ func (d *Doc) addHeading(heading Heading) error {
 			name, heading)
 	}
 
+	// All headings are positioned to keep the parse offset up to date.
+	line, column := d.headingPosition(heading)
+
 	if _, ok := d.Headings[name]; ok {
-		return d.Errorf("duplicate heading: %q (heading: %+v)",
-			name, heading)
+		return d.Findingf(ruleDuplicateHeading, line, column, "",
+			"duplicate heading: %q (heading: %+v)", name, heading)
 	}
 
 	// Potentially change the ID to handle strange characters
@@ -108,9 +113,9 @@ func (d *Doc) addLink(link Link) error {
 
 	// Not checked by default as magic "build status" / go report / godoc
 	// links don't have a description - they have a image only.
-	if strict && link.Description == "" {
-		return d.Errorf("link description cannot be blank: %q (%+v)",
-			addr, link)
+	if d.checker.Options.Strict && link.Description == "" {
+		return d.Findingf(ruleBlankLinkDesc, link.Line, link.Column, "",
+			"link description cannot be blank: %q (%+v)", addr, link)
 	}
 
 	fields := logrus.Fields{
@@ -68,7 +68,7 @@ func TestDocAddHeading(t *testing.T) {
 	logger := logrus.WithField("test", "true")
 
 	for i, d := range data {
-		doc := newDoc("foo", logger)
+		doc := NewChecker(logger, Options{}).newDoc("foo")
 
 		assert.Empty(doc.Headings)
 
@@ -101,19 +101,19 @@ func TestDocAddLink(t *testing.T) {
 	}
 
 	data := []testData{
-		{Link{nil, "", "", "", -1}, true},
-		{Link{nil, "foo", "", "", unknownLink}, true},
+		{Link{nil, "", "", "", -1, 0, 0}, true},
+		{Link{nil, "foo", "", "", unknownLink, 0, 0}, true},
 
-		{Link{nil, "foo", "", "", internalLink}, false},
-		{Link{nil, "http://google.com", "", "", urlLink}, false},
-		{Link{nil, "https://google.com", "", "", urlLink}, false},
-		{Link{nil, "mailto:me@somewhere.com", "", "", mailLink}, false},
+		{Link{nil, "foo", "", "", internalLink, 0, 0}, false},
+		{Link{nil, "http://google.com", "", "", urlLink, 0, 0}, false},
+		{Link{nil, "https://google.com", "", "", urlLink, 0, 0}, false},
+		{Link{nil, "mailto:me@somewhere.com", "", "", mailLink, 0, 0}, false},
 	}
 
 	logger := logrus.WithField("test", "true")
 
 	for i, d := range data {
-		doc := newDoc("foo", logger)
+		doc := NewChecker(logger, Options{}).newDoc("foo")
 
 		assert.Empty(doc.Links)
 
@@ -148,14 +148,6 @@ func TestDocLinkAddrToPath(t *testing.T) {
 	assert.NoError(err)
 	defer os.RemoveAll(dir)
 
-	savedDocRoot := docRoot
-	docRoot = dir
-
-	defer func() {
-		docRoot = savedDocRoot
-
-	}()
-
 	mdFile := "bar.md"
 	mdPath := filepath.Join("/", mdFile)
 	actualMDPath := filepath.Join(dir, mdFile)
@@ -174,7 +166,7 @@ func TestDocLinkAddrToPath(t *testing.T) {
 	}
 
 	logger := logrus.WithField("test", "true")
-	doc := newDoc("foo", logger)
+	doc := NewChecker(logger, Options{DocRoot: dir}).newDoc("foo")
 
 	for i, d := range data {
 		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runGit runs git with the specified arguments in the specified directory,
+// returning its output.
+func runGit(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("git %s failed: %v (stderr: %q)",
+			strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// changedDocs returns the absolute paths of the markdown documents below
+// the specified directory which have been modified or added since the
+// specified git revision, and those which have been deleted. Both lists are
+// sorted by name.
+//
+// Uncommitted changes are included. A renamed document is considered to have
+// been deleted and added.
+func changedDocs(dir, revision string) (modified, deleted []string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// git resolves symlinks in the top-level directory, so the directory
+	// is resolved too to allow the paths to be compared. The paths
+	// returned are below the directory as specified.
+	resolvedDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	top, err := runGit(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	top, err = filepath.EvalSymlinks(strings.TrimSpace(top))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := runGit(dir, "diff", "--name-status", "--no-renames", revision, "--", "*"+mdExtension)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("unexpected git diff output: %q", line)
+		}
+
+		status := fields[0]
+
+		rel, err := filepath.Rel(resolvedDir, filepath.Join(top, filepath.FromSlash(fields[1])))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			// Not below the directory
+			continue
+		}
+
+		path := filepath.Join(dir, rel)
+
+		if status == "D" {
+			deleted = append(deleted, path)
+		} else {
+			modified = append(modified, path)
+		}
+	}
+
+	sort.Strings(modified)
+	sort.Strings(deleted)
+
+	return modified, deleted, nil
+}
+
+// docsToCheck returns the absolute paths of the modified documents, and all
+// documents which link to either the modified or deleted documents, sorted by
+// name.
+func docsToCheck(graph *LinkGraph, modified, deleted []string) ([]string, error) {
+	unique := make(map[string]bool)
+
+	for _, file := range modified {
+		unique[file] = true
+	}
+
+	for _, file := range append(append([]string{}, modified...), deleted...) {
+		referrers, err := graph.Referrers(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, referrer := range referrers {
+			unique[referrer] = true
+		}
+	}
+
+	var files []string
+
+	for file := range unique {
+		files = append(files, file)
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// displayPath returns the specified path relative to the current directory
+// if possible, else the path unchanged.
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return rel
+}
+
+// checkChangedDocs checks the documents which have changed since the
+// specified git revision, and the documents which link to them.
+//
+// Only these documents are checked; the documents they link to are only
+// parsed to allow the links to be validated.
+func (c *Checker) checkChangedDocs(root, revision, cacheFile string) ([]*Doc, error) {
+	modified, deleted, err := changedDocs(root, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := NewLinkGraph(c.Logger, root, c.Options.DocRoot, cacheFile)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := docsToCheck(graph, modified, deleted)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Logger.WithFields(logrus.Fields{
+		"revision":       revision,
+		"modified-count": len(modified),
+		"deleted-count":  len(deleted),
+		"check-count":    len(files),
+	}).Info("Found changed documents")
+
+	var targets []*Doc
+
+	for _, file := range files {
+		targets = append(targets, c.newDoc(displayPath(file)))
+	}
+
+	if err := c.parseConcurrently(targets); err != nil {
+		return nil, err
+	}
+
+	if err := c.parseReferences(); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkIntraDocLinks(); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,207 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// createGitRepo creates a git repository in the specified directory
+// containing the specified documents in a single commit.
+func createGitRepo(assert *assert.Assertions, dir string, docs map[string]string) {
+	for name, contents := range docs {
+		err := createFile(filepath.Join(dir, name), contents)
+		assert.NoError(err)
+	}
+
+	commands := [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial"},
+	}
+
+	for _, args := range commands {
+		_, err := runGit(dir, args...)
+		assert.NoError(err)
+	}
+}
+
+func TestChangedDocs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	createGitRepo(assert, dir, map[string]string{
+		"a.md":     "# A\n",
+		"b.md":     "# B\n",
+		"c.md":     "# C\n",
+		"file.txt": "not markdown\n",
+	})
+
+	modified, deleted, err := changedDocs(dir, "HEAD")
+	assert.NoError(err)
+	assert.Empty(modified)
+	assert.Empty(deleted)
+
+	err = createFile(filepath.Join(dir, "a.md"), "# A changed\n")
+	assert.NoError(err)
+
+	err = createFile(filepath.Join(dir, "file.txt"), "changed\n")
+	assert.NoError(err)
+
+	err = os.Remove(filepath.Join(dir, "b.md"))
+	assert.NoError(err)
+
+	modified, deleted, err = changedDocs(dir, "HEAD")
+	assert.NoError(err)
+	assert.Equal([]string{filepath.Join(dir, "a.md")}, modified)
+	assert.Equal([]string{filepath.Join(dir, "b.md")}, deleted)
+
+	_, _, err = changedDocs(dir, "no-such-revision")
+	assert.Error(err)
+}
+
+func TestCheckChangedDocs(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	createGitRepo(assert, dir, map[string]string{
+		"a.md": "# A\n\nSee [b](b.md#section).\n",
+		"b.md": "# B\n\n## Section\n",
+		"c.md": "# C\n\nSee [missing](#missing).\n",
+		"d.md": "# D\n\nSee [b](b.md).\n",
+	})
+
+	logger := logrus.WithField("test", "true")
+
+	checker := NewChecker(logger, Options{CollectFindings: true})
+
+	docs, err := checker.checkChangedDocs(dir, "HEAD", "")
+	assert.NoError(err)
+	assert.Empty(docs)
+	assert.Empty(checker.Findings())
+
+	// Remove the heading a.md links to.
+	err = createFile(filepath.Join(dir, "b.md"), "# B\n\n## Other\n")
+	assert.NoError(err)
+
+	checker = NewChecker(logger, Options{CollectFindings: true})
+
+	docs, err = checker.checkChangedDocs(dir, "HEAD", "")
+	assert.NoError(err)
+
+	var names []string
+
+	for _, doc := range docs {
+		names = append(names, doc.Name)
+	}
+
+	// c.md is broken but unchanged so should not be checked.
+	assert.Equal([]string{
+		filepath.Join(dir, "a.md"),
+		filepath.Join(dir, "b.md"),
+		filepath.Join(dir, "d.md"),
+	}, names)
+
+	findings := checker.Findings()
+	assert.Len(findings, 1)
+	assert.Equal(ruleInvalidExternalLink, findings[0].RuleID)
+	assert.Equal(filepath.Join(dir, "a.md"), findings[0].File)
+
+	// Deleting a document should result in the documents linking to it
+	// being checked.
+	err = os.Remove(filepath.Join(dir, "b.md"))
+	assert.NoError(err)
+
+	checker = NewChecker(logger, Options{CollectFindings: true})
+
+	docs, err = checker.checkChangedDocs(dir, "HEAD", "")
+	assert.NoError(err)
+	assert.Len(docs, 2)
+
+	findings = checker.Findings()
+	assert.Len(findings, 2)
+
+	for _, finding := range findings {
+		assert.Equal(ruleMissingFile, finding.RuleID)
+	}
+}
+
+func TestCheckChangedDocsSymlink(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	repoDir := filepath.Join(dir, "repo")
+
+	err = os.MkdirAll(filepath.Join(repoDir, "docs"), testDirMode)
+	assert.NoError(err)
+
+	createGitRepo(assert, repoDir, map[string]string{
+		"README.md":     "# Readme\n",
+		"docs/a.md":     "# A\n\nSee [b](b.md#section).\n",
+		"docs/b.md":     "# B\n\n## Section\n",
+		"docs/other.md": "# Other\n",
+	})
+
+	link := filepath.Join(dir, "link")
+
+	err = os.Symlink(repoDir, link)
+	assert.NoError(err)
+
+	// The documents are checked using a path below the symlink.
+	root := filepath.Join(link, "docs")
+
+	err = createFile(filepath.Join(root, "b.md"), "# B\n\n## Other\n")
+	assert.NoError(err)
+
+	// Not below the root so should be ignored.
+	err = createFile(filepath.Join(repoDir, "README.md"), "# Changed\n")
+	assert.NoError(err)
+
+	modified, deleted, err := changedDocs(root, "HEAD")
+	assert.NoError(err)
+	assert.Equal([]string{filepath.Join(root, "b.md")}, modified)
+	assert.Empty(deleted)
+
+	logger := logrus.WithField("test", "true")
+
+	checker := NewChecker(logger, Options{CollectFindings: true})
+
+	docs, err := checker.checkChangedDocs(root, "HEAD", "")
+	assert.NoError(err)
+
+	var names []string
+
+	for _, doc := range docs {
+		names = append(names, doc.Name)
+	}
+
+	assert.Equal([]string{
+		filepath.Join(root, "a.md"),
+		filepath.Join(root, "b.md"),
+	}, names)
+
+	findings := checker.Findings()
+	if assert.Len(findings, 1) {
+		assert.Equal(ruleInvalidExternalLink, findings[0].RuleID)
+		assert.Equal(filepath.Join(root, "a.md"), findings[0].File)
+	}
+}
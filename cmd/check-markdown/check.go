@@ -9,6 +9,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"strings"
 )
 
 // checkLink checks the validity of the specified link. If checkOtherDoc is
@@ -19,32 +20,33 @@ func (d *Doc) checkLink(address string, link Link, checkOtherDoc bool) error {
 		return errors.New("link address not set")
 	}
 
+	line, column := link.Line, link.Column
+
 	switch link.Type {
 	case externalFile:
 		fallthrough
 	case externalLink:
 		// Check to ensure that referenced file actually exists
 
-		var file string
+		file := link.ResolvedPath
 
-		if link.ResolvedPath != "" {
-			file = link.ResolvedPath
-		} else {
-			file, _, err := splitLink(address)
+		if file == "" {
+			name, _, err := splitLink(address)
 			if err != nil {
 				return err
 			}
 
-			file, err = d.linkAddrToPath(file)
+			file, err = d.linkAddrToPath(name)
 			if err != nil {
 				return err
 			}
+		}
 
-			if !fileExists(file) {
-				return d.Errorf("link type %v invalid: %q does not exist",
-					link.Type,
-					file)
-			}
+		if !fileExists(file) {
+			return d.Findingf(ruleMissingFile, line, column, "",
+				"link type %v invalid: %q does not exist",
+				link.Type,
+				file)
 		}
 
 		if link.Type == externalFile {
@@ -52,7 +54,7 @@ func (d *Doc) checkLink(address string, link Link, checkOtherDoc bool) error {
 		}
 
 		// Check the other document
-		other, err := getDoc(file, d.Logger)
+		other, err := d.checker.getDoc(file)
 		if err != nil {
 			return err
 		}
@@ -61,7 +63,10 @@ func (d *Doc) checkLink(address string, link Link, checkOtherDoc bool) error {
 			break
 		}
 
-		_, section, err := splitLink(address)
+		// Note that the link address is used since the address
+		// specified is the resolved path which does not include the
+		// section.
+		_, section, err := splitLink(link.Address)
 		if err != nil {
 			return err
 		}
@@ -71,7 +76,17 @@ func (d *Doc) checkLink(address string, link Link, checkOtherDoc bool) error {
 		}
 
 		if !other.hasHeading(section) {
-			return other.Errorf("invalid link %v", address)
+			msg := fmt.Sprintf("invalid link %v: no heading %q in %q", link.Address, section, other.Name)
+
+			fix := ""
+
+			suggestion, err2 := createHeadingID(link.Description)
+			if err2 == nil && suggestion != section && other.hasHeading(suggestion) {
+				fix = strings.TrimSuffix(link.Address, section) + suggestion
+				msg = fmt.Sprintf("%s - correct link name is %q", msg, fix)
+			}
+
+			return d.Findingf(ruleInvalidExternalLink, line, column, fix, "%s", msg)
 		}
 
 	case internalLink:
@@ -82,6 +97,8 @@ func (d *Doc) checkLink(address string, link Link, checkOtherDoc bool) error {
 		if found == nil {
 			msg := fmt.Sprintf("failed to find heading for link %q (%+v)", address, link)
 
+			fix := ""
+
 			// There is a chance the link description matches the
 			// correct heading the link address refers to. In
 			// which case, we can derive the correct link address!
@@ -91,10 +108,11 @@ func (d *Doc) checkLink(address string, link Link, checkOtherDoc bool) error {
 				found = d.headingByLinkName(suggestion)
 				if found != nil {
 					msg = fmt.Sprintf("%s - correct link name is %q", msg, suggestion)
+					fix = anchorPrefix + suggestion
 				}
 			}
 
-			return d.Errorf("%s", msg)
+			return d.Findingf(ruleInvalidInternalLink, line, column, fix, "%s", msg)
 		}
 	case urlLink:
 		// NOP - handled by xurls
@@ -104,10 +122,13 @@ func (d *Doc) checkLink(address string, link Link, checkOtherDoc bool) error {
 }
 
 // check performs all checks on the document.
+//
+// If findings are being collected, all links are checked, else the first
+// problem found is returned.
 func (d *Doc) check() error {
-	for name, linkList := range d.Links {
-		for _, link := range linkList {
-			err := d.checkLink(name, link, false)
+	for _, name := range d.linkAddresses() {
+		for _, link := range d.Links[name] {
+			err := d.report(d.checkLink(name, link, false))
 			if err != nil {
 				return err
 			}
@@ -0,0 +1,265 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Options controls how a Checker handles documents.
+type Options struct {
+	// Root directory used to handle "absolute link paths" that start
+	// with a slash to denote the "top directory", like this:
+	//
+	// [Foo](/absolute-link.md)
+	DocRoot string
+
+	// If set, perform additional checks.
+	Strict bool
+
+	// If set, record every problem found in the document set rather than
+	// stopping at the first one.
+	CollectFindings bool
+
+	// Maximum number of documents to parse concurrently. If zero, the
+	// number of CPUs is used.
+	Workers int
+}
+
+// Checker checks a set of markdown documents: a primary document and all
+// the documents it references.
+type Checker struct {
+	Logger *logrus.Entry
+
+	Options Options
+
+	// Protects all the fields below.
+	lock sync.Mutex
+
+	// Details of the main document, and all other documents it
+	// references.
+	// Key: document name.
+	docs map[string]*Doc
+
+	// List of problems found in all documents when
+	// Options.CollectFindings is set.
+	findings []Finding
+
+	// Black Friday sometimes chokes on markdown (I know!!), so record how
+	// many extra headings we found.
+	extraHeadings int
+}
+
+// NewChecker creates a new Checker.
+func NewChecker(logger *logrus.Entry, options Options) *Checker {
+	if options.Workers <= 0 {
+		options.Workers = runtime.NumCPU()
+	}
+
+	return &Checker{
+		Logger:  logger,
+		Options: options,
+		docs:    make(map[string]*Doc),
+	}
+}
+
+// addFinding records the specified finding, ignoring duplicates.
+func (c *Checker) addFinding(finding Finding) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for _, f := range c.findings {
+		if f == finding {
+			return
+		}
+	}
+
+	c.findings = append(c.findings, finding)
+}
+
+// Findings returns all problems found so far.
+func (c *Checker) Findings() []Finding {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]Finding{}, c.findings...)
+}
+
+// ClearFindings forgets all problems found so far.
+func (c *Checker) ClearFindings() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.findings = nil
+}
+
+// addExtraHeadings records the number of headings Black Friday failed to
+// detect.
+func (c *Checker) addExtraHeadings(count int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.extraHeadings += count
+}
+
+// ExtraHeadings returns the number of headings Black Friday failed to
+// detect.
+func (c *Checker) ExtraHeadings() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.extraHeadings
+}
+
+// Docs returns all known documents, ordered by name.
+func (c *Checker) Docs() []*Doc {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var list []*Doc
+
+	for _, d := range c.docs {
+		list = append(list, d)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// listDocs returns the list of parsed documents, with the primary document
+// first and the remaining documents ordered by name.
+func (c *Checker) listDocs(primary *Doc) []*Doc {
+	list := []*Doc{primary}
+
+	for _, d := range c.Docs() {
+		if d == primary || !d.Parsed {
+			continue
+		}
+
+		list = append(list, d)
+	}
+
+	return list
+}
+
+// unparsedDocs returns the documents which have not yet been parsed, ordered
+// by name.
+func (c *Checker) unparsedDocs() []*Doc {
+	var list []*Doc
+
+	for _, d := range c.Docs() {
+		if !d.Parsed {
+			list = append(list, d)
+		}
+	}
+
+	return list
+}
+
+// parseDocs parses all the documents the main document references. If
+// listMode is set, the documents are parsed for display rather than
+// checking.
+//
+// Parsing a document may add further documents to the set, so this is
+// repeated until all documents have been parsed. If a document cannot be
+// parsed, the error for the first such document (by name) is returned.
+func (c *Checker) parseDocs(listMode bool) error {
+	for {
+		pending := c.unparsedDocs()
+		if len(pending) == 0 {
+			return nil
+		}
+
+		for _, doc := range pending {
+			// Referenced documents are handled in the same mode
+			// as the main document.
+			doc.ListMode = listMode
+		}
+
+		if err := c.parseConcurrently(pending); err != nil {
+			return err
+		}
+	}
+}
+
+// parseReferences parses all unparsed documents purely so that links to them
+// can be checked. Documents these documents reference are not parsed, and
+// problems in them are ignored.
+func (c *Checker) parseReferences() error {
+	pending := c.unparsedDocs()
+
+	for _, doc := range pending {
+		doc.ListMode = true
+		doc.Reference = true
+	}
+
+	return c.parseConcurrently(pending)
+}
+
+// parseConcurrently parses the specified documents, with up to
+// Options.Workers documents being parsed concurrently. If a document cannot
+// be parsed, the error for the first such document in the list is returned.
+func (c *Checker) parseConcurrently(docs []*Doc) error {
+	errs := make([]error, len(docs))
+
+	// Used to limit the number of concurrent workers.
+	tokens := make(chan struct{}, c.Options.Workers)
+
+	var wg sync.WaitGroup
+
+	for i, doc := range docs {
+		wg.Add(1)
+		tokens <- struct{}{}
+
+		go func(i int, doc *Doc) {
+			defer wg.Done()
+			defer func() { <-tokens }()
+
+			errs[i] = doc.parse()
+		}(i, doc)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkIntraDocLinks checks the links between documents are correct.
+//
+// For example, if a document refers to "foo.md#section-bar", this function
+// will ensure that "section-bar" exists in external file "foo.md".
+func (c *Checker) checkIntraDocLinks() error {
+	for _, doc := range c.Docs() {
+		if doc.Reference {
+			continue
+		}
+
+		for _, addr := range doc.linkAddresses() {
+			for _, link := range doc.Links[addr] {
+				err := doc.report(doc.checkLink(addr, link, true))
+				if err != nil {
+					return doc.Errorf("intra-doc link invalid: %v", err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
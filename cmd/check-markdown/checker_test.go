@@ -0,0 +1,164 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDocCount = 20
+
+// createDocTree creates a set of documents in the specified directory where
+// the primary document links to all others, and every other document links
+// to the next. Every third document also contains a broken link. Returns
+// the path to the primary document.
+func createDocTree(assert *assert.Assertions, dir string) string {
+	primary := filepath.Join(dir, "README.md")
+
+	contents := "# Primary\n\n"
+
+	for i := 0; i < testDocCount; i++ {
+		name := fmt.Sprintf("doc-%02d.md", i)
+		next := fmt.Sprintf("doc-%02d.md", (i+1)%testDocCount)
+
+		contents += fmt.Sprintf("- [%s](%s)\n", name, name)
+
+		doc := fmt.Sprintf("# Doc %d\n\n## Section\n\nSee [next](%s#section).\n", i, next)
+
+		if i%3 == 0 {
+			doc += "See [missing](#missing).\n"
+		}
+
+		err := createFile(filepath.Join(dir, name), doc)
+		assert.NoError(err)
+	}
+
+	err := createFile(primary, contents)
+	assert.NoError(err)
+
+	return primary
+}
+
+// checkDocTree checks all documents below the specified primary document,
+// returning the names of all the documents checked, all the findings and the
+// error.
+func checkDocTree(primary string, options Options) ([]string, []Finding, error) {
+	logger := logrus.WithField("test", "true")
+
+	checker := NewChecker(logger, options)
+
+	doc := checker.newDoc(primary)
+	doc.ListMode = true
+
+	if err := doc.parse(); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checker.parseDocs(true); err != nil {
+		return nil, nil, err
+	}
+
+	if err := checker.checkIntraDocLinks(); err != nil {
+		return nil, nil, err
+	}
+
+	var names []string
+
+	for _, d := range checker.listDocs(doc) {
+		names = append(names, d.Name)
+	}
+
+	return names, checker.newReport(doc).Findings, nil
+}
+
+func TestNewChecker(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := logrus.WithField("test", "true")
+
+	checker := NewChecker(logger, Options{})
+	assert.True(checker.Options.Workers > 0)
+	assert.Empty(checker.Docs())
+	assert.Empty(checker.Findings())
+
+	checker = NewChecker(logger, Options{Workers: 3})
+	assert.Equal(3, checker.Options.Workers)
+
+	doc := checker.newDoc("foo")
+	assert.Equal(checker, doc.checker)
+
+	other, err := checker.getDoc("foo")
+	assert.NoError(err)
+	assert.Equal(doc, other)
+
+	_, err = checker.getDoc("")
+	assert.Error(err)
+
+	assert.Equal([]*Doc{doc}, checker.Docs())
+}
+
+func TestCheckerParseDocsConcurrently(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	primary := createDocTree(assert, dir)
+
+	expectedNames, expectedFindings, err := checkDocTree(primary, Options{
+		CollectFindings: true,
+		Workers:         1,
+	})
+	assert.NoError(err)
+
+	assert.Len(expectedNames, testDocCount+1)
+	assert.Equal(primary, expectedNames[0])
+
+	// One broken link in every third document
+	assert.Len(expectedFindings, (testDocCount+2)/3)
+
+	for _, workers := range []int{2, 4, testDocCount * 2} {
+		for i := 0; i < 5; i++ {
+			msg := fmt.Sprintf("workers: %d, run: %d", workers, i)
+
+			names, findings, err := checkDocTree(primary, Options{
+				CollectFindings: true,
+				Workers:         workers,
+			})
+
+			assert.NoError(err, msg)
+			assert.Equal(expectedNames, names, msg)
+			assert.Equal(expectedFindings, findings, msg)
+		}
+	}
+}
+
+func TestCheckerParseDocsFirstError(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	primary := createDocTree(assert, dir)
+
+	_, _, expectedErr := checkDocTree(primary, Options{Workers: 1})
+	assert.Error(expectedErr)
+
+	for i := 0; i < 5; i++ {
+		_, _, err := checkDocTree(primary, Options{Workers: testDocCount})
+		assert.Equal(expectedErr, err)
+	}
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	bf "gopkg.in/russross/blackfriday.v2"
+)
+
+// newCodeBlock creates a new CodeBlock.
+func newCodeBlock(doc *Doc, content, info string) CodeBlock {
+	info = strings.TrimSpace(info)
+
+	language := ""
+
+	if fields := strings.Fields(info); len(fields) > 0 {
+		language = fields[0]
+	}
+
+	hash := sha256.Sum256([]byte(content))
+
+	lines := strings.Count(content, "\n")
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		lines++
+	}
+
+	return CodeBlock{
+		Doc:      doc,
+		Language: language,
+		Info:     info,
+		Content:  content,
+		Lines:    lines,
+		Hash:     hex.EncodeToString(hash[:]),
+	}
+}
+
+// codeBlockLine returns the line number the specified code block node starts
+// on, or zero if it cannot be determined.
+//
+// Nodes are visited in document order, so the search for each block starts
+// from the parse offset to handle duplicate blocks, and the parse offset is
+// then moved past the block so its contents are not mistaken for later
+// nodes.
+func (d *Doc) codeBlockLine(node *bf.Node) int {
+	// Only the first line is used since the lines of indented blocks (and
+	// blocks in lists) have had their indentation removed.
+	needle := node.Literal
+
+	if i := bytes.IndexByte(needle, '\n'); i >= 0 {
+		needle = needle[:i]
+	}
+
+	fence := len(needle) == 0
+
+	if fence {
+		// Nothing to search for, so look for the fence instead.
+		needle = bytes.Repeat([]byte{node.FenceChar}, node.FenceLength)
+	}
+
+	offset, _ := d.find(string(needle))
+	if offset < 0 {
+		return 0
+	}
+
+	d.parseOffset = offset + len(needle)
+
+	if bytes.Contains(bytes.TrimRight(node.Literal, "\n"), []byte("\n")) {
+		d.skip(node.Literal)
+	}
+
+	line, _ := d.offsetPosition(offset)
+
+	if node.IsFenced && !fence {
+		// Report the line of the opening fence.
+		line--
+	}
+
+	return line
+}
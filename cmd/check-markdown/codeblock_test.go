@@ -0,0 +1,91 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCodeBlock(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		content          string
+		info             string
+		expectedLanguage string
+		expectedLines    int
+	}
+
+	data := []testData{
+		{"", "", "", 0},
+		{"foo\n", "", "", 1},
+		{"foo", "bash", "bash", 1},
+		{"foo\nbar\n", " bash  check ", "bash", 2},
+		{"foo\n\nbar\n", "go", "go", 3},
+	}
+
+	logger := logrus.WithField("test", "true")
+	doc := NewChecker(logger, Options{}).newDoc("foo")
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
+
+		block := newCodeBlock(doc, d.content, d.info)
+
+		assert.Equal(doc, block.Doc, msg)
+		assert.Equal(d.content, block.Content, msg)
+		assert.Equal(d.expectedLanguage, block.Language, msg)
+		assert.Equal(d.expectedLines, block.Lines, msg)
+		assert.Len(block.Hash, 64, msg)
+	}
+
+	assert.Equal(newCodeBlock(doc, "foo", "").Hash, newCodeBlock(doc, "foo", "bash").Hash)
+	assert.NotEqual(newCodeBlock(doc, "foo", "").Hash, newCodeBlock(doc, "bar", "").Hash)
+}
+
+func TestDocCodeBlocks(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "code.md")
+
+	contents := "# Code\n\n```bash\n$ ls\n```\n\n```\n$ ls\n```\n\n    indented\n"
+
+	err = createFile(file, contents)
+	assert.NoError(err)
+
+	logger := logrus.WithField("test", "true")
+	doc := NewChecker(logger, Options{}).newDoc(file)
+	doc.ListMode = true
+
+	err = doc.parse()
+	assert.NoError(err)
+
+	assert.Len(doc.CodeBlocks, 3)
+
+	assert.Equal("bash", doc.CodeBlocks[0].Language)
+	assert.Equal(3, doc.CodeBlocks[0].Line)
+
+	// Duplicate content, so the position must be found after the
+	// previous block.
+	assert.Equal("", doc.CodeBlocks[1].Language)
+	assert.Equal(7, doc.CodeBlocks[1].Line)
+	assert.Equal(doc.CodeBlocks[0].Hash, doc.CodeBlocks[1].Hash)
+
+	assert.Equal("indented\n", doc.CodeBlocks[2].Content)
+	assert.Equal(11, doc.CodeBlocks[2].Line)
+}
@@ -10,17 +10,20 @@ import (
 	"fmt"
 	"os"
 	"sort"
-
-	"github.com/sirupsen/logrus"
 )
 
 var outputFile = os.Stdout
 
 // displayHandler is an interface that all output display handlers
 // (formatters) must implement.
+//
+// Each method is passed the list of documents to display details of.
 type DisplayHandler interface {
-	DisplayHeadings(d *Doc) error
-	DisplayLinks(d *Doc) error
+	DisplayHeadings(docs []*Doc) error
+	DisplayLinks(docs []*Doc) error
+	DisplayCodeBlocks(docs []*Doc) error
+	DisplayImages(docs []*Doc) error
+	DisplayFrontMatter(docs []*Doc) error
 }
 
 // DisplayHandlers encapsulates the list of available display handlers.
@@ -45,6 +48,7 @@ func NewDisplayHandlers(tsvSeparator string, disableHeader bool) *DisplayHandler
 
 		handlers[textFormat] = NewDisplayText(outputFile)
 		handlers[tsvFormat] = NewDisplayTSV(outputFile, separator, disableHeader)
+		handlers[jsonFormat] = NewDisplayJSON(outputFile)
 	}
 
 	h := &DisplayHandlers{
@@ -78,19 +82,25 @@ func (d *DisplayHandlers) Get() []string {
 	return formats
 }
 
-func show(inputFilename string, logger *logrus.Entry, handler DisplayHandler, what DataToShow) error {
-	var fn func(*Doc) error
+func show(checker *Checker, inputFilename string, singleDocOnly bool, handler DisplayHandler, what DataToShow) error {
+	var fn func([]*Doc) error
 
 	switch what {
 	case showHeadings:
 		fn = handler.DisplayHeadings
 	case showLinks:
 		fn = handler.DisplayLinks
+	case showCodeBlocks:
+		fn = handler.DisplayCodeBlocks
+	case showImages:
+		fn = handler.DisplayImages
+	case showFrontMatter:
+		fn = handler.DisplayFrontMatter
 	default:
 		return fmt.Errorf("unknown show option: %v", what)
 	}
 
-	doc := newDoc(inputFilename, logger)
+	doc := checker.newDoc(inputFilename)
 	doc.ListMode = true
 
 	err := doc.parse()
@@ -98,5 +108,15 @@ func show(inputFilename string, logger *logrus.Entry, handler DisplayHandler, wh
 		return err
 	}
 
-	return fn(doc)
+	if !singleDocOnly {
+		if err := checker.parseDocs(true); err != nil {
+			return err
+		}
+	}
+
+	if count := len(checker.Findings()); count > 0 {
+		checker.Logger.WithField("problem-count", count).Debug("Ignoring problems found in documents")
+	}
+
+	return fn(checker.listDocs(doc))
 }
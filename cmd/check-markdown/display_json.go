@@ -0,0 +1,159 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type displayJSON struct {
+	file *os.File
+}
+
+type jsonHeading struct {
+	Document string `json:"document"`
+	Name     string `json:"name"`
+	MDName   string `json:"md-name"`
+	LinkName string `json:"link-name"`
+	Level    int    `json:"level"`
+}
+
+type jsonLink struct {
+	Document     string `json:"document"`
+	Address      string `json:"address"`
+	ResolvedPath string `json:"resolved-path,omitempty"`
+	Description  string `json:"description"`
+	Type         string `json:"type"`
+}
+
+type jsonCodeBlock struct {
+	Document string `json:"document"`
+	Line     int    `json:"line"`
+	Language string `json:"language"`
+	Info     string `json:"info"`
+	Lines    int    `json:"lines"`
+	Hash     string `json:"hash"`
+	Content  string `json:"content"`
+}
+
+type jsonImage struct {
+	Document    string `json:"document"`
+	Address     string `json:"address"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description"`
+}
+
+type jsonFrontMatter struct {
+	Document string `json:"document"`
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+}
+
+func NewDisplayJSON(file *os.File) DisplayHandler {
+	return &displayJSON{
+		file: file,
+	}
+}
+
+func (d *displayJSON) display(value interface{}) error {
+	encoder := json.NewEncoder(d.file)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+func (d *displayJSON) DisplayLinks(docs []*Doc) error {
+	links := []jsonLink{}
+
+	for _, doc := range docs {
+		for _, linkList := range doc.Links {
+			for _, l := range linkList {
+				links = append(links, jsonLink{
+					Document:     doc.Name,
+					Address:      l.Address,
+					ResolvedPath: l.ResolvedPath,
+					Description:  l.Description,
+					Type:         l.Type.String(),
+				})
+			}
+		}
+	}
+
+	return d.display(links)
+}
+
+func (d *displayJSON) DisplayHeadings(docs []*Doc) error {
+	headings := []jsonHeading{}
+
+	for _, doc := range docs {
+		for _, h := range doc.Headings {
+			headings = append(headings, jsonHeading{
+				Document: doc.Name,
+				Name:     h.Name,
+				MDName:   h.MDName,
+				LinkName: h.LinkName,
+				Level:    h.Level,
+			})
+		}
+	}
+
+	return d.display(headings)
+}
+
+func (d *displayJSON) DisplayCodeBlocks(docs []*Doc) error {
+	blocks := []jsonCodeBlock{}
+
+	for _, doc := range docs {
+		for _, c := range doc.CodeBlocks {
+			blocks = append(blocks, jsonCodeBlock{
+				Document: doc.Name,
+				Line:     c.Line,
+				Language: c.Language,
+				Info:     c.Info,
+				Lines:    c.Lines,
+				Hash:     c.Hash,
+				Content:  c.Content,
+			})
+		}
+	}
+
+	return d.display(blocks)
+}
+
+func (d *displayJSON) DisplayImages(docs []*Doc) error {
+	images := []jsonImage{}
+
+	for _, doc := range docs {
+		for _, i := range doc.Images {
+			images = append(images, jsonImage{
+				Document:    doc.Name,
+				Address:     i.Address,
+				Title:       i.Title,
+				Description: i.Description,
+			})
+		}
+	}
+
+	return d.display(images)
+}
+
+func (d *displayJSON) DisplayFrontMatter(docs []*Doc) error {
+	entries := []jsonFrontMatter{}
+
+	for _, doc := range docs {
+		for _, e := range doc.FrontMatter {
+			entries = append(entries, jsonFrontMatter{
+				Document: doc.Name,
+				Key:      e.Key,
+				Value:    e.Value,
+			})
+		}
+	}
+
+	return d.display(entries)
+}
@@ -20,12 +20,14 @@ func NewDisplayText(file *os.File) DisplayHandler {
 	}
 }
 
-func (d *displayText) DisplayLinks(doc *Doc) error {
-	for _, linkList := range doc.Links {
-		for _, link := range linkList {
-			err := d.displayLink(link)
-			if err != nil {
-				return err
+func (d *displayText) DisplayLinks(docs []*Doc) error {
+	for _, doc := range docs {
+		for _, linkList := range doc.Links {
+			for _, link := range linkList {
+				err := d.displayLink(link)
+				if err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -39,11 +41,13 @@ func (d *displayText) displayLink(l Link) error {
 	return err
 }
 
-func (d *displayText) DisplayHeadings(doc *Doc) error {
-	for _, h := range doc.Headings {
-		err := d.displayHeading(h)
-		if err != nil {
-			return err
+func (d *displayText) DisplayHeadings(docs []*Doc) error {
+	for _, doc := range docs {
+		for _, h := range doc.Headings {
+			err := d.displayHeading(h)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -55,3 +59,57 @@ func (d *displayText) displayHeading(h Heading) error {
 
 	return err
 }
+
+func (d *displayText) DisplayCodeBlocks(docs []*Doc) error {
+	for _, doc := range docs {
+		for _, c := range doc.CodeBlocks {
+			err := d.displayCodeBlock(c)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// displayCodeBlock displays the specified code block. The content is not
+// shown as it may span multiple lines.
+func (d *displayText) displayCodeBlock(c CodeBlock) error {
+	_, err := fmt.Fprintf(d.file, "{Doc:%v Line:%d Language:%s Info:%s Lines:%d Hash:%s}\n",
+		c.Doc, c.Line, c.Language, c.Info, c.Lines, c.Hash)
+
+	return err
+}
+
+func (d *displayText) DisplayImages(docs []*Doc) error {
+	for _, doc := range docs {
+		for _, i := range doc.Images {
+			err := d.displayImage(i)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *displayText) displayImage(i Image) error {
+	_, err := fmt.Fprintf(d.file, "%+v\n", i)
+
+	return err
+}
+
+func (d *displayText) DisplayFrontMatter(docs []*Doc) error {
+	for _, doc := range docs {
+		for _, e := range doc.FrontMatter {
+			_, err := fmt.Fprintf(d.file, "{Doc:%v Key:%s Value:%q}\n", doc, e.Key, e.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
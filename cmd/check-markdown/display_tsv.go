@@ -27,46 +27,83 @@ func NewDisplayTSV(file *os.File, separator rune, disableHeader bool) DisplayHan
 	return tsv
 }
 
-func (d *displayTSV) DisplayLinks(doc *Doc) error {
+// display writes the specified header (unless disabled) and records.
+func (d *displayTSV) display(header []string, records [][]string) error {
 	if !d.disableHeader {
-		record := linkHeaderRecord()
+		if err := d.writer.Write(header); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range records {
 		if err := d.writer.Write(record); err != nil {
 			return err
 		}
 	}
 
-	for _, linkList := range doc.Links {
-		for _, link := range linkList {
-			record := linkToRecord(link)
+	d.writer.Flush()
+
+	return d.writer.Error()
+}
+
+func (d *displayTSV) DisplayLinks(docs []*Doc) error {
+	var records [][]string
 
-			if err := d.writer.Write(record); err != nil {
-				return err
+	for _, doc := range docs {
+		for _, linkList := range doc.Links {
+			for _, link := range linkList {
+				records = append(records, linkToRecord(link))
 			}
 		}
 	}
 
-	d.writer.Flush()
+	return d.display(linkHeaderRecord(), records)
+}
 
-	return d.writer.Error()
+func (d *displayTSV) DisplayHeadings(docs []*Doc) error {
+	var records [][]string
+
+	for _, doc := range docs {
+		for _, h := range doc.Headings {
+			records = append(records, headingToRecord(doc, h))
+		}
+	}
+
+	return d.display(headingHeaderRecord(), records)
 }
 
-func (d *displayTSV) DisplayHeadings(doc *Doc) error {
-	if !d.disableHeader {
-		record := headingHeaderRecord()
-		if err := d.writer.Write(record); err != nil {
-			return err
+func (d *displayTSV) DisplayCodeBlocks(docs []*Doc) error {
+	var records [][]string
+
+	for _, doc := range docs {
+		for _, c := range doc.CodeBlocks {
+			records = append(records, codeBlockToRecord(c))
 		}
 	}
 
-	for _, l := range doc.Headings {
-		record := headingToRecord(l)
+	return d.display(codeBlockHeaderRecord(), records)
+}
 
-		if err := d.writer.Write(record); err != nil {
-			return err
+func (d *displayTSV) DisplayImages(docs []*Doc) error {
+	var records [][]string
+
+	for _, doc := range docs {
+		for _, i := range doc.Images {
+			records = append(records, imageToRecord(i))
 		}
 	}
 
-	d.writer.Flush()
+	return d.display(imageHeaderRecord(), records)
+}
 
-	return d.writer.Error()
+func (d *displayTSV) DisplayFrontMatter(docs []*Doc) error {
+	var records [][]string
+
+	for _, doc := range docs {
+		for _, e := range doc.FrontMatter {
+			records = append(records, frontMatterToRecord(doc, e))
+		}
+	}
+
+	return d.display(frontMatterHeaderRecord(), records)
 }
@@ -9,50 +9,67 @@ package main
 import (
 	"errors"
 	"fmt"
-
-	"github.com/sirupsen/logrus"
+	"sort"
 )
 
-// Details of the main document, and all other documents it references.
-// Key: document name.
-var docs map[string]*Doc
+// newDoc creates a new document and adds it to the checkers document set.
+func (c *Checker) newDoc(name string) *Doc {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-func init() {
-	docs = make(map[string]*Doc)
+	return c.addDoc(name)
 }
 
-// newDoc creates a new document.
-func newDoc(name string, logger *logrus.Entry) *Doc {
+// addDoc creates a new document and adds it to the checkers document set.
+// The caller must hold the lock.
+func (c *Checker) addDoc(name string) *Doc {
 	d := &Doc{
 		Name:     name,
 		Headings: make(map[string]Heading),
 		Links:    make(map[string][]Link),
 		Parsed:   false,
 		ShowTOC:  false,
-		Logger:   logger,
+		checker:  c,
 	}
 
-	d.Logger = logger.WithField("file", d.Name)
+	d.Logger = c.Logger.WithField("file", d.Name)
 
 	// add to the hash
-	docs[name] = d
+	c.docs[name] = d
 
 	return d
 }
 
 // getDoc returns the Doc structure represented by the specified name,
-// creating it and adding to the docs map if necessary.
-func getDoc(name string, logger *logrus.Entry) (*Doc, error) {
+// creating it and adding to the checkers document set if necessary.
+func (c *Checker) getDoc(name string) (*Doc, error) {
 	if name == "" {
 		return &Doc{}, errors.New("need doc name")
 	}
 
-	doc, ok := docs[name]
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	doc, ok := c.docs[name]
 	if ok {
 		return doc, nil
 	}
 
-	return newDoc(name, logger), nil
+	return c.addDoc(name), nil
+}
+
+// linkAddresses returns the addresses of all links in the document, sorted
+// to ensure the document is always checked in the same order.
+func (d *Doc) linkAddresses() []string {
+	var addresses []string
+
+	for addr := range d.Links {
+		addresses = append(addresses, addr)
+	}
+
+	sort.Strings(addresses)
+
+	return addresses
 }
 
 // hasHeading returns true if the specified heading exists for the document.
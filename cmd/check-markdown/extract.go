@@ -44,6 +44,29 @@ func linkDescription(l *bf.Node) (string, error) {
 	return text, nil
 }
 
+// imageDescription extracts the alternate text from the specified image
+// node.
+func imageDescription(i *bf.Node) (string, error) {
+	if err := checkNode(i, bf.Image); err != nil {
+		return "", err
+	}
+
+	text := ""
+
+	for node := i.FirstChild; node != nil; node = node.Next {
+		switch node.Type {
+		case bf.Code:
+			text += string(node.Literal)
+		case bf.Text:
+			text += string(node.Literal)
+		default:
+			logger.WithField("node", node).Debug("ignoring node")
+		}
+	}
+
+	return text, nil
+}
+
 // headingName extracts the heading name from the specified Heading node in
 // plain text, and markdown. The latter is used for creating TOC's which need
 // to include the original markdown value.
@@ -0,0 +1,242 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Severity describes how serious a Finding is.
+type Severity string
+
+const (
+	severityError   Severity = "error"
+	severityWarning Severity = "warning"
+)
+
+// Identifiers for each type of problem the checker can report.
+const (
+	ruleParseError          = "parse-error"
+	ruleDuplicateHeading    = "duplicate-heading"
+	ruleBlankLinkDesc       = "blank-link-description"
+	ruleMissingFile         = "missing-file"
+	ruleInvalidInternalLink = "invalid-internal-link"
+	ruleInvalidExternalLink = "invalid-external-link"
+	ruleSnippetLanguage     = "snippet-unsupported-language"
+	ruleSnippetFailed       = "snippet-failed"
+	ruleSnippetTimeout      = "snippet-timeout"
+	ruleSnippetOutput       = "snippet-output-mismatch"
+)
+
+// ruleDescriptions provides a short summary of every rule.
+var ruleDescriptions = map[string]string{
+	ruleParseError:          "Document could not be parsed",
+	ruleDuplicateHeading:    "Headings must be unique within a document",
+	ruleBlankLinkDesc:       "Link must have a description (strict mode only)",
+	ruleMissingFile:         "Link refers to a file that does not exist",
+	ruleInvalidInternalLink: "Link refers to a heading that does not exist in this document",
+	ruleInvalidExternalLink: "Link refers to a heading that does not exist in another document",
+	ruleSnippetLanguage:     "Snippet is written in a language that cannot be run",
+	ruleSnippetFailed:       "Snippet exited with an error",
+	ruleSnippetTimeout:      "Snippet did not finish in time",
+	ruleSnippetOutput:       "Snippet output does not match the expected output",
+}
+
+// Finding describes a single problem found in a document.
+type Finding struct {
+	// Rule that was violated.
+	RuleID string `json:"rule-id"`
+
+	Severity Severity `json:"severity"`
+
+	// Document the problem was found in.
+	File string `json:"file"`
+
+	// Position of the problem in the document (1-based). Zero if the
+	// position could not be determined.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+
+	Message string `json:"message"`
+
+	// Suggested replacement text that would resolve the problem (if known).
+	Fix string `json:"fix,omitempty"`
+}
+
+// FindingError is an error that describes a Finding.
+type FindingError struct {
+	Finding
+}
+
+// Error returns the finding in the same format as Doc.Errorf.
+func (e *FindingError) Error() string {
+	return fmt.Sprintf("file=%q: %s", e.File, e.Message)
+}
+
+// Findingf creates a FindingError for the problem described by format at the
+// specified position in the document.
+func (d *Doc) Findingf(ruleID string, line, column int, fix, format string, args ...interface{}) error {
+	return &FindingError{
+		Finding: Finding{
+			RuleID:   ruleID,
+			Severity: severityError,
+			File:     d.Name,
+			Line:     line,
+			Column:   column,
+			Message:  fmt.Sprintf(format, args...),
+			Fix:      fix,
+		},
+	}
+}
+
+// report handles the specified error. If findings are being collected and
+// the error describes a finding, it is recorded and nil returned, allowing
+// the caller to continue checking. Otherwise, the error is returned.
+func (d *Doc) report(err error) error {
+	if err == nil || !d.checker.Options.CollectFindings {
+		return err
+	}
+
+	var findingErr *FindingError
+
+	finding := Finding{
+		RuleID:   ruleParseError,
+		Severity: severityError,
+		File:     d.Name,
+		Message:  err.Error(),
+	}
+
+	if errors.As(err, &findingErr) {
+		finding = findingErr.Finding
+	}
+
+	// Links are checked both when the document is parsed and when
+	// links between documents are checked, so duplicates are ignored.
+	d.checker.addFinding(finding)
+
+	return nil
+}
+
+// offsetPosition returns the 1-based line and column of the specified byte
+// offset in the document.
+func (d *Doc) offsetPosition(offset int) (line, column int) {
+	before := d.Data[:offset]
+
+	line = bytes.Count(before, []byte("\n")) + 1
+
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+
+	column = utf8.RuneCount(before[lineStart:]) + 1
+
+	return line, column
+}
+
+// find returns the offset of the first occurrence of any of the specified
+// strings in the document after the current parse offset, along with the
+// string found. If none of the strings can be found, -1 is returned.
+func (d *Doc) find(needles ...string) (offset int, found string) {
+	if d.parseOffset > len(d.Data) {
+		return -1, ""
+	}
+
+	for _, needle := range needles {
+		if needle == "" {
+			continue
+		}
+
+		index := bytes.Index(d.Data[d.parseOffset:], []byte(needle))
+		if index >= 0 {
+			return d.parseOffset + index, needle
+		}
+	}
+
+	return -1, ""
+}
+
+// position returns the 1-based line and column of the first occurrence of
+// any of the specified strings in the document after the current parse
+// offset. If none of the strings can be found, zero values are returned.
+func (d *Doc) position(needles ...string) (line, column int) {
+	offset, _ := d.find(needles...)
+	if offset < 0 {
+		return 0, 0
+	}
+
+	return d.offsetPosition(offset)
+}
+
+// skip moves the parse offset past the next occurrence of the specified
+// text, which does not contain any nodes that need to be found (such as
+// code or HTML).
+func (d *Doc) skip(text []byte) {
+	// The lines of indented code blocks have had their indentation
+	// removed, so only the last line is searched for.
+	text = bytes.TrimRight(text, "\n")
+
+	if i := bytes.LastIndexByte(text, '\n'); i >= 0 {
+		text = text[i+1:]
+	}
+
+	if offset, found := d.find(string(text)); offset >= 0 {
+		d.parseOffset = offset + len(found)
+	}
+}
+
+// linkPosition returns the position of the specified link in the document.
+// Links are positioned in document order as the document is parsed, so the
+// parse offset is moved past the link.
+func (d *Doc) linkPosition(link Link) (line, column int) {
+	address := link.Address
+
+	if link.Type == internalLink {
+		address = anchorPrefix + address
+	}
+
+	offset, found := d.find("](" + address)
+	if offset < 0 {
+		// Reference links and autolinks. The parse offset is not
+		// moved since link definitions may appear anywhere.
+		return d.position(address)
+	}
+
+	d.parseOffset = offset + len(found)
+
+	// Skip the end of the link description.
+	return d.offsetPosition(offset + len("]("))
+}
+
+// headingPosition returns the position of the specified heading in the
+// document. Headings are positioned in document order as the document is
+// parsed, so the parse offset is moved to the start of the heading text
+// (which may contain links).
+func (d *Doc) headingPosition(heading Heading) (line, column int) {
+	var needles []string
+
+	// Headings created from text nodes do not have a markdown name.
+	if heading.MDName != "" {
+		needles = append(needles, " "+heading.MDName+"\n",
+			heading.MDName+"\n", heading.MDName)
+	}
+
+	offset, found := d.find(append(needles, heading.Name)...)
+	if offset < 0 {
+		return 0, 0
+	}
+
+	if strings.HasPrefix(found, " ") {
+		// Skip the space following the heading prefix.
+		offset++
+	}
+
+	d.parseOffset = offset
+
+	return d.offsetPosition(offset)
+}
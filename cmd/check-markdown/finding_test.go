@@ -0,0 +1,174 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocPosition(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		needle         string
+		expectedLine   int
+		expectedColumn int
+	}
+
+	logger := logrus.WithField("test", "true")
+
+	doc := NewChecker(logger, Options{}).newDoc("foo")
+	doc.Data = []byte("# Title\n\nSee [bar](#bar).\n\n## bär\n\nA [bar](bar.md).\n")
+
+	data := []testData{
+		{"", 0, 0},
+		{"not found", 0, 0},
+
+		{"# Title", 1, 1},
+		{"Title", 1, 3},
+		{"See", 3, 1},
+		{"(#bar)", 3, 10},
+		{"bär", 5, 4},
+		{"A [bar]", 7, 1},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
+
+		line, column := doc.position(d.needle)
+
+		assert.Equal(d.expectedLine, line, msg)
+		assert.Equal(d.expectedColumn, column, msg)
+	}
+
+	line, column := doc.linkPosition(Link{Address: "bar", Type: internalLink})
+	assert.Equal(3, line)
+	assert.Equal(11, column)
+
+	line, column = doc.linkPosition(Link{Address: "bar.md", Type: externalLink})
+	assert.Equal(7, line)
+	assert.Equal(9, column)
+}
+
+func TestDocHeadingPosition(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := logrus.WithField("test", "true")
+
+	doc := NewChecker(logger, Options{}).newDoc("foo")
+	doc.Data = []byte("# foo\n\n## bar\n\n## bar\n")
+
+	heading := Heading{Name: "bar", MDName: "bar", LinkName: "bar", Level: 2}
+
+	// Headings are found in document order
+	line, column := doc.headingPosition(heading)
+	assert.Equal(3, line)
+	assert.Equal(4, column)
+
+	line, column = doc.headingPosition(heading)
+	assert.Equal(5, line)
+	assert.Equal(4, column)
+}
+
+func TestFindingPositions(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// The link and heading also appear in code, which must not be
+	// reported as their positions.
+	data := []string{
+		"# Title",
+		"",
+		"```",
+		"[missing](missing.md)",
+		"## Foo",
+		"```",
+		"",
+		"## Foo",
+		"",
+		"See `[missing](missing.md)` and [missing](missing.md).",
+		"",
+		"    ## Foo",
+		"",
+		"## Foo",
+		"",
+	}
+
+	primary := filepath.Join(dir, readmeName)
+
+	err = createFile(primary, strings.Join(data, "\n"))
+	assert.NoError(err)
+
+	_, findings, err := checkDocTree(primary, Options{
+		CollectFindings: true,
+		Workers:         1,
+	})
+	assert.NoError(err)
+
+	positions := make(map[string][]int)
+
+	for _, finding := range findings {
+		positions[finding.RuleID] = []int{finding.Line, finding.Column}
+	}
+
+	assert.Equal(map[string][]int{
+		ruleDuplicateHeading: {14, 4},
+		ruleMissingFile:      {10, 43},
+	}, positions)
+}
+
+func TestDocReport(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := logrus.WithField("test", "true")
+	checker := NewChecker(logger, Options{})
+	doc := checker.newDoc("foo")
+
+	findingErr := doc.Findingf(ruleMissingFile, 1, 2, "", "file %q missing", "bar.md")
+	otherErr := errors.New("some other error")
+
+	assert.NoError(doc.report(nil))
+	assert.Equal(findingErr, doc.report(findingErr))
+	assert.Equal(otherErr, doc.report(otherErr))
+	assert.Empty(checker.Findings())
+
+	checker.Options.CollectFindings = true
+
+	assert.NoError(doc.report(findingErr))
+	assert.NoError(doc.report(otherErr))
+
+	// Duplicates should be ignored
+	assert.NoError(doc.report(findingErr))
+
+	findings := checker.Findings()
+
+	assert.Len(findings, 2)
+
+	assert.Equal(Finding{
+		RuleID:   ruleMissingFile,
+		Severity: severityError,
+		File:     "foo",
+		Line:     1,
+		Column:   2,
+		Message:  `file "bar.md" missing`,
+	}, findings[0])
+
+	assert.Equal(ruleParseError, findings[1].RuleID)
+	assert.Equal(otherErr.Error(), findings[1].Message)
+}
@@ -0,0 +1,99 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// Line that starts and (normally) ends a YAML front matter block.
+	frontMatterDelimiter = "---"
+
+	// Alternative YAML document end marker that can end a front matter
+	// block.
+	frontMatterEndMarker = "..."
+)
+
+// splitFrontMatter splits the specified document contents into the YAML
+// front matter (without the delimiters) and the markdown body. If the
+// document does not start with a front matter block, frontMatter will be nil
+// and body will be the entire document.
+//
+// Since a document can also start with a horizontal rule, the block is only
+// considered to be front matter if it is closed and is a YAML mapping.
+func splitFrontMatter(data []byte) (frontMatter, body []byte) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	if len(lines) == 0 || strings.TrimSpace(string(lines[0])) != frontMatterDelimiter {
+		return nil, data
+	}
+
+	offset := len(lines[0])
+
+	for _, line := range lines[1:] {
+		trimmed := strings.TrimSpace(string(line))
+
+		if trimmed == frontMatterDelimiter || trimmed == frontMatterEndMarker {
+			frontMatter = data[len(lines[0]):offset]
+			body = data[offset+len(line):]
+
+			if _, err := parseFrontMatter(frontMatter); err != nil {
+				// Not YAML, so this is not front matter.
+				return nil, data
+			}
+
+			return frontMatter, body
+		}
+
+		offset += len(line)
+	}
+
+	// No end delimiter, so this is not front matter.
+	return nil, data
+}
+
+// parseFrontMatter converts the specified YAML front matter into a list of
+// top-level entries.
+func parseFrontMatter(frontMatter []byte) ([]FrontMatterEntry, error) {
+	var fields yaml.MapSlice
+
+	if err := yaml.Unmarshal(frontMatter, &fields); err != nil {
+		return nil, err
+	}
+
+	var entries []FrontMatterEntry
+
+	for _, field := range fields {
+		var value string
+
+		switch v := field.Value.(type) {
+		case nil:
+			value = ""
+		case string, bool, int, int64, uint64, float64:
+			value = fmt.Sprintf("%v", v)
+		default:
+			bytes, err := yaml.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+
+			value = strings.TrimSpace(string(bytes))
+		}
+
+		entries = append(entries, FrontMatterEntry{
+			Key:   fmt.Sprintf("%v", field.Key),
+			Value: value,
+		})
+	}
+
+	return entries, nil
+}
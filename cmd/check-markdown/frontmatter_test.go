@@ -0,0 +1,123 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitFrontMatter(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		data                string
+		expectedFrontMatter string
+		expectedBody        string
+	}
+
+	data := []testData{
+		{"", "", ""},
+		{"# foo\n", "", "# foo\n"},
+		{"foo\n---\n", "", "foo\n---\n"},
+
+		// No end delimiter
+		{"---\nfoo: bar\n", "", "---\nfoo: bar\n"},
+
+		// Horizontal rules rather than front matter
+		{"---\nSee [foo](foo.md).\n\n---\n", "", "---\nSee [foo](foo.md).\n\n---\n"},
+		{"---\n- foo\n---\n", "", "---\n- foo\n---\n"},
+		{"---\nfoo: [\n---\n", "", "---\nfoo: [\n---\n"},
+
+		{"---\n---\n# foo\n", "", "# foo\n"},
+		{"---\nfoo: bar\n---\n# foo\n", "foo: bar\n", "# foo\n"},
+		{"---\nfoo: bar\n...\n# foo\n", "foo: bar\n", "# foo\n"},
+		{"---\nfoo: bar\nbaz: 1\n---\n", "foo: bar\nbaz: 1\n", ""},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
+
+		frontMatter, body := splitFrontMatter([]byte(d.data))
+
+		assert.Equal(d.expectedFrontMatter, string(frontMatter), msg)
+		assert.Equal(d.expectedBody, string(body), msg)
+	}
+}
+
+func TestParseFrontMatter(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		frontMatter     string
+		expectedEntries []FrontMatterEntry
+		expectError     bool
+	}
+
+	data := []testData{
+		{"", nil, false},
+		{"foo: [", nil, true},
+		{"- foo", nil, true},
+
+		{"foo: bar", []FrontMatterEntry{{"foo", "bar"}}, false},
+		{"foo:", []FrontMatterEntry{{"foo", ""}}, false},
+		{
+			"title: hello world\nweight: 3\ndraft: true\n",
+			[]FrontMatterEntry{
+				{"title", "hello world"},
+				{"weight", "3"},
+				{"draft", "true"},
+			},
+			false,
+		},
+		{"tags:\n  - a\n  - b\n", []FrontMatterEntry{{"tags", "- a\n- b"}}, false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
+
+		entries, err := parseFrontMatter([]byte(d.frontMatter))
+
+		if d.expectError {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedEntries, entries, msg)
+	}
+}
+
+func TestHorizontalRuleNotFrontMatter(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	primary := filepath.Join(dir, readmeName)
+
+	// The links between the horizontal rules must be checked
+	err = createFile(primary, "---\n\nSee [missing](missing.md).\n\n---\n\n# Title\n")
+	assert.NoError(err)
+
+	_, findings, err := checkDocTree(primary, Options{
+		CollectFindings: true,
+		Workers:         1,
+	})
+	assert.NoError(err)
+
+	if assert.Len(findings, 1) {
+		assert.Equal(ruleMissingFile, findings[0].RuleID)
+		assert.Equal(3, findings[0].Line)
+	}
+}
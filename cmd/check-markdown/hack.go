@@ -61,9 +61,9 @@ func (d *Doc) forceCreateHeadings(node *bf.Node) ([]Heading, error) {
 		heading.LinkName = id
 
 		headings = append(headings, heading)
-
-		extraHeadings++
 	}
 
+	d.checker.addExtraHeadings(len(headings))
+
 	return headings, nil
 }
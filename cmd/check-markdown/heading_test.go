@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -63,3 +64,17 @@ func TestNewHeading(t *testing.T) {
 		assert.Equal(h.LinkName, d.expectedLinkName, msg)
 	}
 }
+
+func TestHeadingToRecord(t *testing.T) {
+	assert := assert.New(t)
+
+	logger := logrus.WithField("test", "true")
+	doc := NewChecker(logger, Options{}).newDoc("foo.md")
+
+	h, err := newHeading("Foo bar", "Foo bar", 2)
+	assert.NoError(err)
+
+	// The document is added after the original fields
+	assert.Equal([]string{"Name", "Link", "Level", "Document"}, headingHeaderRecord())
+	assert.Equal([]string{"Foo bar", "foo-bar", "2", "foo.md"}, headingToRecord(doc, h))
+}
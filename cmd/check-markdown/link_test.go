@@ -56,8 +56,6 @@ func createLinkAndCategorise(assert *assert.Assertions, createLinkManually bool)
 		checkPath bool
 	}
 
-	docRoot = dir
-
 	data := []testData{
 		{"", "", -1, true, false},
 		{"a", "", -1, true, false},
@@ -93,7 +91,7 @@ func createLinkAndCategorise(assert *assert.Assertions, createLinkManually bool)
 		var link Link
 		var err error
 
-		doc := newDoc("foo", logger)
+		doc := NewChecker(logger, Options{DocRoot: dir}).newDoc("foo")
 
 		if createLinkManually {
 			link = Link{
@@ -184,7 +182,7 @@ func TestLinkHandleImplicitREADME(t *testing.T) {
 	logger := logrus.WithField("test", "true")
 
 	for i, d := range data {
-		doc := newDoc("foo", logger)
+		doc := NewChecker(logger, Options{}).newDoc("foo")
 
 		link := Link{
 			Doc:     doc,
@@ -0,0 +1,307 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Version of the link graph cache file format.
+const linkCacheVersion = 1
+
+// mdExtension is the file extension of markdown documents.
+const mdExtension = ".md"
+
+// Directories that are never searched for documents.
+var linkGraphSkipDirs = map[string]bool{
+	".git":   true,
+	"vendor": true,
+}
+
+// LinkCacheEntry records the documents a document links to.
+type LinkCacheEntry struct {
+	// Hex encoded SHA256 hash of the document contents.
+	Hash string `json:"hash"`
+
+	// Documents linked to, relative to the cache root directory.
+	Links []string `json:"links"`
+}
+
+// LinkCache is the persisted form of a LinkGraph.
+type LinkCache struct {
+	Version int `json:"version"`
+
+	// Key: document path, relative to the cache root directory.
+	Files map[string]LinkCacheEntry `json:"files"`
+}
+
+// LinkGraph describes the links between all documents below a directory.
+type LinkGraph struct {
+	Logger *logrus.Entry
+
+	// Directory containing the documents.
+	Root string
+
+	// Root directory for "absolute link paths".
+	DocRoot string
+
+	cache LinkCache
+
+	// Key: absolute path of document linked to.
+	// Value: absolute paths of documents which link to it.
+	referrers map[string][]string
+}
+
+// newLinkCache creates an empty link cache.
+func newLinkCache() LinkCache {
+	return LinkCache{
+		Version: linkCacheVersion,
+		Files:   make(map[string]LinkCacheEntry),
+	}
+}
+
+// readLinkCache reads the specified link cache file. If the file does not
+// exist or is from an incompatible version, an empty cache is returned.
+func readLinkCache(file string) (LinkCache, error) {
+	bytes, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return newLinkCache(), nil
+	} else if err != nil {
+		return LinkCache{}, err
+	}
+
+	var cache LinkCache
+
+	if err := json.Unmarshal(bytes, &cache); err != nil {
+		return LinkCache{}, fmt.Errorf("invalid link cache %q: %v", file, err)
+	}
+
+	if cache.Version != linkCacheVersion || cache.Files == nil {
+		return newLinkCache(), nil
+	}
+
+	return cache, nil
+}
+
+// writeLinkCache saves the specified link cache to the specified file.
+func writeLinkCache(file string, cache LinkCache) error {
+	bytes, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, bytes, 0644)
+}
+
+// NewLinkGraph creates a link graph for all documents below the specified
+// root directory. If cacheFile is not blank, links for documents whose
+// contents have not changed since the cache was last written are read from
+// the cache, and the cache is updated.
+func NewLinkGraph(logger *logrus.Entry, root, docRoot, cacheFile string) (*LinkGraph, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &LinkGraph{
+		Logger:    logger,
+		Root:      root,
+		DocRoot:   docRoot,
+		cache:     newLinkCache(),
+		referrers: make(map[string][]string),
+	}
+
+	if cacheFile != "" {
+		g.cache, err = readLinkCache(cacheFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The root may be below a symlink (which filepath.Walk does not
+	// follow for the root itself), so search the resolved directory but
+	// use paths below the root as specified, which match the paths
+	// returned by changedDocs.
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := findDocs(resolvedRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	newCache := newLinkCache()
+
+	for _, file := range files {
+		rel, err := filepath.Rel(resolvedRoot, file)
+		if err != nil {
+			return nil, err
+		}
+
+		file = filepath.Join(root, rel)
+
+		entry, err := g.entry(file)
+		if err != nil {
+			return nil, err
+		}
+
+		newCache.Files[filepath.ToSlash(rel)] = entry
+
+		for _, link := range entry.Links {
+			target := filepath.Join(root, filepath.FromSlash(link))
+
+			g.referrers[target] = append(g.referrers[target], file)
+		}
+	}
+
+	g.cache = newCache
+
+	if cacheFile != "" {
+		if err := writeLinkCache(cacheFile, g.cache); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// findDocs returns the absolute paths of all markdown documents below the
+// specified directory, sorted by name.
+func findDocs(root string) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if path != root && linkGraphSkipDirs[info.Name()] {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if strings.HasSuffix(path, mdExtension) {
+			files = append(files, path)
+		}
+
+		return nil
+	})
+
+	sort.Strings(files)
+
+	return files, err
+}
+
+// entry returns the cache entry for the specified document, either from the
+// cache (if the document has not changed) or by parsing the document.
+func (g *LinkGraph) entry(file string) (LinkCacheEntry, error) {
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return LinkCacheEntry{}, err
+	}
+
+	sum := sha256.Sum256(bytes)
+	hash := hex.EncodeToString(sum[:])
+
+	rel, err := filepath.Rel(g.Root, file)
+	if err != nil {
+		return LinkCacheEntry{}, err
+	}
+
+	if entry, ok := g.cache.Files[filepath.ToSlash(rel)]; ok && entry.Hash == hash {
+		return entry, nil
+	}
+
+	links, err := g.docLinks(file)
+	if err != nil {
+		return LinkCacheEntry{}, err
+	}
+
+	return LinkCacheEntry{
+		Hash:  hash,
+		Links: links,
+	}, nil
+}
+
+// docLinks returns the documents (relative to the graph root) that the
+// specified document links to, sorted by name.
+func (g *LinkGraph) docLinks(file string) ([]string, error) {
+	// Problems in the document are of no interest here.
+	checker := NewChecker(g.Logger, Options{
+		DocRoot:         g.DocRoot,
+		CollectFindings: true,
+	})
+
+	doc := checker.newDoc(file)
+	doc.ListMode = true
+
+	if err := doc.parse(); err != nil {
+		return nil, err
+	}
+
+	unique := make(map[string]bool)
+
+	for _, linkList := range doc.Links {
+		for _, link := range linkList {
+			if link.Type != externalLink || link.ResolvedPath == "" {
+				continue
+			}
+
+			path, err := filepath.Abs(link.ResolvedPath)
+			if err != nil {
+				return nil, err
+			}
+
+			rel, err := filepath.Rel(g.Root, path)
+			if err != nil {
+				return nil, err
+			}
+
+			unique[filepath.ToSlash(rel)] = true
+		}
+	}
+
+	links := []string{}
+
+	for link := range unique {
+		links = append(links, link)
+	}
+
+	sort.Strings(links)
+
+	return links, nil
+}
+
+// Referrers returns the absolute paths of the documents that link to the
+// specified document, sorted by name.
+func (g *LinkGraph) Referrers(file string) ([]string, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers := append([]string{}, g.referrers[path]...)
+
+	sort.Strings(referrers)
+
+	return referrers, nil
+}
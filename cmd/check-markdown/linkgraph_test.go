@@ -0,0 +1,144 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLinkGraph(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	primary := createDocTree(assert, dir)
+
+	// Should be ignored
+	vendorDir := filepath.Join(dir, "vendor")
+	err = os.MkdirAll(vendorDir, testDirMode)
+	assert.NoError(err)
+
+	err = createFile(filepath.Join(vendorDir, "vendored.md"), "[doc](../doc-00.md)\n")
+	assert.NoError(err)
+
+	logger := logrus.WithField("test", "true")
+
+	graph, err := NewLinkGraph(logger, dir, "", "")
+	assert.NoError(err)
+
+	assert.Len(graph.cache.Files, testDocCount+1)
+	assert.Equal([]string{"doc-01.md"}, graph.cache.Files["doc-00.md"].Links)
+
+	referrers, err := graph.Referrers(filepath.Join(dir, "doc-00.md"))
+	assert.NoError(err)
+	assert.Equal([]string{primary, filepath.Join(dir, "doc-19.md")}, referrers)
+
+	referrers, err = graph.Referrers(primary)
+	assert.NoError(err)
+	assert.Empty(referrers)
+}
+
+func TestLinkGraphCache(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	cacheFile := filepath.Join(dir, "cache.json")
+	docA := filepath.Join(dir, "a.md")
+	docB := filepath.Join(dir, "b.md")
+
+	err = createFile(docA, "# A\n\n[b](b.md)\n")
+	assert.NoError(err)
+
+	err = createFile(docB, "# B\n")
+	assert.NoError(err)
+
+	logger := logrus.WithField("test", "true")
+
+	_, err = NewLinkGraph(logger, dir, "", cacheFile)
+	assert.NoError(err)
+
+	cache, err := readLinkCache(cacheFile)
+	assert.NoError(err)
+	assert.Len(cache.Files, 2)
+	assert.Equal([]string{"b.md"}, cache.Files["a.md"].Links)
+
+	// Poison the cache entry: since the document is unchanged, the cached
+	// links should be used.
+	entry := cache.Files["a.md"]
+	entry.Links = []string{"cached.md"}
+	cache.Files["a.md"] = entry
+
+	err = writeLinkCache(cacheFile, cache)
+	assert.NoError(err)
+
+	graph, err := NewLinkGraph(logger, dir, "", cacheFile)
+	assert.NoError(err)
+	assert.Equal([]string{"cached.md"}, graph.cache.Files["a.md"].Links)
+
+	// Changing the document should invalidate the cache entry.
+	err = createFile(docA, "# A\n\nNo links.\n")
+	assert.NoError(err)
+
+	graph, err = NewLinkGraph(logger, dir, "", cacheFile)
+	assert.NoError(err)
+	assert.Empty(graph.cache.Files["a.md"].Links)
+	assert.NotEqual(entry.Hash, graph.cache.Files["a.md"].Hash)
+
+	referrers, err := graph.Referrers(docB)
+	assert.NoError(err)
+	assert.Empty(referrers)
+
+	// Removed documents should be dropped from the cache.
+	err = os.Remove(docB)
+	assert.NoError(err)
+
+	_, err = NewLinkGraph(logger, dir, "", cacheFile)
+	assert.NoError(err)
+
+	cache, err = readLinkCache(cacheFile)
+	assert.NoError(err)
+	assert.Len(cache.Files, 1)
+}
+
+func TestReadLinkCache(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "cache.json")
+
+	cache, err := readLinkCache(file)
+	assert.NoError(err)
+	assert.Empty(cache.Files)
+
+	err = createFile(file, "not json")
+	assert.NoError(err)
+
+	_, err = readLinkCache(file)
+	assert.Error(err)
+
+	err = createFile(file, `{"version": 999, "files": {"a.md": {"hash": "x"}}}`)
+	assert.NoError(err)
+
+	cache, err = readLinkCache(file)
+	assert.NoError(err)
+	assert.Equal(linkCacheVersion, cache.Version)
+	assert.Empty(cache.Files)
+}
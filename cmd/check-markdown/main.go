@@ -26,11 +26,16 @@ const (
 	// expand to the value of the document root.
 	absoluteLinkPrefix = "/"
 
-	showLinks    DataToShow = iota
-	showHeadings DataToShow = iota
+	showLinks       DataToShow = iota
+	showHeadings    DataToShow = iota
+	showCodeBlocks  DataToShow = iota
+	showImages      DataToShow = iota
+	showFrontMatter DataToShow = iota
 
 	textFormat          = "text"
 	tsvFormat           = "tsv"
+	jsonFormat          = "json"
+	sarifFormat         = "sarif"
 	defaultOutputFormat = textFormat
 	defaultSeparator    = "\t"
 )
@@ -41,8 +46,6 @@ var (
 	version = ""
 	commit  = ""
 
-	strict = false
-
 	// list entry character to use when generating TOCs
 	listPrefix = "*"
 
@@ -51,16 +54,6 @@ var (
 	errNeedFile = errors.New("need markdown file")
 )
 
-// Black Friday sometimes chokes on markdown (I know!!), so record how many
-// extra headings we found.
-var extraHeadings int
-
-// Root directory used to handle "absolute link paths" that start with a slash
-// to denote the "top directory", like this:
-//
-// [Foo](/absolute-link.md)
-var docRoot string
-
 var notes = fmt.Sprintf(`
 
 NOTES:
@@ -69,10 +62,8 @@ NOTES:
   denoting that the path that follows is an "absolute path" from the specified
   document root path.
 
-- The order the document nodes are parsed internally is not known to
-  this program. This means that if multiple errors exist in the document,
-  running this tool multiple times will error one *one* of the errors, but not
-  necessarily the same one as last time.
+- If multiple errors exist in the document, only the first one found is
+  reported, unless a structured check output format is specified.
 
 LIMITATIONS:
 
@@ -87,6 +78,12 @@ var formatFlag = cli.StringFlag{
 	Value: defaultOutputFormat,
 }
 
+var checkFormatFlag = cli.StringFlag{
+	Name:  "format",
+	Usage: fmt.Sprintf("display results in specified format (%s, %s or %s)", textFormat, jsonFormat, sarifFormat),
+	Value: defaultOutputFormat,
+}
+
 var separatorFlag = cli.StringFlag{
 	Name:  "separator",
 	Usage: fmt.Sprintf("use the specified separator character (%s format only)", tsvFormat),
@@ -127,9 +124,49 @@ func handleLogging(c *cli.Context) {
 	logger.Logger.SetLevel(logLevel)
 }
 
+// reportHandler returns the handler for the check output format specified,
+// or nil if results should be logged.
+func reportHandler(c *cli.Context) (ReportHandler, error) {
+	format := c.String("format")
+
+	if format == "" || format == textFormat {
+		return nil, nil
+	}
+
+	handler, ok := reportHandlers[format]
+	if !ok {
+		return nil, fmt.Errorf("no handler for format %q", format)
+	}
+
+	return handler, nil
+}
+
+// newChecker creates a Checker configured from the global options.
+func newChecker(c *cli.Context, collectFindings bool) *Checker {
+	return NewChecker(logger, Options{
+		DocRoot:         c.GlobalString("doc-root"),
+		Strict:          c.GlobalBool("strict"),
+		CollectFindings: collectFindings,
+		Workers:         c.GlobalInt("workers"),
+	})
+}
+
 func handleDoc(c *cli.Context, createTOC bool) error {
 	handleLogging(c)
 
+	if !createTOC && c.String("changed-since") != "" {
+		handler, err := reportHandler(c)
+		if err != nil {
+			return err
+		}
+
+		if handler != nil {
+			logger.Logger.Out = os.Stderr
+		}
+
+		return handleChangedDocs(c, handler)
+	}
+
 	if c.NArg() == 0 {
 		return errNeedFile
 	}
@@ -141,7 +178,26 @@ func handleDoc(c *cli.Context, createTOC bool) error {
 
 	singleDocOnly := c.GlobalBool("single-doc-only")
 
-	doc := newDoc(fileName, logger)
+	var handler ReportHandler
+
+	if !createTOC {
+		var err error
+
+		handler, err = reportHandler(c)
+		if err != nil {
+			return err
+		}
+	}
+
+	if handler != nil {
+		// Ensure the structured output is not mixed with log output.
+		logger.Logger.Out = os.Stderr
+	}
+
+	// Report every problem found when displaying structured output.
+	checker := newChecker(c, handler != nil)
+
+	doc := checker.newDoc(fileName)
 	doc.ShowTOC = createTOC
 
 	if createTOC {
@@ -155,36 +211,28 @@ func handleDoc(c *cli.Context, createTOC bool) error {
 		return err
 	}
 
+	docs := checker.Docs()
+
 	if singleDocOnly && len(docs) > 1 {
 		doc.Logger.Debug("Not checking referenced files at user request")
-		return nil
-	}
-
-	// Now handle all other docs that the main doc references.
-	// This requires care to avoid recursion.
-	for {
-		count := len(docs)
-		parsed := 0
-		for _, doc := range docs {
-			if doc.Parsed {
-				// Document has already been handled
-				parsed++
-				continue
-			}
 
-			if err := doc.parse(); err != nil {
-				return err
-			}
+		if handler == nil {
+			return nil
+		}
+	} else {
+		// Now handle all other docs that the main doc references.
+		if err := checker.parseDocs(false); err != nil {
+			return err
 		}
 
-		if parsed == count {
-			break
+		err = checker.checkIntraDocLinks()
+		if err != nil {
+			return err
 		}
 	}
 
-	err = handleIntraDocLinks()
-	if err != nil {
-		return err
+	if handler != nil {
+		return displayReport(checker, handler, doc)
 	}
 
 	if !createTOC {
@@ -192,6 +240,8 @@ func handleDoc(c *cli.Context, createTOC bool) error {
 		doc.showStats()
 	}
 
+	docs = checker.Docs()
+
 	count := len(docs)
 
 	if count > 1 {
@@ -211,6 +261,8 @@ func handleDoc(c *cli.Context, createTOC bool) error {
 	}
 
 	// Highlight blackfriday deficiencies
+	extraHeadings := checker.ExtraHeadings()
+
 	if !doc.ShowTOC && extraHeadings > 0 {
 		doc.Logger.WithField("extra-heading-count", extraHeadings).Debug("Found extra headings")
 	}
@@ -218,6 +270,122 @@ func handleDoc(c *cli.Context, createTOC bool) error {
 	return nil
 }
 
+// displayReport displays the results of checking the specified document
+// (and all the documents it references) using the specified handler. doc
+// may be nil if the user did not specify a document.
+func displayReport(checker *Checker, handler ReportHandler, doc *Doc) error {
+	report := checker.newReport(doc)
+
+	if err := handler.DisplayReport(report); err != nil {
+		return err
+	}
+
+	if count := report.errorCount(); count > 0 {
+		if doc == nil {
+			return fmt.Errorf("found %d problem(s)", count)
+		}
+
+		return fmt.Errorf("found %d problem(s) in %q", count, doc.Name)
+	}
+
+	return nil
+}
+
+// handleChangedDocs checks all documents below the document root that have
+// changed since the git revision specified, and the documents that link to
+// them.
+func handleChangedDocs(c *cli.Context, handler ReportHandler) error {
+	checker := newChecker(c, handler != nil)
+
+	docs, err := checker.checkChangedDocs(checker.Options.DocRoot,
+		c.String("changed-since"), c.String("link-cache"))
+	if err != nil {
+		return err
+	}
+
+	if handler != nil {
+		return displayReport(checker, handler, nil)
+	}
+
+	for _, doc := range docs {
+		doc.Logger.Info("Checked file")
+		doc.showStats()
+	}
+
+	return nil
+}
+
+// handleSnippets runs all snippets in the specified document (and all the
+// documents it references).
+func handleSnippets(c *cli.Context) error {
+	handleLogging(c)
+
+	if c.NArg() == 0 {
+		return errNeedFile
+	}
+
+	fileName := c.Args().First()
+	if fileName == "" {
+		return errNeedFile
+	}
+
+	handler, err := reportHandler(c)
+	if err != nil {
+		return err
+	}
+
+	if handler != nil {
+		logger.Logger.Out = os.Stderr
+	}
+
+	// Document problems are not of interest here so record, rather than
+	// fail on, them.
+	checker := newChecker(c, true)
+
+	doc := checker.newDoc(fileName)
+	doc.ListMode = true
+
+	if err := doc.parse(); err != nil {
+		return err
+	}
+
+	if !c.GlobalBool("single-doc-only") {
+		if err := checker.parseDocs(true); err != nil {
+			return err
+		}
+	}
+
+	checker.ClearFindings()
+
+	count, err := runSnippets(checker.listDocs(doc), c.Duration("timeout"))
+	if err != nil {
+		return err
+	}
+
+	if handler != nil {
+		return displayReport(checker, handler, doc)
+	}
+
+	findings := checker.Findings()
+
+	for _, f := range findings {
+		logger.WithFields(logrus.Fields{
+			"file": f.File,
+			"line": f.Line,
+			"rule": f.RuleID,
+			"fix":  f.Fix,
+		}).Error(f.Message)
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("%d of %d snippet(s) failed", len(findings), count)
+	}
+
+	doc.Logger.WithField("snippet-count", count).Info("Checked snippets")
+
+	return nil
+}
+
 // commonListHandler is used to handle all list operations.
 func commonListHandler(context *cli.Context, what DataToShow) error {
 	handleLogging(context)
@@ -246,7 +414,10 @@ func commonListHandler(context *cli.Context, what DataToShow) error {
 
 	file := context.Args().Get(0)
 
-	return show(file, logger, handler, what)
+	// Problems in the documents do not prevent them from being listed.
+	checker := newChecker(context, true)
+
+	return show(checker, file, context.GlobalBool("single-doc-only"), handler, what)
 }
 
 func realMain() error {
@@ -255,8 +426,6 @@ func realMain() error {
 		return err
 	}
 
-	docRoot = cwd
-
 	cli.VersionPrinter = func(c *cli.Context) {
 		fmt.Fprintln(os.Stdout, c.App.Version)
 	}
@@ -277,7 +446,7 @@ func realMain() error {
 		cli.StringFlag{
 			Name:  "doc-root, r",
 			Usage: "specify document root",
-			Value: docRoot,
+			Value: cwd,
 		},
 		cli.BoolFlag{
 			Name:  "single-doc-only, o",
@@ -287,6 +456,10 @@ func realMain() error {
 			Name:  "strict, s",
 			Usage: "enable strict mode",
 		},
+		cli.IntFlag{
+			Name:  "workers, j",
+			Usage: "maximum number of documents to parse concurrently (default: number of CPUs)",
+		},
 	}
 
 	app.Commands = []cli.Command{
@@ -294,10 +467,44 @@ func realMain() error {
 			Name:        "check",
 			Usage:       "perform tests on the specified document",
 			Description: "Exit code denotes success",
+			Flags: []cli.Flag{
+				checkFormatFlag,
+				cli.StringFlag{
+					Name:  "changed-since",
+					Usage: "ignore the file arguments and only check documents below the document root that have changed since the specified git revision (and the documents linking to them)",
+				},
+				cli.StringFlag{
+					Name:  "link-cache",
+					Usage: "file used to cache links between documents (--changed-since only)",
+				},
+			},
 			Action: func(c *cli.Context) error {
 				return handleDoc(c, false)
 			},
 		},
+		{
+			Name:  "run-snippets",
+			Usage: "run marked code blocks and check their output",
+			Description: fmt.Sprintf(`Code blocks are run if the info string contains %q
+   (for example "bash %s") or if the block is preceded by an HTML comment
+   containing only %q. The expected output of the block is either specified
+   in a code block immediately following it with an info string of %q, or
+   by prefixing all commands in the block with %q. Each block is run in
+   an isolated working directory (a new temporary directory, which is also
+   used as HOME and TMPDIR), but with the PATH and permissions of the user
+   running the tool, so it can access the rest of the system.
+
+   Exit code denotes success`, snippetMarker, snippetMarker, snippetMarker, snippetOutputMarker, snippetPrompt),
+			Flags: []cli.Flag{
+				checkFormatFlag,
+				cli.DurationFlag{
+					Name:  "timeout",
+					Usage: "maximum time each snippet may run for",
+					Value: defaultSnippetTimeout,
+				},
+			},
+			Action: handleSnippets,
+		},
 		{
 			Name:  "toc",
 			Usage: "display a markdown Table of Contents",
@@ -307,7 +514,7 @@ func realMain() error {
 		},
 		{
 			Name:  "list",
-			Usage: "display particular parts of the document",
+			Usage: "display particular parts of the document (and all documents it references)",
 			Subcommands: []cli.Command{
 				{
 					Name:  "headings",
@@ -333,6 +540,42 @@ func realMain() error {
 						return commonListHandler(c, showLinks)
 					},
 				},
+				{
+					Name:  "code-blocks",
+					Usage: "display code blocks",
+					Flags: []cli.Flag{
+						formatFlag,
+						noHeaderFlag,
+						separatorFlag,
+					},
+					Action: func(c *cli.Context) error {
+						return commonListHandler(c, showCodeBlocks)
+					},
+				},
+				{
+					Name:  "images",
+					Usage: "display images",
+					Flags: []cli.Flag{
+						formatFlag,
+						noHeaderFlag,
+						separatorFlag,
+					},
+					Action: func(c *cli.Context) error {
+						return commonListHandler(c, showImages)
+					},
+				},
+				{
+					Name:  "front-matter",
+					Usage: "display YAML front matter",
+					Flags: []cli.Flag{
+						formatFlag,
+						noHeaderFlag,
+						separatorFlag,
+					},
+					Action: func(c *cli.Context) error {
+						return commonListHandler(c, showFrontMatter)
+					},
+				},
 			},
 		},
 	}
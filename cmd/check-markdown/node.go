@@ -19,6 +19,15 @@ func (d *Doc) handleNode(node *bf.Node) error {
 		err = d.handleHeading(node)
 	case bf.Link:
 		err = d.handleLink(node)
+	case bf.Image:
+		err = d.handleImage(node)
+	case bf.CodeBlock:
+		err = d.handleCodeBlock(node)
+	case bf.Code, bf.HTMLBlock, bf.HTMLSpan:
+		// Not checked, but skipped so that any text in them that
+		// looks like a link or heading is not mistaken for a later
+		// one.
+		d.skip(node.Literal)
 	case bf.Text:
 		// handle blackfriday deficiencies
 		headings, err := d.forceCreateHeadings(node)
@@ -92,24 +101,45 @@ func (d *Doc) handleLink(node *bf.Node) error {
 		return err
 	}
 
+	link.Line, link.Column = d.linkPosition(link)
+
 	return d.addLink(link)
 }
 
-// handleIntraDocLinks checks the links between documents are correct.
-//
-// For example, if a document refers to "foo.md#section-bar", this function
-// will ensure that "section-bar" exists in external file "foo.md".
-func handleIntraDocLinks() error {
-	for _, doc := range docs {
-		for addr, linkList := range doc.Links {
-			for _, link := range linkList {
-				err := doc.checkLink(addr, link, true)
-				if err != nil {
-					return doc.Errorf("intra-doc link invalid: %v", err)
-				}
-			}
-		}
+// handleImage processes the image represented by the specified node.
+func (d *Doc) handleImage(node *bf.Node) error {
+	if err := checkNode(node, bf.Image); err != nil {
+		return err
+	}
+
+	description, err := imageDescription(node)
+	if err != nil {
+		return d.Errorf("failed to get image description: %v", err)
+	}
+
+	d.Images = append(d.Images, Image{
+		Doc:         d,
+		Address:     string(node.Destination),
+		Title:       string(node.Title),
+		Description: description,
+	})
+
+	return nil
+}
+
+// handleCodeBlock processes the code block represented by the specified node.
+func (d *Doc) handleCodeBlock(node *bf.Node) error {
+	if err := checkNode(node, bf.CodeBlock); err != nil {
+		return err
 	}
 
+	block := newCodeBlock(d, string(node.Literal), string(node.Info))
+
+	block.Line = d.codeBlockLine(node)
+	block.Runnable = isSnippet(node)
+	block.ExpectedOutput = isSnippetOutput(node)
+
+	d.CodeBlocks = append(d.CodeBlocks, block)
+
 	return nil
 }
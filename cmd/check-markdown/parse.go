@@ -14,10 +14,6 @@ import (
 	bf "gopkg.in/russross/blackfriday.v2"
 )
 
-// List of errors found by visitor. Used as the visitor cannot return an error
-// directly.
-var errorList []error
-
 func (d *Doc) parse() error {
 	if !d.ShowTOC && !d.ListMode {
 		d.Logger.Info("Checking file")
@@ -41,11 +37,45 @@ func (d *Doc) parseMarkdown() error {
 		return err
 	}
 
+	d.Data = bytes
+
+	// Black Friday does not understand front matter, so remove it before
+	// parsing the markdown.
+	frontMatter, body := splitFrontMatter(bytes)
+
+	if frontMatter != nil {
+		d.FrontMatter, err = parseFrontMatter(frontMatter)
+		if err != nil && !d.Reference {
+			err = d.report(d.Errorf("invalid front matter: %v", err))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
 	md := bf.New(bf.WithExtensions(bf.CommonExtensions))
 
-	root := md.Parse(bytes)
+	root := md.Parse(body)
+
+	// List of errors found by visitor. Used as the visitor cannot return
+	// an error directly.
+	var errorList []error
 
-	root.Walk(makeVisitor(d, d.ShowTOC))
+	root.Walk(makeVisitor(d, d.ShowTOC, &errorList))
+
+	if d.Reference {
+		return nil
+	}
+
+	if d.checker.Options.CollectFindings {
+		for _, err := range errorList {
+			if err := d.report(err); err != nil {
+				return err
+			}
+		}
+
+		errorList = nil
+	}
 
 	errorCount := len(errorList)
 	if errorCount > 0 {
@@ -72,8 +102,9 @@ func (d *Doc) parseMarkdown() error {
 // makeVisitor returns a function that is used to visit all document nodes.
 //
 // If createTOC is false, the visitor will check all nodes, but if true, the
-// visitor will only display a table of contents for the document.
-func makeVisitor(doc *Doc, createTOC bool) func(node *bf.Node, entering bool) bf.WalkStatus {
+// visitor will only display a table of contents for the document. Any errors
+// found are added to errorList.
+func makeVisitor(doc *Doc, createTOC bool, errorList *[]error) func(node *bf.Node, entering bool) bf.WalkStatus {
 	f := func(node *bf.Node, entering bool) bf.WalkStatus {
 		if !entering {
 			return bf.GoToNext
@@ -90,7 +121,7 @@ func makeVisitor(doc *Doc, createTOC bool) func(node *bf.Node, entering bool) bf
 		if err != nil {
 			// The visitor cannot return an error, so collect up all parser
 			// errors for dealing with later.
-			errorList = append(errorList, err)
+			*errorList = append(*errorList, err)
 		}
 
 		return bf.GoToNext
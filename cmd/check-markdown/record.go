@@ -27,17 +27,78 @@ func linkToRecord(l Link) (record []string) {
 	return record
 }
 
+// The document is the last field so that the positions of the original
+// fields are unchanged.
 func headingHeaderRecord() []string {
 	return []string{
 		"Name",
 		"Link",
 		"Level",
+		"Document",
 	}
 }
-func headingToRecord(h Heading) (record []string) {
+
+func headingToRecord(d *Doc, h Heading) (record []string) {
 	record = append(record, h.Name)
 	record = append(record, h.LinkName)
 	record = append(record, fmt.Sprintf("%d", h.Level))
+	record = append(record, d.Name)
+
+	return record
+}
+
+func codeBlockHeaderRecord() []string {
+	return []string{
+		"Document",
+		"Line",
+		"Language",
+		"Info",
+		"Lines",
+		"Hash",
+	}
+}
+
+func codeBlockToRecord(c CodeBlock) (record []string) {
+	record = append(record, c.Doc.Name)
+	record = append(record, fmt.Sprintf("%d", c.Line))
+	record = append(record, c.Language)
+	record = append(record, c.Info)
+	record = append(record, fmt.Sprintf("%d", c.Lines))
+	record = append(record, c.Hash)
+
+	return record
+}
+
+func imageHeaderRecord() []string {
+	return []string{
+		"Document",
+		"Address",
+		"Title",
+		"Description",
+	}
+}
+
+func imageToRecord(i Image) (record []string) {
+	record = append(record, i.Doc.Name)
+	record = append(record, i.Address)
+	record = append(record, i.Title)
+	record = append(record, i.Description)
+
+	return record
+}
+
+func frontMatterHeaderRecord() []string {
+	return []string{
+		"Document",
+		"Key",
+		"Value",
+	}
+}
+
+func frontMatterToRecord(d *Doc, e FrontMatterEntry) (record []string) {
+	record = append(record, d.Name)
+	record = append(record, e.Key)
+	record = append(record, e.Value)
 
 	return record
 }
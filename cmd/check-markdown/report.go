@@ -0,0 +1,110 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// ReportHandler is an interface that all handlers which display the results
+// of checking a set of documents must implement.
+type ReportHandler interface {
+	DisplayReport(r *Report) error
+}
+
+// DocReport describes a single checked document.
+type DocReport struct {
+	File       string `json:"file"`
+	Statistics Stats  `json:"statistics"`
+}
+
+// Report describes the results of checking a set of documents.
+type Report struct {
+	// The document specified by the user (if any).
+	Primary string `json:"primary,omitempty"`
+
+	// All documents checked, including the primary document.
+	Documents []DocReport `json:"documents"`
+
+	Findings []Finding `json:"findings"`
+
+	// Used to make absolute document paths relative.
+	docRoot string
+}
+
+// reportHandlers is a map of the available structured check output format
+// handling implementations.
+var reportHandlers = map[string]ReportHandler{
+	jsonFormat:  NewReportJSON(outputFile),
+	sarifFormat: NewReportSARIF(outputFile),
+}
+
+// newReport creates a report of all checked documents, ordered by name.
+// primary may be nil if the user did not specify a document.
+func (c *Checker) newReport(primary *Doc) *Report {
+	r := &Report{
+		Findings: c.Findings(),
+		docRoot:  c.Options.DocRoot,
+	}
+
+	if primary != nil {
+		r.Primary = primary.Name
+	}
+
+	for _, d := range c.Docs() {
+		if !d.Parsed || d.Reference {
+			continue
+		}
+
+		r.Documents = append(r.Documents, DocReport{
+			File:       d.Name,
+			Statistics: d.stats(),
+		})
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		a, b := r.Findings[i], r.Findings[j]
+
+		if a.File != b.File {
+			return a.File < b.File
+		}
+
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+
+		return a.Column < b.Column
+	})
+
+	return r
+}
+
+// errorCount returns the number of findings with error severity.
+func (r *Report) errorCount() int {
+	count := 0
+
+	for _, f := range r.Findings {
+		if f.Severity == severityError {
+			count++
+		}
+	}
+
+	return count
+}
+
+// relativePath returns the specified document path relative to the document
+// root if possible, else the path unchanged.
+func (r *Report) relativePath(path string) string {
+	if filepath.IsAbs(path) && r.docRoot != "" {
+		if rel, err := filepath.Rel(r.docRoot, path); err == nil {
+			path = rel
+		}
+	}
+
+	return filepath.ToSlash(path)
+}
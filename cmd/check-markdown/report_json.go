@@ -0,0 +1,29 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type reportJSON struct {
+	file *os.File
+}
+
+func NewReportJSON(file *os.File) ReportHandler {
+	return &reportJSON{
+		file: file,
+	}
+}
+
+func (r *reportJSON) DisplayReport(report *Report) error {
+	encoder := json.NewEncoder(r.file)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
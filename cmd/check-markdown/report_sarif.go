@@ -0,0 +1,215 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Static Analysis Results Interchange Format (SARIF) details.
+//
+// See: https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+
+	toolName = "kata-check-markdown"
+	toolURI  = "https://github.com/kata-containers/tests/tree/main/cmd/check-markdown"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool              `json:"tool"`
+	Results    []sarifResult          `json:"results"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type reportSARIF struct {
+	file *os.File
+}
+
+func NewReportSARIF(file *os.File) ReportHandler {
+	return &reportSARIF{
+		file: file,
+	}
+}
+
+// sarifLevel converts the specified severity into a SARIF result level.
+func sarifLevel(severity Severity) string {
+	switch severity {
+	case severityError:
+		return "error"
+	case severityWarning:
+		return "warning"
+	}
+
+	return "note"
+}
+
+// sarifRules returns a list of all the rules, sorted by ID.
+func sarifRules() []sarifRule {
+	var rules []sarifRule
+
+	for id, description := range ruleDescriptions {
+		rules = append(rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{Text: description},
+			DefaultConfiguration: sarifConfiguration{
+				Level: sarifLevel(severityError),
+			},
+		})
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return rules
+}
+
+func (r *reportSARIF) DisplayReport(report *Report) error {
+	rules := sarifRules()
+
+	ruleIndex := make(map[string]int)
+
+	for i, rule := range rules {
+		ruleIndex[rule.ID] = i
+	}
+
+	toolVersion := version
+	if toolVersion == "" {
+		toolVersion = commit
+	}
+
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           toolName,
+				Version:        toolVersion,
+				InformationURI: toolURI,
+				Rules:          rules,
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	for _, f := range report.Findings {
+		result := sarifResult{
+			RuleID:    f.RuleID,
+			RuleIndex: ruleIndex[f.RuleID],
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{Text: f.Message},
+		}
+
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{
+					URI: report.relativePath(f.File),
+				},
+			},
+		}
+
+		if f.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{
+				StartLine:   f.Line,
+				StartColumn: f.Column,
+			}
+		}
+
+		result.Locations = []sarifLocation{location}
+
+		if f.Fix != "" {
+			result.Message.Text = fmt.Sprintf("%s (suggested fix: %q)", f.Message, f.Fix)
+			result.Properties = map[string]interface{}{
+				"suggestedFix": f.Fix,
+			}
+		}
+
+		run.Results = append(run.Results, result)
+	}
+
+	stats := make(map[string]Stats)
+
+	for _, d := range report.Documents {
+		stats[report.relativePath(d.File)] = d.Statistics
+	}
+
+	run.Properties = map[string]interface{}{
+		"statistics": stats,
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(r.file)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(log)
+}
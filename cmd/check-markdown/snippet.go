@@ -0,0 +1,307 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	bf "gopkg.in/russross/blackfriday.v2"
+)
+
+const (
+	// Word in a code block info string (or the content of an HTML
+	// comment before the block) that marks the block as a snippet to run.
+	snippetMarker = "check"
+
+	// Info string of a code block containing the expected output of the
+	// previous snippet.
+	snippetOutputMarker = "output"
+
+	// Prefix used for commands in snippets that also show the output of
+	// the commands.
+	snippetPrompt = "$ "
+
+	defaultSnippetTimeout = 30 * time.Second
+)
+
+// snippetInterpreters maps a code block language to the command used to run
+// the snippet. The snippet is appended as the final argument.
+var snippetInterpreters = map[string][]string{
+	"bash":    {"bash", "-e", "-c"},
+	"console": {"bash", "-e", "-c"},
+	"sh":      {"sh", "-e", "-c"},
+	"shell":   {"bash", "-e", "-c"},
+}
+
+// Snippet is a runnable code block.
+type Snippet struct {
+	Block CodeBlock
+
+	// The commands to run.
+	Commands string
+
+	// The output the commands should produce on stdout.
+	ExpectedOutput string
+
+	// Set if ExpectedOutput should be checked.
+	CheckOutput bool
+
+	// Line the expected output starts on.
+	OutputLine int
+}
+
+// isSnippet determines if the specified code block node is a snippet that
+// should be run.
+//
+// Snippets are marked either by specifying the marker in the info string:
+//
+//	```bash check
+//
+// ... or by placing an HTML comment containing only the marker immediately
+// before the code block:
+//
+//	<!-- check -->
+//	```bash
+func isSnippet(node *bf.Node) bool {
+	if !node.IsFenced {
+		return false
+	}
+
+	// The first word is the language
+	fields := strings.Fields(string(node.Info))
+
+	for i := 1; i < len(fields); i++ {
+		if fields[i] == snippetMarker {
+			return true
+		}
+	}
+
+	prev := node.Prev
+
+	if prev == nil || prev.Type != bf.HTMLBlock {
+		return false
+	}
+
+	comment := strings.TrimSpace(string(prev.Literal))
+
+	if !strings.HasPrefix(comment, "<!--") || !strings.HasSuffix(comment, "-->") {
+		return false
+	}
+
+	comment = strings.TrimSuffix(strings.TrimPrefix(comment, "<!--"), "-->")
+
+	return strings.TrimSpace(comment) == snippetMarker
+}
+
+// isSnippetOutput determines if the specified code block node contains the
+// expected output of the snippet immediately before it.
+func isSnippetOutput(node *bf.Node) bool {
+	if strings.TrimSpace(string(node.Info)) != snippetOutputMarker {
+		return false
+	}
+
+	prev := node.Prev
+
+	return prev != nil && prev.Type == bf.CodeBlock && isSnippet(prev)
+}
+
+// splitSnippet splits the content of a snippet into the commands to run and
+// the expected output. If any line starts with a prompt, only those lines
+// (and any continuation lines that follow them) are commands: all other lines
+// are expected output. Otherwise, all lines are commands.
+func splitSnippet(content string) (commands, output string, hasPrompts bool) {
+	lines := strings.SplitAfter(content, "\n")
+
+	for _, line := range lines {
+		if strings.HasPrefix(line, snippetPrompt) {
+			hasPrompts = true
+			break
+		}
+	}
+
+	if !hasPrompts {
+		return content, "", false
+	}
+
+	continuation := false
+
+	for _, line := range lines {
+		isCommand := strings.HasPrefix(line, snippetPrompt)
+
+		if isCommand || continuation {
+			commands += strings.TrimPrefix(line, snippetPrompt)
+		} else {
+			output += line
+		}
+
+		continuation = (isCommand || continuation) &&
+			strings.HasSuffix(strings.TrimRight(line, "\n"), "\\")
+	}
+
+	return commands, output, true
+}
+
+// snippets returns the list of snippets in the document.
+func (d *Doc) snippets() []Snippet {
+	var snippets []Snippet
+
+	for i, block := range d.CodeBlocks {
+		if !block.Runnable {
+			continue
+		}
+
+		commands, output, hasPrompts := splitSnippet(block.Content)
+
+		s := Snippet{
+			Block:          block,
+			Commands:       commands,
+			ExpectedOutput: output,
+			CheckOutput:    hasPrompts,
+			OutputLine:     block.Line,
+		}
+
+		// A separate expected output block overrides any output
+		// shown in the snippet.
+		if i+1 < len(d.CodeBlocks) && d.CodeBlocks[i+1].ExpectedOutput {
+			next := d.CodeBlocks[i+1]
+
+			s.ExpectedOutput = next.Content
+			s.CheckOutput = true
+			s.OutputLine = next.Line
+		}
+
+		snippets = append(snippets, s)
+	}
+
+	return snippets
+}
+
+// snippetEnv returns the environment to run snippets in. The isolated
+// working directory dir is also used as HOME and TMPDIR, but PATH is
+// inherited so snippets can run the same commands as the user.
+func snippetEnv(dir string) []string {
+	return []string{
+		"HOME=" + dir,
+		"LC_ALL=C",
+		"PATH=" + os.Getenv("PATH"),
+		"TMPDIR=" + dir,
+	}
+}
+
+// normaliseOutput removes trailing whitespace from all lines, and any
+// trailing blank lines, to avoid spurious mismatches.
+func normaliseOutput(output string) string {
+	lines := strings.Split(output, "\n")
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// run runs the snippet in an isolated working directory (a new temporary
+// directory), returning a FindingError if it fails or produces unexpected
+// output. The snippet runs with the permissions of the user running the tool.
+func (s Snippet) run(timeout time.Duration) error {
+	doc := s.Block.Doc
+
+	language := s.Block.Language
+
+	interpreter, ok := snippetInterpreters[language]
+	if !ok {
+		return doc.Findingf(ruleSnippetLanguage, s.Block.Line, 1, "",
+			"cannot run snippet: unsupported language %q", language)
+	}
+
+	dir, err := ioutil.TempDir("", "check-markdown-snippet-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var args []string
+
+	args = append(args, interpreter[1:]...)
+	args = append(args, s.Commands)
+
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command(interpreter[0], args...)
+	cmd.Dir = dir
+	cmd.Env = snippetEnv(dir)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Run the snippet in its own process group so that any background
+	// processes it started (which may keep stdout and stderr open) are
+	// also killed on timeout.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	doc.Logger.WithField("line", s.Block.Line).Debug("running snippet")
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	timer := time.AfterFunc(timeout, func() {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	})
+
+	err = cmd.Wait()
+
+	// The timer has already fired if it cannot be stopped
+	if !timer.Stop() {
+		return doc.Findingf(ruleSnippetTimeout, s.Block.Line, 1, "",
+			"snippet did not finish within %v", timeout)
+	}
+
+	if err != nil {
+		return doc.Findingf(ruleSnippetFailed, s.Block.Line, 1, "",
+			"snippet failed: %v (stderr: %q)", err, strings.TrimSpace(stderr.String()))
+	}
+
+	if !s.CheckOutput {
+		return nil
+	}
+
+	expected := normaliseOutput(s.ExpectedOutput)
+	actual := normaliseOutput(stdout.String())
+
+	if expected != actual {
+		return doc.Findingf(ruleSnippetOutput, s.OutputLine, 1, actual,
+			"snippet output mismatch: expected %q, got %q", expected, actual)
+	}
+
+	return nil
+}
+
+// runSnippets runs all the snippets in the specified documents, returning
+// the number of snippets run.
+func runSnippets(docs []*Doc, timeout time.Duration) (int, error) {
+	count := 0
+
+	for _, d := range docs {
+		for _, s := range d.snippets() {
+			count++
+
+			if err := d.report(s.run(timeout)); err != nil {
+				return count, fmt.Errorf("line %d: %v", s.Block.Line, err)
+			}
+		}
+	}
+
+	return count, nil
+}
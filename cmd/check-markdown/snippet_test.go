@@ -0,0 +1,175 @@
+//
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitSnippet(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		content            string
+		expectedCommands   string
+		expectedOutput     string
+		expectedHasPrompts bool
+	}
+
+	data := []testData{
+		{"", "", "", false},
+		{"echo foo\n", "echo foo\n", "", false},
+		{"echo foo\nfoo\n", "echo foo\nfoo\n", "", false},
+
+		{"$ echo foo\nfoo\n", "echo foo\n", "foo\n", true},
+		{"$ true\n$ echo foo\nfoo\n", "true\necho foo\n", "foo\n", true},
+		{"$ echo foo \\\n  bar\nfoo bar\n", "echo foo \\\n  bar\n", "foo bar\n", true},
+		{"$ echo foo\nfoo\n$ echo bar\nbar\n", "echo foo\necho bar\n", "foo\nbar\n", true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
+
+		commands, output, hasPrompts := splitSnippet(d.content)
+
+		assert.Equal(d.expectedCommands, commands, msg)
+		assert.Equal(d.expectedOutput, output, msg)
+		assert.Equal(d.expectedHasPrompts, hasPrompts, msg)
+	}
+}
+
+func TestNormaliseOutput(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		output   string
+		expected string
+	}
+
+	data := []testData{
+		{"", ""},
+		{"\n\n", ""},
+		{"foo", "foo"},
+		{"foo \t\r\nbar  \n\n", "foo\nbar"},
+		{"  foo", "  foo"},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
+
+		assert.Equal(d.expected, normaliseOutput(d.output), msg)
+	}
+}
+
+func TestDocSnippets(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "snippets.md")
+
+	contents := "# Snippets\n\n" +
+		"```bash check\necho foo\n```\n\n```output\nfoo\n```\n\n" +
+		"<!-- check -->\n```sh\n$ echo bar\nbar\n```\n\n" +
+		"```bash\nnot a snippet\n```\n\n" +
+		"```output\nnot expected output\n```\n"
+
+	err = createFile(file, contents)
+	assert.NoError(err)
+
+	logger := logrus.WithField("test", "true")
+	doc := NewChecker(logger, Options{}).newDoc(file)
+	doc.ListMode = true
+
+	err = doc.parse()
+	assert.NoError(err)
+
+	snippets := doc.snippets()
+	assert.Len(snippets, 2)
+
+	assert.Equal("echo foo\n", snippets[0].Commands)
+	assert.Equal("foo\n", snippets[0].ExpectedOutput)
+	assert.True(snippets[0].CheckOutput)
+	assert.Equal(3, snippets[0].Block.Line)
+	assert.Equal(7, snippets[0].OutputLine)
+
+	assert.Equal("echo bar\n", snippets[1].Commands)
+	assert.Equal("bar\n", snippets[1].ExpectedOutput)
+	assert.True(snippets[1].CheckOutput)
+	assert.Equal(12, snippets[1].Block.Line)
+}
+
+func TestSnippetRun(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		language       string
+		commands       string
+		expectedOutput string
+		checkOutput    bool
+		expectedRuleID string
+	}
+
+	data := []testData{
+		{"bash", "true", "", false, ""},
+		{"bash", "echo foo", "", false, ""},
+		{"bash", "echo foo", "foo\n", true, ""},
+		{"sh", "echo foo", "foo", true, ""},
+		{"bash", "pwd | grep -q check-markdown-snippet-", "", true, ""},
+
+		{"python", "print(1)", "", false, ruleSnippetLanguage},
+		{"bash", "false", "", false, ruleSnippetFailed},
+		{"bash", "false\ntrue", "", false, ruleSnippetFailed},
+		{"bash", "echo foo", "bar", true, ruleSnippetOutput},
+		{"bash", "sleep 5", "", false, ruleSnippetTimeout},
+
+		// The background process keeps stdout open
+		{"bash", "sleep 5 &\nsleep 5", "", false, ruleSnippetTimeout},
+	}
+
+	logger := logrus.WithField("test", "true")
+	doc := NewChecker(logger, Options{}).newDoc("foo")
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v\n", i, d)
+
+		s := Snippet{
+			Block:          newCodeBlock(doc, d.commands, d.language),
+			Commands:       d.commands,
+			ExpectedOutput: d.expectedOutput,
+			CheckOutput:    d.checkOutput,
+		}
+
+		start := time.Now()
+
+		err := s.run(500 * time.Millisecond)
+
+		// All the snippet's processes are killed on timeout
+		assert.True(time.Since(start) < 5*time.Second, msg)
+
+		if d.expectedRuleID == "" {
+			assert.NoError(err, msg)
+			continue
+		}
+
+		var findingErr *FindingError
+
+		assert.True(errors.As(err, &findingErr), msg)
+		assert.Equal(d.expectedRuleID, findingErr.RuleID, msg)
+	}
+}
@@ -12,7 +12,18 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-func (d *Doc) showStats() {
+// Stats summarises the contents of a document.
+type Stats struct {
+	HeadingsCount int `json:"headings-count"`
+	LinksCount    int `json:"links-count"`
+
+	// Key: link type name
+	// Value: number of links of that type
+	LinkTypeCounts map[string]int `json:"link-type-counts"`
+}
+
+// stats returns the statistics for the document.
+func (d *Doc) stats() Stats {
 	var counters [LinkTypeCount]int
 
 	linkCount := 0
@@ -24,14 +35,28 @@ func (d *Doc) showStats() {
 		}
 	}
 
-	fields := logrus.Fields{
-		"headings-count": len(d.Headings),
-		"links-count":    linkCount,
+	s := Stats{
+		HeadingsCount:  len(d.Headings),
+		LinksCount:     linkCount,
+		LinkTypeCounts: make(map[string]int),
 	}
 
 	for i, count := range counters {
-		name := LinkType(i).String()
+		s.LinkTypeCounts[LinkType(i).String()] = count
+	}
+
+	return s
+}
+
+func (d *Doc) showStats() {
+	s := d.stats()
+
+	fields := logrus.Fields{
+		"headings-count": s.HeadingsCount,
+		"links-count":    s.LinksCount,
+	}
 
+	for name, count := range s.LinkTypeCounts {
 		fieldName := fmt.Sprintf("link-type-%s-count", name)
 
 		fields[fieldName] = count
@@ -126,6 +126,98 @@ type Link struct {
 	Description string
 
 	Type LinkType
+
+	// 1-based position of the link in the document (zero if unknown).
+	Line   int
+	Column int
+}
+
+// CodeBlock is a block of verbatim text in a markdown document.
+//
+// Example: A fenced code block like this:
+//
+//     ```bash
+//     $ echo hello
+//     ```
+//
+// ... would be described as:
+//
+// ```go
+// CodeBlock{
+//   Line:     1,
+//   Language: "bash",
+//   Info:     "bash",
+//   Content:  "$ echo hello\n",
+//   Lines:    1,
+//   Hash:     "<SHA256 of Content>",
+// }
+// ```
+type CodeBlock struct {
+	// Document this code block is part of.
+	Doc *Doc
+
+	// Line number the block starts on (the opening fence for fenced code
+	// blocks). Zero if unknown.
+	Line int
+
+	// The first word of the info string (may be blank).
+	Language string
+
+	// The full info string specified after the opening fence.
+	Info string
+
+	// The verbatim text in the block.
+	Content string
+
+	// Number of lines in Content.
+	Lines int
+
+	// Hex encoded SHA256 hash of Content.
+	Hash string
+
+	// Set if the block is a snippet that should be run.
+	Runnable bool
+
+	// Set if the block contains the expected output of the
+	// previous (runnable) block.
+	ExpectedOutput bool
+}
+
+// Image is a reference to an image.
+//
+// Example: An image like this:
+//
+//     ![alt text](images/foo.png "title")
+//
+// ... would be described as:
+//
+// ```go
+// Image{
+//   Address:     "images/foo.png",
+//   Title:       "title",
+//   Description: "alt text",
+// }
+// ```
+type Image struct {
+	// Document this image is displayed in.
+	Doc *Doc
+
+	// Original address from document.
+	Address string
+
+	// Optional image title.
+	Title string
+
+	// The alternate text for the image.
+	Description string
+}
+
+// FrontMatterEntry is a top-level entry in a documents YAML front matter.
+type FrontMatterEntry struct {
+	Key string
+
+	// The value of the entry. Non-scalar values are encoded as YAML.
+	Value string
 }
 
 // Doc represents a markdown document.
@@ -141,9 +233,22 @@ type Doc struct {
 	// the same _address_, but of a different type.
 	Links map[string][]Link
 
+	// List of code blocks, in the order they appear in the document.
+	CodeBlocks []CodeBlock
+
+	// List of images, in the order they appear in the document.
+	Images []Image
+
+	// Top-level front matter entries, in the order they appear in the
+	// document.
+	FrontMatter []FrontMatterEntry
+
 	// Filename
 	Name string
 
+	// Raw document contents
+	Data []byte
+
 	// true when this document has been fully parsed
 	Parsed bool
 
@@ -151,4 +256,18 @@ type Doc struct {
 	ShowTOC bool
 
 	ListMode bool
+
+	// Set if the document is only parsed to allow links to it from other
+	// documents to be checked. Problems in the document are ignored.
+	Reference bool
+
+	// Offset into Data to start searching for the next node while
+	// parsing. The parser does not record the positions of nodes, so
+	// they are found by searching forwards through the document (which
+	// ensures text appearing more than once is found at the right
+	// position).
+	parseOffset int
+
+	// The checker that owns this document.
+	checker *Checker
 }
@@ -0,0 +1,197 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The subject prefixes git-rebase(1) --autosquash recognises. Such commits
+// are created by "git commit --fixup" and "git commit --squash".
+var autosquashPrefixes = []string{
+	"fixup!",
+	"squash!",
+	"amend!",
+}
+
+// Markers which identify work in progress commits by default.
+var defaultWIPMarkers = []string{
+	"WIP",
+	"DO NOT MERGE",
+	"DNM",
+}
+
+// Matches an abbreviated commit hash.
+var abbrevHashPattern = regexp.MustCompile(`^[[:xdigit:]]{4,40}$`)
+
+// parseAutosquash returns the autosquash prefix and target of the specified
+// subject. If the subject does not start with an autosquash prefix, ok is
+// false. Repeated prefixes (such as "fixup! fixup! foo: bar") are removed.
+func parseAutosquash(subject string) (prefix, target string, ok bool) {
+	target = subject
+
+	for {
+		found := false
+
+		for _, p := range autosquashPrefixes {
+			if strings.HasPrefix(target, p+" ") {
+				if prefix == "" {
+					prefix = p
+				}
+
+				target = strings.TrimSpace(strings.TrimPrefix(target, p))
+				found = true
+			}
+		}
+
+		if !found {
+			break
+		}
+	}
+
+	return prefix, target, prefix != ""
+}
+
+// isAutosquashCommit returns true if the commit must be squashed into an
+// earlier commit.
+func isAutosquashCommit(commit *Commit) bool {
+	_, _, ok := parseAutosquash(commit.subject)
+	return ok
+}
+
+// findAutosquashTarget returns the commit the specified target refers to,
+// using the same rules as git-rebase(1): either a commit with a matching
+// subject, a commit whose subject starts with the target, or a commit whose
+// hash starts with the target. Only commits which are not themselves to be
+// squashed are considered, and the earliest match is returned.
+func findAutosquashTarget(target string, commits []Commit) *Commit {
+	matchers := []func(c *Commit) bool{
+		func(c *Commit) bool { return c.subject == target },
+		func(c *Commit) bool {
+			return abbrevHashPattern.MatchString(target) && strings.HasPrefix(c.hash, strings.ToLower(target))
+		},
+		func(c *Commit) bool { return strings.HasPrefix(c.subject, target) },
+	}
+
+	for _, matches := range matchers {
+		for i := range commits {
+			commit := &commits[i]
+
+			if !isAutosquashCommit(commit) && matches(commit) {
+				return commit
+			}
+		}
+	}
+
+	return nil
+}
+
+// newWIPPattern returns a pattern matching any of the specified markers
+// either as a prefix of the subject or of the summary following the
+// subsystem (such as "WIP: foo", "foo: [wip] bar" or "(DNM) foo"), or as a
+// separate word anywhere in the subject (such as "foo: bar WIP"). Only
+// prefixes are matched ignoring case, so words like "wipe" and "swipe" and
+// lower case uses such as "foo: fix wip counter" do not match.
+//
+// The marker is in the first non-empty submatch.
+func newWIPPattern(markers []string) *regexp.Regexp {
+	var quoted []string
+
+	for _, marker := range markers {
+		if marker != "" {
+			quoted = append(quoted, regexp.QuoteMeta(marker))
+		}
+	}
+
+	if len(quoted) == 0 {
+		return nil
+	}
+
+	alternatives := strings.Join(quoted, "|")
+
+	prefix := fmt.Sprintf(`(?i:^(?:[^:[:blank:]]*:[[:blank:]]*)?[\[(]?(%s)(?:[^[:alnum:]]|$))`, alternatives)
+	word := fmt.Sprintf(`(?:^|[^[:alnum:]])(%s)(?:$|[^[:alnum:]])`, alternatives)
+
+	return regexp.MustCompile(prefix + "|" + word)
+}
+
+// wipMarker returns the work in progress marker in the specified subject, or
+// "" if it does not contain one.
+func wipMarker(pattern *regexp.Regexp, subject string) string {
+	if pattern == nil {
+		return ""
+	}
+
+	matches := pattern.FindStringSubmatch(subject)
+	if matches == nil {
+		return ""
+	}
+
+	// matches[0] is the entire match
+	for _, marker := range matches[1:] {
+		if marker != "" {
+			return marker
+		}
+	}
+
+	return ""
+}
+
+// checkSquashCandidates returns the problems found with commits in the range
+// which should be squashed into other commits before merging: autosquash
+// commits, work in progress commits and commits with duplicate subjects.
+// The problems are returned by commit hash.
+//
+// Unlike the other checks, these only apply to a range of commits since
+// such commits are a normal part of preparing a change locally.
+func checkSquashCandidates(config *CommitConfig, commits []Commit) map[string][]error {
+	problems := make(map[string][]error)
+
+	wipPattern := newWIPPattern(config.WIPMarkers)
+
+	// Key: subject
+	// Value: first commit with that subject
+	subjects := make(map[string]*Commit)
+
+	for i := range commits {
+		commit := &commits[i]
+
+		if prefix, target, ok := parseAutosquash(commit.subject); ok {
+			var err error
+
+			if earlier := findAutosquashTarget(target, commits[:i]); earlier != nil {
+				err = commitErrorf(commit, "%s commit must be squashed into commit %v (%q)",
+					prefix, earlier.hash, earlier.subject)
+			} else {
+				err = commitErrorf(commit, "%s commit must be squashed (no earlier commit matching %q in range)",
+					prefix, target)
+			}
+
+			problems[commit.hash] = append(problems[commit.hash], err)
+			continue
+		}
+
+		if marker := wipMarker(wipPattern, commit.subject); marker != "" {
+			problems[commit.hash] = append(problems[commit.hash],
+				commitErrorf(commit, "work in progress commit (subject contains %q) must be completed or squashed", marker))
+		}
+
+		if !config.NoDuplicateSubjects {
+			continue
+		}
+
+		if earlier, ok := subjects[commit.subject]; ok {
+			problems[commit.hash] = append(problems[commit.hash],
+				commitErrorf(commit, "subject duplicates commit %v: squash the commits or make the subjects distinct", earlier.hash))
+			continue
+		}
+
+		subjects[commit.subject] = commit
+	}
+
+	return problems
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAutosquash(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		subject        string
+		expectedPrefix string
+		expectedTarget string
+		expectedOK     bool
+	}
+
+	data := []testData{
+		{"", "", "", false},
+		{"foo: bar", "", "foo: bar", false},
+		{"fixup!", "", "fixup!", false},
+		{"foo: fixup! bar", "", "foo: fixup! bar", false},
+		{"fixup!foo: bar", "", "fixup!foo: bar", false},
+
+		{"fixup! foo: bar", "fixup!", "foo: bar", true},
+		{"squash! foo: bar", "squash!", "foo: bar", true},
+		{"amend! foo: bar", "amend!", "foo: bar", true},
+		{"fixup! fixup! foo: bar", "fixup!", "foo: bar", true},
+		{"squash! fixup!  foo: bar", "squash!", "foo: bar", true},
+		{"fixup! 0123abc", "fixup!", "0123abc", true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		prefix, target, ok := parseAutosquash(d.subject)
+		assert.Equal(d.expectedOK, ok, msg)
+		assert.Equal(d.expectedPrefix, prefix, msg)
+		assert.Equal(d.expectedTarget, target, msg)
+	}
+}
+
+func TestFindAutosquashTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	commits := []Commit{
+		{hash: testHash1, subject: "foo: Add bar baz"},
+		{hash: testHash2, subject: "foo: Add bar"},
+		{hash: testHash3, subject: "fixup! foo: Add qux"},
+	}
+
+	type testData struct {
+		target       string
+		expectedHash string
+	}
+
+	data := []testData{
+		// Exact matches are preferred
+		{"foo: Add bar", testHash2},
+		{"foo: Add bar baz", testHash1},
+
+		// Then hashes
+		{"89abcdef", testHash2},
+		{"89ABCDEF", testHash2},
+
+		// Then the earliest subject prefix
+		{"foo: Add", testHash1},
+
+		// Autosquash commits are never targets
+		{"foo: Add qux", ""},
+		{"fedcba98", ""},
+
+		{"bar: Other", ""},
+		{"012", ""},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		commit := findAutosquashTarget(d.target, commits)
+		if d.expectedHash == "" {
+			assert.Nil(commit, msg)
+			continue
+		}
+
+		if assert.NotNil(commit, msg) {
+			assert.Equal(d.expectedHash, commit.hash, msg)
+		}
+	}
+}
+
+func TestCheckSquashCandidates(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		subjects            []string
+		wipMarkers          []string
+		noDuplicateSubjects bool
+
+		// Key: commit hash (the 1-based index of the commit)
+		// Value: expected problems
+		expectedProblems map[int][]string
+	}
+
+	data := []testData{
+		{[]string{"foo: bar", "foo: baz"}, defaultWIPMarkers, true, nil},
+
+		{[]string{"foo: bar", "fixup! foo: bar"}, defaultWIPMarkers, false, map[int][]string{
+			2: {"fixup! commit must be squashed into commit 1 (\"foo: bar\")"},
+		}},
+		{[]string{"foo: bar", "foo: baz", "squash! foo: baz", "amend! foo: bar"}, defaultWIPMarkers, false, map[int][]string{
+			3: {"squash! commit must be squashed into commit 2 (\"foo: baz\")"},
+			4: {"amend! commit must be squashed into commit 1 (\"foo: bar\")"},
+		}},
+
+		// The target must be earlier in the range
+		{[]string{"fixup! foo: bar", "foo: bar"}, defaultWIPMarkers, false, map[int][]string{
+			1: {"fixup! commit must be squashed (no earlier commit matching \"foo: bar\" in range)"},
+		}},
+
+		{[]string{"foo: WIP bar", "foo: [wip] baz", "foo: DO NOT MERGE", "foo: dnm", "foo: wipe disk", "foo: swipe"}, defaultWIPMarkers, false, map[int][]string{
+			1: {"work in progress commit (subject contains \"WIP\")"},
+			2: {"work in progress commit (subject contains \"wip\")"},
+			3: {"work in progress commit (subject contains \"DO NOT MERGE\")"},
+			4: {"work in progress commit (subject contains \"dnm\")"},
+		}},
+		// Markers are only matched ignoring case as a prefix
+		{[]string{"Swipe handling", "foo: fix wip counter", "WIP: foo", "feat(foo): wip bar", "foo: bar (DNM)", "foo: bar-wip", "(dnm) foo: bar"}, defaultWIPMarkers, false, map[int][]string{
+			3: {"work in progress commit (subject contains \"WIP\")"},
+			4: {"work in progress commit (subject contains \"wip\")"},
+			5: {"work in progress commit (subject contains \"DNM\")"},
+			7: {"work in progress commit (subject contains \"dnm\")"},
+		}},
+		{[]string{"foo: WIP bar", "foo: TODO bar"}, []string{"todo"}, false, map[int][]string{
+			2: {"work in progress commit (subject contains \"TODO\")"},
+		}},
+		{[]string{"foo: WIP bar"}, nil, false, nil},
+
+		{[]string{"foo: bar", "foo: baz", "foo: bar", "foo: bar"}, defaultWIPMarkers, false, nil},
+		{[]string{"foo: bar", "foo: baz", "foo: bar", "foo: bar"}, defaultWIPMarkers, true, map[int][]string{
+			3: {"subject duplicates commit 1"},
+			4: {"subject duplicates commit 1"},
+		}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		config := createCommitConfig()
+		config.WIPMarkers = d.wipMarkers
+		config.NoDuplicateSubjects = d.noDuplicateSubjects
+
+		var commits []Commit
+
+		// Use the (1-based) index as the hash
+		for j, subject := range d.subjects {
+			commits = append(commits, Commit{
+				hash:    fmt.Sprintf("%d", j+1),
+				subject: subject,
+			})
+		}
+
+		problems := checkSquashCandidates(config, commits)
+		assert.Len(problems, len(d.expectedProblems), msg)
+
+		for j, expected := range d.expectedProblems {
+			errs := problems[fmt.Sprintf("%d", j)]
+
+			if !assert.Len(errs, len(expected), msg) {
+				continue
+			}
+
+			for k, err := range errs {
+				assert.True(strings.Contains(err.Error(), expected[k]), "%s: %v", msg, err)
+			}
+		}
+	}
+}
+
+func TestCheckCommitsDetailsAutosquash(t *testing.T) {
+	assert := assert.New(t)
+
+	config := createCommitConfig()
+	config.NeedFixes = false
+
+	body := []string{"body", "", "Signed-off-by: me@foo.com"}
+
+	commits := []Commit{
+		{hash: "a", subject: "foo: bar", body: body},
+		{hash: "b", subject: "fixup! foo: bar", body: body},
+		{hash: "c", subject: "foo: baz", body: body},
+	}
+
+	err := checkCommitsDetails(config, commits)
+	assert.Error(err)
+
+	violations, ok := err.(Violations)
+	assert.True(ok)
+	assert.Len(violations, 1)
+	assert.Equal("b", violations[0].Commit)
+	assert.Equal("fixup! commit must be squashed into commit a (\"foo: bar\")", violations[0].Message)
+
+	// Only the subject format is not checked when checking a single
+	// message
+	problems, err := checkMessage(config, "fixup! foo: bar\n\nbody\n\nSigned-off-by: me@foo.com\n", defaultCommentChar)
+	assert.NoError(err)
+	assert.Empty(problems)
+
+	problems, err = checkMessage(config, "fixup! foo: bar\n", defaultCommentChar)
+	assert.NoError(err)
+	if assert.Len(problems, 1) {
+		assert.Equal("pure whitespace body", problemMessage(problems[0]))
+	}
+
+	// The content policy still applies
+	config.Policy.NoBinaryFiles = true
+
+	commits[1].changes = []FileChange{
+		{Path: "foo.exe", Status: fileAdded, Binary: true},
+	}
+
+	err = checkCommitsDetails(config, commits)
+	assert.Error(err)
+
+	violations, ok = err.(Violations)
+	assert.True(ok)
+
+	var messages []string
+
+	for _, v := range violations {
+		assert.Equal("b", v.Commit)
+		messages = append(messages, v.Message)
+	}
+
+	assert.Contains(messages, "binary file \"foo.exe\" not permitted")
+	assert.Contains(messages, "fixup! commit must be squashed into commit a (\"foo: bar\")")
+}
@@ -30,6 +30,40 @@ type CommitConfig struct {
 	// Ignore NeedFixes if the subsystem matches this value.
 	IgnoreFixesSubsystem string
 
+	// Format of the subject line (kata style if not set).
+	SubjectGrammar SubjectGrammar
+
+	// If set, the subsystems (or scopes) must match one of these glob
+	// patterns.
+	AllowedSubsystems []string
+
+	// If set, used to check the issues referenced by "Fixes #XXX"
+	// entries.
+	IssueTracker IssueTracker
+
+	// Repository ("org/repo") of issues referenced without specifying
+	// a repository.
+	IssueRepo string
+
+	// If set, sign-offs (and other identity trailers) must be
+	// well-formed and the commit must be signed off by its author or
+	// committer.
+	StrictDCO bool
+
+	// Used to match sign-offs with the commit author and committer
+	// when StrictDCO is set (may be nil).
+	Identities *IdentityMap
+
+	// Checks performed on the files changed by each commit.
+	Policy ContentPolicy
+
+	// Subjects containing any of these words identify work in progress
+	// commits.
+	WIPMarkers []string
+
+	// If set, each commit in a range must have a different subject.
+	NoDuplicateSubjects bool
+
 	MaxSubjectLineLength int
 	MaxBodyLineLength    int
 
@@ -50,6 +84,17 @@ type Commit struct {
 	subsystem string
 	body      []string
 
+	// In "Name <email>" format.
+	author    string
+	committer string
+
+	// Hashes of the parent commits.
+	parents []string
+
+	// The files changed by the commit. Only set if required by the
+	// content policy.
+	changes []FileChange
+
 	// true if the commit undoes a previous commit.
 	revertCommit bool
 }
@@ -71,7 +116,7 @@ const (
 )
 
 var (
-	// Full path to git(1) command
+	// Full path to git(1) command (set on first use)
 	gitPath = ""
 	verbose = false
 	debug   = false
@@ -85,15 +130,6 @@ var (
 	errNoConfig = errors.New("Need config")
 )
 
-func init() {
-	var err error
-	gitPath, err = exec.LookPath("git")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: cannot find git in PATH\n")
-		os.Exit(1)
-	}
-}
-
 func commonChecks(config *CommitConfig, commit *Commit) error {
 	if config == nil {
 		return errNoConfig
@@ -106,19 +142,27 @@ func commonChecks(config *CommitConfig, commit *Commit) error {
 	return nil
 }
 
+// checkCommitSubject returns the first problem found with the commit
+// subject.
 func checkCommitSubject(config *CommitConfig, commit *Commit) error {
+	return firstError(checkCommitSubjectErrors(config, commit))
+}
+
+// checkCommitSubjectErrors returns all the problems found with the commit
+// subject.
+func checkCommitSubjectErrors(config *CommitConfig, commit *Commit) []error {
 	if err := commonChecks(config, commit); err != nil {
-		return err
+		return []error{err}
 	}
 
 	subject := commit.subject
 
 	if subject == "" {
-		return fmt.Errorf("Commit %v: empty subject", commit.hash)
+		return []error{commitErrorf(commit, "empty subject")}
 	}
 
 	if strings.TrimSpace(subject) == "" {
-		return fmt.Errorf("Commit %v: pure whitespace subject", commit.hash)
+		return []error{commitErrorf(commit, "pure whitespace subject")}
 	}
 
 	lcSubject := strings.ToLower(subject)
@@ -150,36 +194,42 @@ func checkCommitSubject(config *CommitConfig, commit *Commit) error {
 		subject = strings.TrimFunc(subject, func(r rune) bool { return string(r) == "\"" })
 	}
 
-	subsystemPattern := regexp.MustCompile(`^[[:blank:]]*([^:[:blank:]]*)[[:blank:]]*:`)
-
-	matches := subsystemPattern.FindStringSubmatch(subject)
+	grammar := config.SubjectGrammar
+	if grammar == nil {
+		grammar = kataSubjectGrammar{}
+	}
 
-	var subsystem string
+	var errs []error
 
-	if matches == nil || len(matches) != 2 {
-		return fmt.Errorf("Commit %v: Failed to find subsystem in subject: %q", commit.hash, subject)
+	subsystem, err := grammar.Parse(subject)
+	if err != nil {
+		errs = append(errs, commitErrorf(commit, "%v", err))
+	} else if subsystem == "" && grammar.RequiresSubsystem() && len(config.AllowedSubsystems) > 0 {
+		errs = append(errs, commitErrorf(commit, "blank subsystem not in allow list: %q", subject))
+	} else if err := checkSubsystemAllowed(config.AllowedSubsystems, subsystem); err != nil {
+		errs = append(errs, commitErrorf(commit, "%v: %q", err, subject))
 	}
 
-	// matches[0]: the entire matching string
-	// matches[1] the subsystem name (without the colon)
-	subsystem = matches[1]
-
 	length := len(subject)
 	if length > config.MaxSubjectLineLength {
-		return fmt.Errorf("Commit %v: subject too long (max %v, got %v): %q",
-			commit.hash, config.MaxSubjectLineLength, length, subject)
+		errs = append(errs, commitErrorf(commit, "subject too long (max %v, got %v): %q",
+			config.MaxSubjectLineLength, length, subject))
 	}
 
-	commit.subsystem = subsystem
-
 	if config.NeedFixes && config.FixesPattern != nil {
-		matches = config.FixesPattern.FindStringSubmatch(subject)
+		matches := config.FixesPattern.FindStringSubmatch(subject)
 
 		if matches != nil {
 			config.FoundFixes = true
 		}
 	}
 
+	if len(errs) > 0 {
+		return errs
+	}
+
+	commit.subsystem = subsystem
+
 	return nil
 }
 
@@ -239,23 +289,32 @@ func checkCommitBodyLine(config *CommitConfig, commit *Commit, line string,
 	// checks won't be applied to it.
 	length := len(line)
 	if !commit.revertCommit && length > config.MaxBodyLineLength && len(trimmedLine) > 1 {
-		return fmt.Errorf("commit %v: body line %d too long (max %v, got %v): %q",
-			commit.hash, 1+lineNum, config.MaxBodyLineLength, length, line)
+		return commitErrorf(commit, "body line %d too long (max %v, got %v): %q",
+			1+lineNum, config.MaxBodyLineLength, length, line)
 	}
 
 	return nil
 }
 
+// checkCommitBody returns the first problem found with the commit body.
 func checkCommitBody(config *CommitConfig, commit *Commit) error {
+	return firstError(checkCommitBodyErrors(config, commit))
+}
+
+// checkCommitBodyErrors returns all the problems found with the commit
+// body.
+func checkCommitBodyErrors(config *CommitConfig, commit *Commit) []error {
 	if err := commonChecks(config, commit); err != nil {
-		return err
+		return []error{err}
 	}
 
 	body := commit.body
 	if body == nil {
-		return fmt.Errorf("Commit %v: empty body", commit.hash)
+		return []error{commitErrorf(commit, "empty body")}
 	}
 
+	var errs []error
+
 	// line number which contains a sign-off line.
 	sobLine := -1
 
@@ -266,171 +325,129 @@ func checkCommitBody(config *CommitConfig, commit *Commit) error {
 		err := checkCommitBodyLine(config, commit, line, i,
 			&nonWhitespaceOnlyLine, &sobLine)
 		if err != nil {
-			return err
+			errs = append(errs, err)
 		}
 	}
 
 	if nonWhitespaceOnlyLine == -1 {
-		return fmt.Errorf("Commit %v: pure whitespace body", commit.hash)
+		return append(errs, commitErrorf(commit, "pure whitespace body"))
 	}
 
 	if config.NeedSOBS && sobLine == -1 {
-		return fmt.Errorf("Commit %v: no %v specified", commit.hash, config.SobString)
+		errs = append(errs, commitErrorf(commit, "no %v specified", config.SobString))
 	}
 
 	if sobLine == nonWhitespaceOnlyLine {
-		return fmt.Errorf("Commit %v: single-line %q body not permitted", commit.hash, config.SobString)
+		errs = append(errs, commitErrorf(commit, "single-line %q body not permitted", config.SobString))
 	}
 
-	return nil
+	return errs
 }
 
-func getCommitRange(commit, branch string) ([]string, error) {
-	if commit == "" {
-		return nil, errNoCommit
-	}
-
-	if branch == "" {
-		return nil, errNoBranch
-	}
-
-	var args []string
-
-	args = append(args, gitPath)
-	args = append(args, "rev-list")
-	args = append(args, "--no-merges")
-	args = append(args, "--reverse")
-	args = append(args, fmt.Sprintf("origin/%s..%s", branch, commit))
-
-	return runCommand(args)
-}
-
-func runGitLog(commit, prettyFormat string) ([]string, error) {
-	if commit == "" {
-		return nil, errNoCommit
-	}
-
-	if prettyFormat == "" {
-		return nil, errors.New("no pretty format")
-	}
-
-	var args []string
-
-	args = append(args, gitPath)
-	args = append(args, "log")
-	args = append(args, "-1")
-	args = append(args, fmt.Sprintf("--pretty=%s", prettyFormat))
-	args = append(args, commit)
-
-	return runCommand(args)
+// checkCommit returns the first problem found with the commit.
+func checkCommit(config *CommitConfig, commit *Commit) error {
+	return firstError(checkCommitErrors(config, commit))
 }
 
-func getCommitSubject(commit string) (string, error) {
-	if commit == "" {
-		return "", errNoCommit
-	}
-
-	lines, err := runGitLog(commit, "%s")
-	if err != nil {
-		return "", err
+// checkCommitErrors returns all the problems found with the commit.
+func checkCommitErrors(config *CommitConfig, commit *Commit) []error {
+	if err := commonChecks(config, commit); err != nil {
+		return []error{err}
 	}
 
-	return lines[0], nil
-}
+	var errs []error
 
-func getCommitBody(commit string) ([]string, error) {
-	if commit == "" {
-		return []string{}, errNoCommit
+	// The subject is created by git(1) and the commit will be squashed
+	// (see checkSquashCandidates()), but the rest of the commit must
+	// still be valid.
+	if !isAutosquashCommit(commit) {
+		errs = checkCommitSubjectErrors(config, commit)
 	}
 
-	return runGitLog(commit, "%b")
-}
+	errs = append(errs, checkCommitBodyErrors(config, commit)...)
 
-func checkCommit(config *CommitConfig, commit *Commit) error {
-	err := checkCommitSubject(config, commit)
-	if err != nil {
-		return err
+	if config.StrictDCO {
+		errs = append(errs, checkCommitDCO(config, commit)...)
 	}
 
-	return checkCommitBody(config, commit)
+	return append(errs, checkCommitContent(&config.Policy, commit)...)
 }
 
 // checkCommits performs checks on specified list of commits
-func checkCommits(config *CommitConfig, commitHashes []string) error {
+func checkCommits(config *CommitConfig, commits []Commit) error {
 	if config == nil {
 		return errNoConfig
 	}
 
-	if commitHashes == nil {
+	if commits == nil {
 		return errNoCommit
 	}
 
-	if len(commitHashes) == 0 {
+	if len(commits) == 0 {
 		// Handle Travis builds on master
 		return nil
 	}
 
-	commits, err := getCommits(commitHashes)
-	if err != nil {
-		return err
-	}
-
 	return checkCommitsDetails(config, commits)
 }
 
-func getCommits(commitHashes []string) ([]Commit, error) {
-	if commitHashes == nil {
-		return []Commit{}, errNoCommit
+// checkCommitsDetails checks all the specified commits. If any problems are
+// found, a Violations error describing all of them is returned.
+func checkCommitsDetails(config *CommitConfig, commits []Commit) (err error) {
+	if config == nil {
+		return errNoConfig
 	}
 
-	var commits []Commit
+	if commits == nil {
+		return errNoCommit
+	}
 
-	for _, hash := range commitHashes {
-		subject, err := getCommitSubject(hash)
-		if err != nil {
-			return []Commit{}, err
-		}
+	var results []Commit
+	var violations Violations
 
-		if subject == "" {
-			return []Commit{}, fmt.Errorf("Commit %v: empty subject", hash)
-		}
+	var fixesRefsPattern *regexp.Regexp
 
-		body, err := getCommitBody(hash)
-		if err != nil {
-			return []Commit{}, err
-		}
+	if config.IssueTracker != nil {
+		fixesRefsPattern = newFixesRefsPattern(config.FixesString)
+	}
 
-		if body == nil {
-			return []Commit{}, fmt.Errorf("Commit %v: empty body", hash)
-		}
+	var nonMerges []Commit
 
-		commit := Commit{
-			hash:    hash,
-			subject: subject,
-			body:    body,
+	for _, commit := range commits {
+		if len(commit.parents) <= 1 {
+			nonMerges = append(nonMerges, commit)
 		}
-
-		commits = append(commits, commit)
 	}
 
-	return commits, nil
-}
+	squashProblems := checkSquashCandidates(config, nonMerges)
 
-func checkCommitsDetails(config *CommitConfig, commits []Commit) (err error) {
-	if config == nil {
-		return errNoConfig
-	}
+	for _, commit := range commits {
+		if len(commit.parents) > 1 {
+			// Merge commits are created by git(1) so their
+			// messages are not checked.
+			if config.Policy.NoMerges {
+				violations = append(violations, newViolation(&commit,
+					commitErrorf(&commit, "merge commits not permitted")))
+			}
 
-	if commits == nil {
-		return errNoCommit
-	}
+			continue
+		}
 
-	var results []Commit
+		errs := checkCommitErrors(config, &commit)
 
-	for _, commit := range commits {
-		err = checkCommit(config, &commit)
-		if err != nil {
-			return err
+		if config.IssueTracker != nil {
+			issueErrs, err := verifyIssues(config, &commit, fixesRefsPattern)
+			if err != nil {
+				return err
+			}
+
+			errs = append(errs, issueErrs...)
+		}
+
+		errs = append(errs, squashProblems[commit.hash]...)
+
+		for _, err := range errs {
+			violations = append(violations, newViolation(&commit, err))
 		}
 
 		results = append(results, commit)
@@ -454,52 +471,29 @@ func checkCommitsDetails(config *CommitConfig, commits []Commit) (err error) {
 	}
 
 	if config.NeedFixes && !config.FoundFixes {
-		return fmt.Errorf("No %q found", config.FixesString)
-	}
-
-	return nil
-}
-
-// detectCIEnvironment checks if running under a recognised Continuous
-// Integration system and returns the commit from the source ("from")
-// branch, the destination ("to") branch (normally "master") that
-// "commit" wants to be merged into, and the source ("from") branch.
-//
-// If srcBranch is unset the CI is handling a "master" branch (non-PR)
-// build.
-func detectCIEnvironment() (commit, dstBranch, srcBranch string) {
-	var name string
-
-	if os.Getenv("TRAVIS") != "" {
-		name = "TravisCI"
-
-		commit = os.Getenv("TRAVIS_PULL_REQUEST_SHA")
-
-		srcBranch = os.Getenv("TRAVIS_PULL_REQUEST_BRANCH")
-		dstBranch = os.Getenv("TRAVIS_BRANCH")
-	} else if os.Getenv("ghprbPullId") != "" {
-		name = "JenkinsCI - github pull request builder"
-
-		commit = os.Getenv("ghprbActualCommit")
-
-		srcBranch = os.Getenv("ghprbSourceBranch")
-		dstBranch = os.Getenv("ghprbTargetBranch")
+		violations = append(violations, Violation{
+			Message: fmt.Sprintf("No %q found", config.FixesString),
+		})
 	}
 
-	if verbose && name != "" {
-		fmt.Printf("Detected %v Environment\n", name)
+	if len(violations) > 0 {
+		return violations
 	}
 
-	return commit, dstBranch, srcBranch
+	return nil
 }
 
 // preChecks performs checks on the range of commits described by commit
 // and branch.
-func preChecks(config *CommitConfig, commit, branch string) error {
+func preChecks(config *CommitConfig, source CommitSource, commit, branch string) error {
 	if config == nil {
 		return errNoConfig
 	}
 
+	if source == nil {
+		return errors.New("Need commit source")
+	}
+
 	if commit == "" {
 		return errNoCommit
 	}
@@ -508,11 +502,22 @@ func preChecks(config *CommitConfig, commit, branch string) error {
 		return errNoBranch
 	}
 
-	commits, err := getCommitRange(commit, branch)
+	commits, err := source.Commits(commit, branch)
 	if err != nil {
 		return err
 	}
 
+	if config.Policy.needsChanges() {
+		changeSource, ok := source.(ChangeSource)
+		if !ok {
+			return errors.New("content policies cannot be checked for this commit source")
+		}
+
+		if err := changeSource.LoadChanges(commits, config.Policy.needsHeader); err != nil {
+			return err
+		}
+	}
+
 	if verbose {
 		l := len(commits)
 
@@ -528,13 +533,87 @@ func preChecks(config *CommitConfig, commit, branch string) error {
 	return checkCommits(config, commits)
 }
 
+// getGitPath returns the full path to git(1).
+func getGitPath() (string, error) {
+	if gitPath != "" {
+		return gitPath, nil
+	}
+
+	path, err := exec.LookPath("git")
+	if err != nil {
+		return "", errors.New("cannot find git in PATH")
+	}
+
+	gitPath = path
+
+	return gitPath, nil
+}
+
+// runGitOutput runs git(1) with the specified arguments and returns its
+// stdout.
+func runGitOutput(args ...string) (string, error) {
+	return runGitWithInput("", args...)
+}
+
+// runGitWithInput runs git(1) with the specified arguments and stdin and
+// returns its stdout.
+func runGitWithInput(input string, args ...string) (string, error) {
+	path, err := getGitPath()
+	if err != nil {
+		return "", err
+	}
+
+	return runCommandWithInput(append([]string{path}, args...), input)
+}
+
+// runGit runs git(1) with the specified arguments and returns its stdout
+// lines as a slice.
+func runGit(args ...string) ([]string, error) {
+	path, err := getGitPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return runCommand(append([]string{path}, args...))
+}
+
 // runCommand runs the command specified by args and returns its stdout
 // lines as a slice.
 func runCommand(args []string) (stdout []string, err error) {
+	output, err := runCommandOutput(args)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(output, "\n")
+
+	// Remove last line if empty
+	length := len(lines)
+	last := lines[length-1]
+	if last == "" {
+		lines = lines[:length-1]
+	}
+
+	return lines, nil
+}
+
+// runCommandOutput runs the command specified by args and returns its
+// stdout.
+func runCommandOutput(args []string) (stdout string, err error) {
+	return runCommandWithInput(args, "")
+}
+
+// runCommandWithInput runs the command specified by args, writing input to
+// its stdin, and returns its stdout.
+func runCommandWithInput(args []string, input string) (stdout string, err error) {
 	var outBytes, errBytes bytes.Buffer
 
 	cmd := exec.Command(args[0], args[1:]...)
 
+	if input != "" {
+		cmd.Stdin = strings.NewReader(input)
+	}
+
 	cmdline := strings.Join(args, " ")
 	if debug {
 		fmt.Printf("Running: %q\n", cmdline)
@@ -548,24 +627,16 @@ func runCommand(args []string) (stdout []string, err error) {
 		e := fmt.Errorf("Failed to run command %v: %v"+
 			" (stdout: %v, stderr: %v)",
 			cmdline, err, outBytes.String(), errBytes.String())
-		return nil, e
-	}
-
-	lines := strings.Split(outBytes.String(), "\n")
-
-	// Remove last line if empty
-	length := len(lines)
-	last := lines[length-1]
-	if last == "" {
-		lines = lines[:length-1]
+		return "", e
 	}
 
-	return lines, nil
+	return outBytes.String(), nil
 }
 
 // NewCommitConfig creates a new CommitConfig object.
 func NewCommitConfig(needFixes, needSignOffs bool, fixesPrefix, signoffPrefix, ignoreFixesForSubsystem string, bodyLength, subjectLength int) *CommitConfig {
 	config := &CommitConfig{
+		SubjectGrammar:       kataSubjectGrammar{},
 		NeedSOBS:             needSignOffs,
 		NeedFixes:            needFixes,
 		MaxBodyLineLength:    bodyLength,
@@ -573,6 +644,7 @@ func NewCommitConfig(needFixes, needSignOffs bool, fixesPrefix, signoffPrefix, i
 		SobString:            defaultSobString,
 		FixesString:          defaultFixesString,
 		IgnoreFixesSubsystem: ignoreFixesForSubsystem,
+		WIPMarkers:           defaultWIPMarkers,
 	}
 
 	if config.MaxBodyLineLength == 0 {
@@ -592,7 +664,7 @@ func NewCommitConfig(needFixes, needSignOffs bool, fixesPrefix, signoffPrefix, i
 	}
 
 	if config.NeedFixes {
-		config.FixesPattern = regexp.MustCompile(fmt.Sprintf(`(?i:%s\s*:?\s*#\d+)`, config.FixesString))
+		config.FixesPattern = regexp.MustCompile(fmt.Sprintf(`(?i:%s\s*:?\s*%s)`, config.FixesString, issueRefRegex))
 	}
 
 	if config.NeedSOBS {
@@ -648,26 +720,27 @@ func expandCommitAndBranch(originalCommit, originalBranch string) (commit, branc
 }
 
 // getCommitAndBranch determines the commit and branch to use.
-func getCommitAndBranch(args, srcBranchesToIgnore []string) (commit, branch string, err error) {
-	var srcBranch string
-
+func getCommitAndBranch(args, srcBranchesToIgnore []string) (commit, branch, srcBranch string, err error) {
 	if args == nil {
-		return "", "", errors.New("No args")
+		return "", "", "", errors.New("No args")
 	}
 
 	if srcBranchesToIgnore == nil {
-		return "", "", errors.New("No source branches")
+		return "", "", "", errors.New("No source branches")
 	}
 
 	count := len(args)
 
 	if count == 0 {
 		// no arguments so check the environment
-		commit, branch, srcBranch = detectCIEnvironment()
+		commit, branch, srcBranch, err = detectCIEnvironment()
+		if err != nil {
+			return "", "", "", err
+		}
 	}
 
 	if count > 2 {
-		return "", "", errors.New("Too many arguments. Run with '--help' for usage")
+		return "", "", "", errors.New("Too many arguments. Run with '--help' for usage")
 	}
 
 	if commit == "" && count >= 1 {
@@ -692,11 +765,11 @@ func getCommitAndBranch(args, srcBranchesToIgnore []string) (commit, branch stri
 		}
 	}
 
-	return commit, branch, nil
+	return commit, branch, srcBranch, nil
 }
 
-func getCommitAndBranchWithContext(c *cli.Context) (commit, branch string, err error) {
-	return getCommitAndBranch(c.Args(), c.StringSlice("ignore-source-branch"))
+func getCommitAndBranchWithContext(c *cli.Context, config *ConfigFile) (commit, branch, srcBranch string, err error) {
+	return getCommitAndBranch(c.Args(), stringSliceOption(c, "ignore-source-branch", config.IgnoreSourceBranch))
 }
 
 func checkCommitsAction(c *cli.Context) error {
@@ -708,48 +781,71 @@ func checkCommitsAction(c *cli.Context) error {
 		fmt.Printf("Running %v version %s\n", c.App.Name, c.App.Version)
 	}
 
-	commit, branch, err := getCommitAndBranchWithContext(c)
+	format := c.String("format")
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	// Checking an mbox file does not require a repository.
+	configFile, err := loadConfigFile(c.String("config"), c.String("mbox") == "")
 	if err != nil {
 		return err
 	}
 
-	config := NewCommitConfig(c.Bool("need-fixes"),
-		c.Bool("need-sign-offs"),
-		c.String("fixes-prefix"),
-		c.String("sign-off-prefix"),
-		c.String("ignore-fixes-for-subsystem"),
-		int(c.Uint("body-length")),
-		int(c.Uint("subject-length")))
+	commit, branch, srcBranch, err := getCommitAndBranchWithContext(c, configFile)
+	if err != nil {
+		return err
+	}
 
-	return preChecks(config, commit, branch)
-}
+	config, err := newCommitConfigFromSettings(c, configFile.settings(branch, srcBranch))
+	if err != nil {
+		return err
+	}
 
-func main() {
-	app := cli.NewApp()
-	app.Name = "checkcommits"
-	app.Version = appVersion + " (commit " + appCommit + ")"
-	app.Description = "perform checks on git commits"
-	app.Usage = app.Description
-	app.UsageText = fmt.Sprintf("%s [global options] [commit [branch]]\n", app.Name)
-	app.UsageText += "\n"
-	app.UsageText += "Notes:\n"
-	app.UsageText += "   - The commit argument refers to the (normally latest) commit in the\n"
-	app.UsageText += "     source branch that wants to be merged into the specified (destination)\n"
-	app.UsageText += "     branch.\n\n"
-	app.UsageText += "   - If not specified, commit and branch will be set automatically\n"
-	app.UsageText += "     if running in a supported CI environment (Travis).\n\n"
-	app.UsageText += "   - If not running under a recognised CI environment, commit will default\n"
-	app.UsageText += fmt.Sprintf("     to %q and branch to %q.", defaultCommit, defaultBranch)
+	var source CommitSource = newGitCommitSource(c.String("remote"))
 
-	cli.VersionPrinter = func(c *cli.Context) {
-		// #nosec
-		fmt.Fprintf(os.Stdout, "%s version %s %s\n",
-			c.App.Name,
-			c.App.Version,
-			versionSuffix)
+	if mbox := c.String("mbox"); mbox != "" {
+		source = newMboxCommitSource(mbox)
 	}
 
-	app.Flags = []cli.Flag{
+	err = preChecks(config, source, commit, branch)
+
+	var violations Violations
+
+	if errors.As(err, &violations) {
+		// Only machine-readable output is written to stdout.
+		out := os.Stdout
+		if format == textFormat {
+			out = os.Stderr
+		}
+
+		if displayErr := displayViolations(out, format, violations); displayErr != nil {
+			return displayErr
+		}
+	}
+
+	return err
+}
+
+// appFlags returns the command-line options.
+func appFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "config",
+			Usage: fmt.Sprintf("Read options from the specified YAML or TOML `file` (default: %s in the top-level directory of the repository)", strings.Join(configFileNames, " or ")),
+		},
+
+		cli.StringFlag{
+			Name:  "remote",
+			Usage: "Compare the commit with the branch in the specified `remote`",
+			Value: defaultRemote,
+		},
+
+		cli.StringFlag{
+			Name:  "mbox",
+			Usage: "Check the commits in the specified mbox `file` (as created by \"git format-patch --stdout\") rather than a range of commits",
+		},
+
 		cli.BoolFlag{
 			Name:  "need-fixes, f",
 			Usage: fmt.Sprintf("Ensure at least one commit has a %q entry", defaultFixesString),
@@ -800,15 +896,135 @@ func main() {
 			Value: uint(defaultMaxBodyLineLength),
 		},
 
+		cli.StringFlag{
+			Name:  "format",
+			Usage: fmt.Sprintf("Display problems in the specified `format` (%s)", strings.Join(formatNames(), ", ")),
+			Value: defaultFormat,
+		},
+
+		cli.BoolFlag{
+			Name:  "verify-issues",
+			Usage: fmt.Sprintf("Ensure all issues referenced by %q entries exist, are open and are not pull requests (set GITHUB_TOKEN to authenticate)", defaultFixesString),
+		},
+
+		cli.StringFlag{
+			Name:   "issues-repo",
+			Usage:  "GitHub `repository` (org/repo) of issues referenced without a repository (default: the repository of the origin remote)",
+			EnvVar: "GITHUB_REPOSITORY",
+		},
+
+		cli.BoolFlag{
+			Name:  "strict-dco",
+			Usage: fmt.Sprintf("Ensure every commit has a well-formed %q entry matching its author or committer", defaultSobString),
+		},
+
+		cli.StringFlag{
+			Name:  "dco-identities",
+			Usage: "`file` mapping the alternative email addresses (or domains) of contributors used by --strict-dco",
+		},
+
+		cli.StringSliceFlag{
+			Name:  "wip-markers",
+			Usage: fmt.Sprintf("`word` in a subject identifying a work in progress commit (can be specified multiple times, default: %s)", strings.Join(defaultWIPMarkers, ", ")),
+		},
+
+		cli.BoolFlag{
+			Name:  "no-duplicate-subjects",
+			Usage: "Ensure all commits in the range have different subjects",
+		},
+
+		cli.UintFlag{
+			Name:  "max-file-size",
+			Usage: "Ensure no commit adds or modifies a file larger than `bytes` (0 for unlimited)",
+		},
+
+		cli.StringSliceFlag{
+			Name:  "blocked-paths",
+			Usage: "glob `pattern` matching paths commits must not change (can be specified multiple times)",
+		},
+
+		cli.BoolFlag{
+			Name:  "separate-vendor",
+			Usage: fmt.Sprintf("Ensure changes to %s directories are in their own commits", vendorDir),
+		},
+
+		cli.BoolFlag{
+			Name:  "no-merges",
+			Usage: "Ensure the range does not contain any merge commits",
+		},
+
+		cli.BoolFlag{
+			Name:  "no-binary-files",
+			Usage: "Ensure no commit adds or modifies a binary file",
+		},
+
+		cli.BoolFlag{
+			Name:  "require-spdx",
+			Usage: fmt.Sprintf("Ensure new source files have a %q header", spdxTag),
+		},
+
+		cli.StringFlag{
+			Name:   "github-api-url",
+			Usage:  "GitHub REST API `url` used to verify issues",
+			EnvVar: "GITHUB_API_URL",
+			Value:  defaultGitHubAPIURL,
+		},
+
+		cli.StringFlag{
+			Name:  "subject-style",
+			Usage: fmt.Sprintf("Subject line `style` (%q or %q)", kataSubjectStyle, conventionalSubjectStyle),
+			Value: defaultSubjectStyle,
+		},
+
+		cli.StringFlag{
+			Name:  "allowed-subsystems",
+			Usage: "`file` listing the allowed subsystems (or scopes), one glob pattern per line",
+		},
+
 		cli.UintFlag{
 			Name:  "subject-length",
 			Usage: "Specify maximum subject line `length`",
 			Value: uint(defaultMaxSubjectLineLength),
 		},
 	}
+}
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "checkcommits"
+	app.Version = appVersion + " (commit " + appCommit + ")"
+	app.Description = "perform checks on git commits"
+	app.Usage = app.Description
+	app.UsageText = fmt.Sprintf("%s [global options] [commit [branch]]\n", app.Name)
+	app.UsageText += fmt.Sprintf("   %s command [command options] [arguments...]\n", app.Name)
+	app.UsageText += "\n"
+	app.UsageText += "Notes:\n"
+	app.UsageText += "   - The commit argument refers to the (normally latest) commit in the\n"
+	app.UsageText += "     source branch that wants to be merged into the specified (destination)\n"
+	app.UsageText += "     branch.\n\n"
+	app.UsageText += "   - If not specified, commit and branch will be set automatically\n"
+	app.UsageText += "     if running in a supported CI environment:\n\n"
+	for _, name := range ciNames() {
+		app.UsageText += fmt.Sprintf("     - %s\n", name)
+	}
+	app.UsageText += "\n"
+	app.UsageText += "   - If not running under a recognised CI environment, commit will default\n"
+	app.UsageText += fmt.Sprintf("     to %q and branch to %q.", defaultCommit, defaultBranch)
+
+	cli.VersionPrinter = func(c *cli.Context) {
+		// #nosec
+		fmt.Fprintf(os.Stdout, "%s version %s %s\n",
+			c.App.Name,
+			c.App.Version,
+			versionSuffix)
+	}
+
+	app.Flags = appFlags()
 
 	app.Action = checkCommitsAction
 
+	app.Commands = appCommands()
+
 	err := app.Run(os.Args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
@@ -150,6 +150,12 @@ func clearCIVariables() {
 		"ghprbActualCommit",
 		"ghprbSourceBranch",
 		"ghprbTargetBranch",
+
+		"GITHUB_ACTIONS",
+		"GITLAB_CI",
+		"PROW_JOB_ID",
+		"TF_BUILD",
+		"JENKINS_URL",
 	}
 
 	for _, envVar := range envVars {
@@ -181,7 +187,7 @@ func TestCheckCommits(t *testing.T) {
 		t.Fatalf("expected failure")
 	}
 
-	err = checkCommits(config, []string{})
+	err = checkCommits(config, []Commit{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -198,20 +204,24 @@ func TestCheckCommits(t *testing.T) {
 		"ggggggggggggggggggggggggggggggggggggggggh",
 	}
 
-	err = checkCommits(nil, invalidCommits)
-	if err == nil {
-		t.Fatalf("expected an error")
-	}
+	source := newGitCommitSource("")
 
-	err = checkCommits(config, invalidCommits)
-	if err == nil {
-		t.Fatalf("expected an error")
+	for _, commit := range invalidCommits {
+		err = preChecks(nil, source, commit, "master")
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+
+		err = preChecks(config, source, commit, "master")
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
 	}
 
 	// Simulate a Travis build on the "master" branch
 	config.NeedFixes = true
 	config.NeedSOBS = true
-	err = checkCommits(config, []string{})
+	err = checkCommits(config, []Commit{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -244,17 +254,15 @@ func TestCheckCommitsDetails(t *testing.T) {
 	makeCommits := func(subjectLine, fixesLine string) []Commit {
 		return []Commit{
 			{
-				"",
-				subjectLine,
-				"",
-				[]string{
+				subject: subjectLine,
+				body: []string{
 					"body line 1",
 					"body line 2",
 					"\n",
 					fixesLine,
 					"\n",
 					"Signed-off-by: foo@bar.com",
-				}, false,
+				},
 			},
 		}
 	}
@@ -647,7 +655,10 @@ func TestDetectCIEnvironment(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		commit, dstBranch, srcBranch := detectCIEnvironment()
+		commit, dstBranch, srcBranch, err := detectCIEnvironment()
+		if err != nil {
+			t.Fatal(err)
+		}
 
 		if commit != d.expectedCommit {
 			t.Fatalf("Unexpected commit %v (%q: %+v)", commit, d.name, d)
@@ -695,7 +706,7 @@ func TestGetCommitAndBranch(t *testing.T) {
 	}
 
 	for _, d := range data {
-		commit, branch, err := getCommitAndBranch(d.args, d.srcBranchesToIgnore)
+		commit, branch, _, err := getCommitAndBranch(d.args, d.srcBranchesToIgnore)
 
 		if d.expectFail {
 			if err == nil {
@@ -728,7 +739,7 @@ func TestGetCommitAndBranch(t *testing.T) {
 		}
 
 		// XXX: crucially, no arguments (to trigger the auto-detection)
-		commit, dstBranch, err := getCommitAndBranch([]string{}, []string{})
+		commit, dstBranch, srcBranch, err := getCommitAndBranch([]string{}, []string{})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -741,6 +752,10 @@ func TestGetCommitAndBranch(t *testing.T) {
 			t.Fatalf("Unexpected destination branch %v (%+v)", dstBranch, d)
 		}
 
+		if srcBranch != d.expectedSrcBranch {
+			t.Fatalf("Unexpected source branch %v (%+v)", srcBranch, d)
+		}
+
 		// Crudely undo the changes (it'll be fully undone later
 		// using restoreEnv() but this is required to avoid
 		// tests interfering with one another).
@@ -752,3 +767,80 @@ func TestGetCommitAndBranch(t *testing.T) {
 
 	restoreEnv()
 }
+
+func TestCheckCommitsDetailsAllViolations(t *testing.T) {
+	assert := assert.New(t)
+
+	config := createCommitConfig()
+
+	longSubject := "foo: " + strings.Repeat("x", defaultMaxSubjectLineLength)
+	longLine := strings.Repeat("word ", defaultMaxBodyLineLength)
+
+	commits := []Commit{
+		{
+			hash:    "good",
+			subject: "foo: a good commit",
+			body:    []string{"body", "", "Signed-off-by: me@foo.com"},
+		},
+		{
+			// Invalid subject, two long lines and no sign-off
+			hash:    "bad1",
+			subject: "no subsystem",
+			body:    []string{"body", longLine, longLine},
+		},
+		{
+			// Subject too long and single-line sign-off body
+			hash:    "bad2",
+			subject: longSubject,
+			body:    []string{"Signed-off-by: me@foo.com"},
+		},
+	}
+
+	err := checkCommitsDetails(config, commits)
+	assert.Error(err)
+
+	violations, ok := err.(Violations)
+	assert.True(ok)
+
+	type violationSummary struct {
+		commit string
+		prefix string
+	}
+
+	expected := []violationSummary{
+		{"bad1", "Failed to find subsystem"},
+		{"bad1", "body line 2 too long"},
+		{"bad1", "body line 3 too long"},
+		{"bad1", "no Signed-off-by specified"},
+		{"bad2", "subject too long"},
+		{"bad2", `single-line "Signed-off-by" body not permitted`},
+		{"", `No "Fixes" found`},
+	}
+
+	assert.Len(violations, len(expected))
+
+	for i, v := range violations {
+		if i >= len(expected) {
+			break
+		}
+
+		msg := fmt.Sprintf("violation[%d]: %+v", i, v)
+
+		assert.Equal(expected[i].commit, v.Commit, msg)
+		assert.True(strings.HasPrefix(v.Message, expected[i].prefix), msg)
+
+		if v.Commit != "" {
+			assert.False(strings.HasPrefix(v.Message, "Commit"), msg)
+		}
+	}
+
+	assert.Equal([]string{"bad1", "bad2"}, violations.commits())
+
+	// A single problem should be reported as-is
+	config = createCommitConfig()
+	commits[0].body = []string{"body", "Fixes #1"}
+
+	err = checkCommitsDetails(config, commits[:1])
+	assert.Error(err)
+	assert.Equal("Commit good: no Signed-off-by specified", err.Error())
+}
@@ -0,0 +1,347 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// The prefix git(1) uses for branch references.
+const branchRefPrefix = "refs/heads/"
+
+// CIEnvironment describes the change being built by a Continuous
+// Integration system.
+type CIEnvironment struct {
+	// Name of the CI system.
+	Name string
+
+	// Commit from the source ("from") branch.
+	Commit string
+
+	// Commit at the tip of the destination branch (if known).
+	BaseCommit string
+
+	// The destination ("to") branch that Commit wants to be merged into.
+	DstBranch string
+
+	// The source ("from") branch. Unset for non-PR builds.
+	SrcBranch string
+}
+
+// CIDetector determines whether running under a particular Continuous
+// Integration system.
+type CIDetector interface {
+	// Name returns the name of the CI system.
+	Name() string
+
+	// Detect returns true and the details of the change being built if
+	// the environment (queried using getenv) belongs to the CI system.
+	Detect(getenv func(string) string) (env CIEnvironment, found bool, err error)
+}
+
+// ciDetectors is the list of all supported CI systems, in the order they
+// are checked.
+var ciDetectors []CIDetector
+
+func init() {
+	registerCIDetector(travisDetector{})
+	registerCIDetector(ghprbDetector{})
+	registerCIDetector(githubActionsDetector{})
+	registerCIDetector(gitlabDetector{})
+	registerCIDetector(prowDetector{})
+	registerCIDetector(azureDetector{})
+	registerCIDetector(jenkinsMultibranchDetector{})
+}
+
+// registerCIDetector adds a detector for a new CI system. Detectors are
+// checked in the order they were registered.
+func registerCIDetector(detector CIDetector) {
+	ciDetectors = append(ciDetectors, detector)
+}
+
+// ciNames returns the names of all supported CI systems.
+func ciNames() []string {
+	var names []string
+
+	for _, detector := range ciDetectors {
+		names = append(names, detector.Name())
+	}
+
+	return names
+}
+
+// detectCI returns the details of the change being built by the first CI
+// system that recognises the environment queried by getenv.
+func detectCI(getenv func(string) string) (env CIEnvironment, found bool, err error) {
+	for _, detector := range ciDetectors {
+		env, found, err = detector.Detect(getenv)
+		if err != nil {
+			return CIEnvironment{}, false, fmt.Errorf("%s: %v", detector.Name(), err)
+		}
+
+		if found {
+			env.Name = detector.Name()
+			return env, true, nil
+		}
+	}
+
+	return CIEnvironment{}, false, nil
+}
+
+// detectCIEnvironment checks if running under a recognised Continuous
+// Integration system and returns the commit from the source ("from")
+// branch, the destination ("to") branch (normally "master") that
+// "commit" wants to be merged into, and the source ("from") branch.
+//
+// If srcBranch is unset the CI is handling a "master" branch (non-PR)
+// build.
+func detectCIEnvironment() (commit, dstBranch, srcBranch string, err error) {
+	env, found, err := detectCI(os.Getenv)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if verbose && found {
+		fmt.Printf("Detected %v Environment\n", env.Name)
+
+		if env.BaseCommit != "" {
+			fmt.Printf("Destination branch %v is at commit %v\n", env.DstBranch, env.BaseCommit)
+		}
+	}
+
+	return env.Commit, env.DstBranch, env.SrcBranch, nil
+}
+
+// trimBranchRef returns the branch name for the specified reference.
+func trimBranchRef(ref string) string {
+	return strings.TrimPrefix(ref, branchRefPrefix)
+}
+
+// travisDetector detects TravisCI.
+type travisDetector struct{}
+
+func (travisDetector) Name() string {
+	return "TravisCI"
+}
+
+func (travisDetector) Detect(getenv func(string) string) (CIEnvironment, bool, error) {
+	if getenv("TRAVIS") == "" {
+		return CIEnvironment{}, false, nil
+	}
+
+	return CIEnvironment{
+		Commit:    getenv("TRAVIS_PULL_REQUEST_SHA"),
+		SrcBranch: getenv("TRAVIS_PULL_REQUEST_BRANCH"),
+		DstBranch: getenv("TRAVIS_BRANCH"),
+	}, true, nil
+}
+
+// ghprbDetector detects the Jenkins GitHub pull request builder plugin.
+type ghprbDetector struct{}
+
+func (ghprbDetector) Name() string {
+	return "JenkinsCI - github pull request builder"
+}
+
+func (ghprbDetector) Detect(getenv func(string) string) (CIEnvironment, bool, error) {
+	if getenv("ghprbPullId") == "" {
+		return CIEnvironment{}, false, nil
+	}
+
+	return CIEnvironment{
+		Commit:    getenv("ghprbActualCommit"),
+		SrcBranch: getenv("ghprbSourceBranch"),
+		DstBranch: getenv("ghprbTargetBranch"),
+	}, true, nil
+}
+
+// githubEventRef is a branch in a GitHub webhook event.
+type githubEventRef struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+// githubEvent contains the parts of a GitHub webhook event (as provided
+// by GitHub Actions) that describe the change being built.
+type githubEvent struct {
+	// Set for push events
+	After string `json:"after"`
+
+	// Set for pull request events
+	PullRequest *struct {
+		Head githubEventRef `json:"head"`
+		Base githubEventRef `json:"base"`
+	} `json:"pull_request"`
+}
+
+// githubActionsDetector detects GitHub Actions.
+type githubActionsDetector struct{}
+
+func (githubActionsDetector) Name() string {
+	return "GitHub Actions"
+}
+
+func (githubActionsDetector) Detect(getenv func(string) string) (CIEnvironment, bool, error) {
+	if getenv("GITHUB_ACTIONS") != "true" {
+		return CIEnvironment{}, false, nil
+	}
+
+	// Note that for pull requests, GITHUB_SHA is the commit that merges
+	// the PR branch into the destination branch, so the PR commit is
+	// obtained from the event if possible.
+	env := CIEnvironment{
+		Commit:    getenv("GITHUB_SHA"),
+		SrcBranch: getenv("GITHUB_HEAD_REF"),
+		DstBranch: getenv("GITHUB_BASE_REF"),
+	}
+
+	if env.DstBranch == "" {
+		// Not a PR build
+		env.DstBranch = trimBranchRef(getenv("GITHUB_REF"))
+	}
+
+	eventPath := getenv("GITHUB_EVENT_PATH")
+	if eventPath == "" {
+		return env, true, nil
+	}
+
+	bytes, err := ioutil.ReadFile(eventPath)
+	if err != nil {
+		return CIEnvironment{}, false, err
+	}
+
+	var event githubEvent
+
+	if err := json.Unmarshal(bytes, &event); err != nil {
+		return CIEnvironment{}, false, fmt.Errorf("invalid event file %q: %v", eventPath, err)
+	}
+
+	if pr := event.PullRequest; pr != nil {
+		env.Commit = pr.Head.SHA
+		env.SrcBranch = pr.Head.Ref
+		env.BaseCommit = pr.Base.SHA
+		env.DstBranch = pr.Base.Ref
+	} else if event.After != "" {
+		env.Commit = event.After
+	}
+
+	return env, true, nil
+}
+
+// gitlabDetector detects GitLab CI.
+type gitlabDetector struct{}
+
+func (gitlabDetector) Name() string {
+	return "GitLab CI"
+}
+
+func (gitlabDetector) Detect(getenv func(string) string) (CIEnvironment, bool, error) {
+	if getenv("GITLAB_CI") != "true" {
+		return CIEnvironment{}, false, nil
+	}
+
+	env := CIEnvironment{
+		Commit:    getenv("CI_COMMIT_SHA"),
+		DstBranch: getenv("CI_COMMIT_BRANCH"),
+	}
+
+	if getenv("CI_MERGE_REQUEST_IID") == "" {
+		return env, true, nil
+	}
+
+	env.SrcBranch = getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_NAME")
+	env.DstBranch = getenv("CI_MERGE_REQUEST_TARGET_BRANCH_NAME")
+	env.BaseCommit = getenv("CI_MERGE_REQUEST_TARGET_BRANCH_SHA")
+
+	// Only set for "merged results" pipelines where CI_COMMIT_SHA is
+	// the merge commit.
+	if sha := getenv("CI_MERGE_REQUEST_SOURCE_BRANCH_SHA"); sha != "" {
+		env.Commit = sha
+	}
+
+	return env, true, nil
+}
+
+// prowDetector detects Prow.
+type prowDetector struct{}
+
+func (prowDetector) Name() string {
+	return "Prow"
+}
+
+func (prowDetector) Detect(getenv func(string) string) (CIEnvironment, bool, error) {
+	if getenv("PROW_JOB_ID") == "" {
+		return CIEnvironment{}, false, nil
+	}
+
+	return CIEnvironment{
+		Commit:     getenv("PULL_PULL_SHA"),
+		BaseCommit: getenv("PULL_BASE_SHA"),
+		SrcBranch:  getenv("PULL_HEAD_REF"),
+		DstBranch:  getenv("PULL_BASE_REF"),
+	}, true, nil
+}
+
+// azureDetector detects Azure Pipelines.
+type azureDetector struct{}
+
+func (azureDetector) Name() string {
+	return "Azure Pipelines"
+}
+
+func (azureDetector) Detect(getenv func(string) string) (CIEnvironment, bool, error) {
+	if getenv("TF_BUILD") == "" {
+		return CIEnvironment{}, false, nil
+	}
+
+	env := CIEnvironment{
+		Commit:    getenv("BUILD_SOURCEVERSION"),
+		DstBranch: trimBranchRef(getenv("BUILD_SOURCEBRANCH")),
+	}
+
+	if getenv("SYSTEM_PULLREQUEST_PULLREQUESTID") == "" {
+		return env, true, nil
+	}
+
+	env.SrcBranch = trimBranchRef(getenv("SYSTEM_PULLREQUEST_SOURCEBRANCH"))
+	env.DstBranch = trimBranchRef(getenv("SYSTEM_PULLREQUEST_TARGETBRANCH"))
+
+	// Only set for GitHub repositories, where BUILD_SOURCEVERSION is
+	// the merge commit.
+	if sha := getenv("SYSTEM_PULLREQUEST_SOURCECOMMITID"); sha != "" {
+		env.Commit = sha
+	}
+
+	return env, true, nil
+}
+
+// jenkinsMultibranchDetector detects Jenkins multibranch pipelines.
+type jenkinsMultibranchDetector struct{}
+
+func (jenkinsMultibranchDetector) Name() string {
+	return "JenkinsCI - multibranch pipeline"
+}
+
+func (jenkinsMultibranchDetector) Detect(getenv func(string) string) (CIEnvironment, bool, error) {
+	if getenv("JENKINS_URL") == "" || getenv("BRANCH_NAME") == "" {
+		return CIEnvironment{}, false, nil
+	}
+
+	env := CIEnvironment{
+		Commit:    getenv("GIT_COMMIT"),
+		DstBranch: getenv("BRANCH_NAME"),
+	}
+
+	if getenv("CHANGE_ID") != "" {
+		env.SrcBranch = getenv("CHANGE_BRANCH")
+		env.DstBranch = getenv("CHANGE_TARGET")
+	}
+
+	return env, true, nil
+}
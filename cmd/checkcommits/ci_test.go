@@ -0,0 +1,362 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEnv returns a function which queries the specified variables rather
+// than the environment.
+func fakeEnv(vars map[string]string) func(string) string {
+	return func(key string) string {
+		return vars[key]
+	}
+}
+
+type testCIDetectorData struct {
+	env         map[string]string
+	expectFound bool
+	expectFail  bool
+	expectedEnv CIEnvironment
+}
+
+func testCIDetector(t *testing.T, detector CIDetector, data []testCIDetectorData) {
+	assert := assert.New(t)
+
+	for i, d := range data {
+		msg := fmt.Sprintf("%s: test[%d]: %+v", detector.Name(), i, d)
+
+		env, found, err := detector.Detect(fakeEnv(d.env))
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectFound, found, msg)
+		assert.Equal(d.expectedEnv, env, msg)
+	}
+}
+
+func TestTravisDetector(t *testing.T) {
+	data := []testCIDetectorData{
+		{map[string]string{}, false, false, CIEnvironment{}},
+		{
+			map[string]string{
+				"TRAVIS":                     "true",
+				"TRAVIS_BRANCH":              "main",
+				"TRAVIS_PULL_REQUEST_SHA":    "pr-sha",
+				"TRAVIS_PULL_REQUEST_BRANCH": "topic",
+			},
+			true, false,
+			CIEnvironment{Commit: "pr-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+		{
+			map[string]string{
+				"TRAVIS":        "true",
+				"TRAVIS_BRANCH": "main",
+			},
+			true, false,
+			CIEnvironment{DstBranch: "main"},
+		},
+	}
+
+	testCIDetector(t, travisDetector{}, data)
+}
+
+func TestGhprbDetector(t *testing.T) {
+	data := []testCIDetectorData{
+		{map[string]string{}, false, false, CIEnvironment{}},
+		{
+			map[string]string{
+				"ghprbPullId":       "123",
+				"ghprbActualCommit": "pr-sha",
+				"ghprbSourceBranch": "topic",
+				"ghprbTargetBranch": "main",
+			},
+			true, false,
+			CIEnvironment{Commit: "pr-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+	}
+
+	testCIDetector(t, ghprbDetector{}, data)
+}
+
+func TestGitHubActionsDetector(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	prEvent := filepath.Join(dir, "pr.json")
+	pushEvent := filepath.Join(dir, "push.json")
+	invalidEvent := filepath.Join(dir, "invalid.json")
+	missingEvent := filepath.Join(dir, "missing.json")
+
+	files := map[string]string{
+		prEvent: `{
+			"action": "synchronize",
+			"pull_request": {
+				"head": {"ref": "topic", "sha": "head-sha"},
+				"base": {"ref": "main", "sha": "base-sha"}
+			}
+		}`,
+		pushEvent:    `{"before": "before-sha", "after": "after-sha"}`,
+		invalidEvent: `{`,
+	}
+
+	for file, contents := range files {
+		err := ioutil.WriteFile(file, []byte(contents), 0600)
+		assert.NoError(err)
+	}
+
+	prEnv := map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_SHA":        "merge-sha",
+		"GITHUB_REF":        "refs/pull/1/merge",
+		"GITHUB_HEAD_REF":   "topic",
+		"GITHUB_BASE_REF":   "main",
+		"GITHUB_EVENT_PATH": prEvent,
+	}
+
+	pushEnv := map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_SHA":        "push-sha",
+		"GITHUB_REF":        "refs/heads/main",
+		"GITHUB_EVENT_PATH": pushEvent,
+	}
+
+	withEventPath := func(env map[string]string, path string) map[string]string {
+		result := make(map[string]string)
+
+		for k, v := range env {
+			result[k] = v
+		}
+
+		result["GITHUB_EVENT_PATH"] = path
+
+		return result
+	}
+
+	data := []testCIDetectorData{
+		{map[string]string{}, false, false, CIEnvironment{}},
+		{map[string]string{"GITHUB_ACTIONS": "false"}, false, false, CIEnvironment{}},
+
+		{
+			prEnv, true, false,
+			CIEnvironment{Commit: "head-sha", BaseCommit: "base-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+		{
+			pushEnv, true, false,
+			CIEnvironment{Commit: "after-sha", DstBranch: "main"},
+		},
+
+		// No event file so fall back to the environment
+		{
+			withEventPath(prEnv, ""), true, false,
+			CIEnvironment{Commit: "merge-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+		{
+			withEventPath(pushEnv, ""), true, false,
+			CIEnvironment{Commit: "push-sha", DstBranch: "main"},
+		},
+
+		{withEventPath(prEnv, invalidEvent), false, true, CIEnvironment{}},
+		{withEventPath(prEnv, missingEvent), false, true, CIEnvironment{}},
+	}
+
+	testCIDetector(t, githubActionsDetector{}, data)
+}
+
+func TestGitLabDetector(t *testing.T) {
+	data := []testCIDetectorData{
+		{map[string]string{}, false, false, CIEnvironment{}},
+		{
+			map[string]string{
+				"GITLAB_CI":        "true",
+				"CI_COMMIT_SHA":    "commit-sha",
+				"CI_COMMIT_BRANCH": "main",
+			},
+			true, false,
+			CIEnvironment{Commit: "commit-sha", DstBranch: "main"},
+		},
+		{
+			map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_COMMIT_SHA":                       "commit-sha",
+				"CI_MERGE_REQUEST_IID":                "7",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "topic",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_SHA":  "base-sha",
+			},
+			true, false,
+			CIEnvironment{Commit: "commit-sha", BaseCommit: "base-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+		{
+			map[string]string{
+				"GITLAB_CI":                           "true",
+				"CI_COMMIT_SHA":                       "merge-sha",
+				"CI_MERGE_REQUEST_IID":                "7",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_NAME": "topic",
+				"CI_MERGE_REQUEST_SOURCE_BRANCH_SHA":  "head-sha",
+				"CI_MERGE_REQUEST_TARGET_BRANCH_NAME": "main",
+			},
+			true, false,
+			CIEnvironment{Commit: "head-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+	}
+
+	testCIDetector(t, gitlabDetector{}, data)
+}
+
+func TestProwDetector(t *testing.T) {
+	data := []testCIDetectorData{
+		{map[string]string{}, false, false, CIEnvironment{}},
+		{
+			map[string]string{
+				"PROW_JOB_ID":   "job-id",
+				"PULL_BASE_REF": "main",
+				"PULL_BASE_SHA": "base-sha",
+				"PULL_PULL_SHA": "head-sha",
+				"PULL_HEAD_REF": "topic",
+			},
+			true, false,
+			CIEnvironment{Commit: "head-sha", BaseCommit: "base-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+		{
+			// Postsubmit job
+			map[string]string{
+				"PROW_JOB_ID":   "job-id",
+				"PULL_BASE_REF": "main",
+				"PULL_BASE_SHA": "base-sha",
+			},
+			true, false,
+			CIEnvironment{BaseCommit: "base-sha", DstBranch: "main"},
+		},
+	}
+
+	testCIDetector(t, prowDetector{}, data)
+}
+
+func TestAzureDetector(t *testing.T) {
+	data := []testCIDetectorData{
+		{map[string]string{}, false, false, CIEnvironment{}},
+		{
+			map[string]string{
+				"TF_BUILD":            "True",
+				"BUILD_SOURCEVERSION": "commit-sha",
+				"BUILD_SOURCEBRANCH":  "refs/heads/main",
+			},
+			true, false,
+			CIEnvironment{Commit: "commit-sha", DstBranch: "main"},
+		},
+		{
+			map[string]string{
+				"TF_BUILD":                          "True",
+				"BUILD_SOURCEVERSION":               "merge-sha",
+				"BUILD_SOURCEBRANCH":                "refs/pull/1/merge",
+				"SYSTEM_PULLREQUEST_PULLREQUESTID":  "1",
+				"SYSTEM_PULLREQUEST_SOURCEBRANCH":   "refs/heads/topic",
+				"SYSTEM_PULLREQUEST_TARGETBRANCH":   "refs/heads/main",
+				"SYSTEM_PULLREQUEST_SOURCECOMMITID": "head-sha",
+			},
+			true, false,
+			CIEnvironment{Commit: "head-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+		{
+			// Azure Repos (no source commit)
+			map[string]string{
+				"TF_BUILD":                         "True",
+				"BUILD_SOURCEVERSION":              "commit-sha",
+				"SYSTEM_PULLREQUEST_PULLREQUESTID": "1",
+				"SYSTEM_PULLREQUEST_SOURCEBRANCH":  "topic",
+				"SYSTEM_PULLREQUEST_TARGETBRANCH":  "main",
+			},
+			true, false,
+			CIEnvironment{Commit: "commit-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+	}
+
+	testCIDetector(t, azureDetector{}, data)
+}
+
+func TestJenkinsMultibranchDetector(t *testing.T) {
+	data := []testCIDetectorData{
+		{map[string]string{}, false, false, CIEnvironment{}},
+
+		// Not a multibranch pipeline
+		{map[string]string{"JENKINS_URL": "https://jenkins"}, false, false, CIEnvironment{}},
+
+		{
+			map[string]string{
+				"JENKINS_URL": "https://jenkins",
+				"BRANCH_NAME": "main",
+				"GIT_COMMIT":  "commit-sha",
+			},
+			true, false,
+			CIEnvironment{Commit: "commit-sha", DstBranch: "main"},
+		},
+		{
+			map[string]string{
+				"JENKINS_URL":   "https://jenkins",
+				"BRANCH_NAME":   "PR-1",
+				"GIT_COMMIT":    "head-sha",
+				"CHANGE_ID":     "1",
+				"CHANGE_BRANCH": "topic",
+				"CHANGE_TARGET": "main",
+			},
+			true, false,
+			CIEnvironment{Commit: "head-sha", DstBranch: "main", SrcBranch: "topic"},
+		},
+	}
+
+	testCIDetector(t, jenkinsMultibranchDetector{}, data)
+}
+
+func TestDetectCI(t *testing.T) {
+	assert := assert.New(t)
+
+	env, found, err := detectCI(fakeEnv(map[string]string{}))
+	assert.NoError(err)
+	assert.False(found)
+	assert.Equal(CIEnvironment{}, env)
+
+	// Earlier detectors take priority
+	env, found, err = detectCI(fakeEnv(map[string]string{
+		"TRAVIS":         "true",
+		"TRAVIS_BRANCH":  "travis-branch",
+		"GITHUB_ACTIONS": "true",
+		"GITHUB_REF":     "refs/heads/github-branch",
+	}))
+	assert.NoError(err)
+	assert.True(found)
+	assert.Equal("TravisCI", env.Name)
+	assert.Equal("travis-branch", env.DstBranch)
+
+	env, found, err = detectCI(fakeEnv(map[string]string{
+		"PROW_JOB_ID":   "job-id",
+		"PULL_BASE_REF": "main",
+	}))
+	assert.NoError(err)
+	assert.True(found)
+	assert.Equal("Prow", env.Name)
+
+	_, _, err = detectCI(fakeEnv(map[string]string{
+		"GITHUB_ACTIONS":    "true",
+		"GITHUB_EVENT_PATH": "/does/not/exist",
+	}))
+	assert.Error(err)
+
+	assert.Len(ciNames(), len(ciDetectors))
+}
@@ -0,0 +1,467 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Names of the configuration files searched for in the top-level directory
+// of the repository.
+var configFileNames = []string{
+	".checkcommits.yaml",
+	".checkcommits.yml",
+	".checkcommits.toml",
+}
+
+// CheckSettings are the options that can be specified in the configuration
+// file, both globally and in a profile. Options that are not specified are
+// nil.
+//
+// Each option has the same name as the corresponding command-line flag.
+type CheckSettings struct {
+	NeedFixes               *bool   `yaml:"need-fixes" toml:"need-fixes"`
+	NeedSignOffs            *bool   `yaml:"need-sign-offs" toml:"need-sign-offs"`
+	BodyLength              *uint   `yaml:"body-length" toml:"body-length"`
+	SubjectLength           *uint   `yaml:"subject-length" toml:"subject-length"`
+	FixesPrefix             *string `yaml:"fixes-prefix" toml:"fixes-prefix"`
+	SignOffPrefix           *string `yaml:"sign-off-prefix" toml:"sign-off-prefix"`
+	IgnoreFixesForSubsystem *string `yaml:"ignore-fixes-for-subsystem" toml:"ignore-fixes-for-subsystem"`
+	SubjectStyle            *string `yaml:"subject-style" toml:"subject-style"`
+
+	// Relative paths are relative to the directory containing the
+	// configuration file.
+	AllowedSubsystems *string `yaml:"allowed-subsystems" toml:"allowed-subsystems"`
+
+	VerifyIssues *bool   `yaml:"verify-issues" toml:"verify-issues"`
+	IssuesRepo   *string `yaml:"issues-repo" toml:"issues-repo"`
+
+	StrictDCO *bool `yaml:"strict-dco" toml:"strict-dco"`
+
+	// Relative paths are relative to the directory containing the
+	// configuration file.
+	DCOIdentities *string `yaml:"dco-identities" toml:"dco-identities"`
+
+	MaxFileSize    *uint    `yaml:"max-file-size" toml:"max-file-size"`
+	BlockedPaths   []string `yaml:"blocked-paths" toml:"blocked-paths"`
+	SeparateVendor *bool    `yaml:"separate-vendor" toml:"separate-vendor"`
+	NoMerges       *bool    `yaml:"no-merges" toml:"no-merges"`
+	NoBinaryFiles  *bool    `yaml:"no-binary-files" toml:"no-binary-files"`
+	RequireSPDX    *bool    `yaml:"require-spdx" toml:"require-spdx"`
+
+	WIPMarkers          []string `yaml:"wip-markers" toml:"wip-markers"`
+	NoDuplicateSubjects *bool    `yaml:"no-duplicate-subjects" toml:"no-duplicate-subjects"`
+}
+
+// ConfigProfile is a set of options which only apply to particular
+// branches.
+type ConfigProfile struct {
+	// Used in messages.
+	Name string `yaml:"name" toml:"name"`
+
+	// Regular expressions matching the destination branches the profile
+	// applies to.
+	Branches []string `yaml:"branches" toml:"branches"`
+
+	// Regular expressions matching the source branches the profile
+	// applies to.
+	SourceBranches []string `yaml:"source-branches" toml:"source-branches"`
+
+	CheckSettings `yaml:",inline"`
+}
+
+// ConfigFile represents the repository configuration file.
+type ConfigFile struct {
+	CheckSettings `yaml:",inline"`
+
+	IgnoreSourceBranch []string `yaml:"ignore-source-branch" toml:"ignore-source-branch"`
+
+	// Profiles are applied in order, so a later profile overrides the
+	// options specified by an earlier one.
+	Profiles []ConfigProfile `yaml:"profiles" toml:"profiles"`
+
+	// The file the configuration was read from.
+	path string
+}
+
+// merge sets all options specified in other.
+func (s *CheckSettings) merge(other CheckSettings) {
+	if other.NeedFixes != nil {
+		s.NeedFixes = other.NeedFixes
+	}
+
+	if other.NeedSignOffs != nil {
+		s.NeedSignOffs = other.NeedSignOffs
+	}
+
+	if other.BodyLength != nil {
+		s.BodyLength = other.BodyLength
+	}
+
+	if other.SubjectLength != nil {
+		s.SubjectLength = other.SubjectLength
+	}
+
+	if other.FixesPrefix != nil {
+		s.FixesPrefix = other.FixesPrefix
+	}
+
+	if other.SignOffPrefix != nil {
+		s.SignOffPrefix = other.SignOffPrefix
+	}
+
+	if other.IgnoreFixesForSubsystem != nil {
+		s.IgnoreFixesForSubsystem = other.IgnoreFixesForSubsystem
+	}
+
+	if other.SubjectStyle != nil {
+		s.SubjectStyle = other.SubjectStyle
+	}
+
+	if other.AllowedSubsystems != nil {
+		s.AllowedSubsystems = other.AllowedSubsystems
+	}
+
+	if other.VerifyIssues != nil {
+		s.VerifyIssues = other.VerifyIssues
+	}
+
+	if other.IssuesRepo != nil {
+		s.IssuesRepo = other.IssuesRepo
+	}
+
+	if other.StrictDCO != nil {
+		s.StrictDCO = other.StrictDCO
+	}
+
+	if other.DCOIdentities != nil {
+		s.DCOIdentities = other.DCOIdentities
+	}
+
+	if other.MaxFileSize != nil {
+		s.MaxFileSize = other.MaxFileSize
+	}
+
+	if other.BlockedPaths != nil {
+		s.BlockedPaths = other.BlockedPaths
+	}
+
+	if other.SeparateVendor != nil {
+		s.SeparateVendor = other.SeparateVendor
+	}
+
+	if other.NoMerges != nil {
+		s.NoMerges = other.NoMerges
+	}
+
+	if other.NoBinaryFiles != nil {
+		s.NoBinaryFiles = other.NoBinaryFiles
+	}
+
+	if other.RequireSPDX != nil {
+		s.RequireSPDX = other.RequireSPDX
+	}
+
+	if other.WIPMarkers != nil {
+		s.WIPMarkers = other.WIPMarkers
+	}
+
+	if other.NoDuplicateSubjects != nil {
+		s.NoDuplicateSubjects = other.NoDuplicateSubjects
+	}
+}
+
+// readConfigFile reads the specified YAML or TOML configuration file.
+func readConfigFile(path string) (*ConfigFile, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ConfigFile{
+		path: path,
+	}
+
+	if strings.HasSuffix(path, ".toml") {
+		metadata, err := toml.Decode(string(bytes), config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config file %q: %v", path, err)
+		}
+
+		if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("invalid config file %q: unknown option %q", path, undecoded[0].String())
+		}
+	} else if err := yaml.UnmarshalStrict(bytes, config); err != nil {
+		return nil, fmt.Errorf("invalid config file %q: %v", path, err)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %q: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// validate checks the regular expressions in the configuration.
+func (f *ConfigFile) validate() error {
+	patterns := append([]string{}, f.IgnoreSourceBranch...)
+
+	for i, profile := range f.Profiles {
+		if len(profile.Branches) == 0 && len(profile.SourceBranches) == 0 {
+			return fmt.Errorf("profile %d (%q) does not specify any branches", i, profile.Name)
+		}
+
+		patterns = append(patterns, profile.Branches...)
+		patterns = append(patterns, profile.SourceBranches...)
+	}
+
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid branch pattern %q: %v", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// findConfigFile returns the path to the configuration file in the
+// specified directory, or "" if there is no such file.
+func findConfigFile(dir string) (string, error) {
+	var found []string
+
+	for _, name := range configFileNames {
+		path := filepath.Join(dir, name)
+
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+
+	return "", fmt.Errorf("multiple config files found: %s", strings.Join(found, ", "))
+}
+
+// getRepoTopLevel returns the top-level directory of the repository.
+func getRepoTopLevel() (string, error) {
+	lines, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("failed to determine top-level directory of repository")
+	}
+
+	return lines[0], nil
+}
+
+// loadConfigFile reads the specified configuration file or, if not
+// specified, the configuration file in the top-level directory of the
+// repository. If no file is found, an empty configuration is returned. If
+// needRepo is false, not being in a repository is not an error (for example
+// when checking an mbox file), and an empty configuration is returned.
+func loadConfigFile(path string, needRepo bool) (*ConfigFile, error) {
+	if path == "" {
+		topLevel, err := getRepoTopLevel()
+		if err != nil {
+			if !needRepo {
+				return &ConfigFile{}, nil
+			}
+
+			return nil, err
+		}
+
+		path, err = findConfigFile(topLevel)
+		if err != nil {
+			return nil, err
+		}
+
+		if path == "" {
+			return &ConfigFile{}, nil
+		}
+	}
+
+	if verbose {
+		fmt.Printf("Using config file %v\n", path)
+	}
+
+	return readConfigFile(path)
+}
+
+// settings returns the options that apply when merging commits from
+// srcBranch into dstBranch: the global options overridden by the options
+// from all matching profiles.
+func (f *ConfigFile) settings(dstBranch, srcBranch string) CheckSettings {
+	settings := f.CheckSettings
+
+	for _, profile := range f.Profiles {
+		if branchMatchesREList(dstBranch, profile.Branches) == "" &&
+			branchMatchesREList(srcBranch, profile.SourceBranches) == "" {
+			continue
+		}
+
+		if verbose {
+			fmt.Printf("Using config profile %q\n", profile.Name)
+		}
+
+		settings.merge(profile.CheckSettings)
+	}
+
+	settings.AllowedSubsystems = f.resolvePath(settings.AllowedSubsystems)
+	settings.DCOIdentities = f.resolvePath(settings.DCOIdentities)
+
+	return settings
+}
+
+// resolvePath returns the specified path relative to the directory
+// containing the configuration file.
+func (f *ConfigFile) resolvePath(path *string) *string {
+	if path == nil || *path == "" || f.path == "" || filepath.IsAbs(*path) {
+		return path
+	}
+
+	resolved := filepath.Join(filepath.Dir(f.path), *path)
+
+	return &resolved
+}
+
+// boolOption returns the value of the specified flag if set on the command
+// line or if value is nil, else value.
+func boolOption(c *cli.Context, name string, value *bool) bool {
+	if c.IsSet(name) || value == nil {
+		return c.Bool(name)
+	}
+
+	return *value
+}
+
+// uintOption returns the value of the specified flag if set on the command
+// line or if value is nil, else value.
+func uintOption(c *cli.Context, name string, value *uint) uint {
+	if c.IsSet(name) || value == nil {
+		return c.Uint(name)
+	}
+
+	return *value
+}
+
+// stringOption returns the value of the specified flag if set on the
+// command line or if value is nil, else value.
+func stringOption(c *cli.Context, name string, value *string) string {
+	if c.IsSet(name) || value == nil {
+		return c.String(name)
+	}
+
+	return *value
+}
+
+// stringSliceOption returns the value of the specified flag if set on the
+// command line or if value is nil, else value.
+func stringSliceOption(c *cli.Context, name string, value []string) []string {
+	if c.IsSet(name) || value == nil {
+		return c.StringSlice(name)
+	}
+
+	return value
+}
+
+// newCommitConfigFromSettings creates a CommitConfig from the specified
+// settings, overridden by any options specified on the command line.
+func newCommitConfigFromSettings(c *cli.Context, settings CheckSettings) (*CommitConfig, error) {
+	config := NewCommitConfig(boolOption(c, "need-fixes", settings.NeedFixes),
+		boolOption(c, "need-sign-offs", settings.NeedSignOffs),
+		stringOption(c, "fixes-prefix", settings.FixesPrefix),
+		stringOption(c, "sign-off-prefix", settings.SignOffPrefix),
+		stringOption(c, "ignore-fixes-for-subsystem", settings.IgnoreFixesForSubsystem),
+		int(uintOption(c, "body-length", settings.BodyLength)),
+		int(uintOption(c, "subject-length", settings.SubjectLength)))
+
+	var err error
+
+	config.SubjectGrammar, err = newSubjectGrammar(stringOption(c, "subject-style", settings.SubjectStyle))
+	if err != nil {
+		return nil, err
+	}
+
+	if file := stringOption(c, "allowed-subsystems", settings.AllowedSubsystems); file != "" {
+		config.AllowedSubsystems, err = readAllowList(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config.StrictDCO = boolOption(c, "strict-dco", settings.StrictDCO)
+
+	if file := stringOption(c, "dco-identities", settings.DCOIdentities); file != "" {
+		config.Identities, err = readIdentityMap(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	config.Policy = ContentPolicy{
+		MaxFileSize:    uint64(uintOption(c, "max-file-size", settings.MaxFileSize)),
+		BlockedPaths:   stringSliceOption(c, "blocked-paths", settings.BlockedPaths),
+		SeparateVendor: boolOption(c, "separate-vendor", settings.SeparateVendor),
+		NoMerges:       boolOption(c, "no-merges", settings.NoMerges),
+		NoBinaryFiles:  boolOption(c, "no-binary-files", settings.NoBinaryFiles),
+		RequireSPDX:    boolOption(c, "require-spdx", settings.RequireSPDX),
+	}
+
+	if err := config.Policy.validate(); err != nil {
+		return nil, err
+	}
+
+	// An empty list in the config file disables the check.
+	if c.IsSet("wip-markers") || settings.WIPMarkers != nil {
+		config.WIPMarkers = stringSliceOption(c, "wip-markers", settings.WIPMarkers)
+	}
+
+	config.NoDuplicateSubjects = boolOption(c, "no-duplicate-subjects", settings.NoDuplicateSubjects)
+
+	if boolOption(c, "verify-issues", settings.VerifyIssues) {
+		remote := defaultRemote
+
+		// A remote specified explicitly (for example by the pre-push
+		// hook) must exist, even if the issues repository is known.
+		if c.IsSet("remote") {
+			remote = c.String("remote")
+
+			if _, err := getRemoteURL(remote); err != nil {
+				return nil, err
+			}
+		}
+
+		config.IssueRepo = stringOption(c, "issues-repo", settings.IssuesRepo)
+
+		if config.IssueRepo == "" {
+			config.IssueRepo, err = getRemoteIssueRepo(remote)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		tracker := newGitHubTracker(c.String("github-api-url"), os.Getenv("GITHUB_TOKEN"))
+
+		config.IssueTracker = newCachingTracker(tracker)
+	}
+
+	return config, nil
+}
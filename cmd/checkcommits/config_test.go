@@ -0,0 +1,453 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+const testYAMLConfig = `
+need-fixes: true
+body-length: 100
+subject-style: conventional
+allowed-subsystems: subsystems.txt
+dco-identities: /etc/identities
+ignore-source-branch:
+  - "^dependabot/"
+profiles:
+  - name: stable
+    branches: ["^stable-"]
+    need-sign-offs: true
+    subject-length: 60
+    strict-dco: true
+    dco-identities: identities
+  - name: bots
+    source-branches: ["^bot-"]
+    need-fixes: false
+`
+
+const testTOMLConfig = `
+need-fixes = true
+body-length = 100
+subject-style = "conventional"
+allowed-subsystems = "subsystems.txt"
+dco-identities = "/etc/identities"
+ignore-source-branch = ["^dependabot/"]
+
+[[profiles]]
+name = "stable"
+branches = ["^stable-"]
+need-sign-offs = true
+subject-length = 60
+
+[[profiles]]
+name = "bots"
+source-branches = ["^bot-"]
+need-fixes = false
+`
+
+// newTestContext returns a context for the specified command-line
+// arguments.
+func newTestContext(args []string) (*cli.Context, error) {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+
+	for _, f := range appFlags() {
+		f.Apply(set)
+	}
+
+	if err := set.Parse(args); err != nil {
+		return nil, err
+	}
+
+	return cli.NewContext(nil, set, nil), nil
+}
+
+func TestReadConfigFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	type testData struct {
+		name       string
+		contents   string
+		expectFail bool
+	}
+
+	data := []testData{
+		{".checkcommits.yaml", testYAMLConfig, false},
+		{".checkcommits.toml", testTOMLConfig, false},
+
+		{".checkcommits.yaml", "need-fixes: [", true},
+		{".checkcommits.yaml", "unknown-option: true", true},
+		{".checkcommits.yaml", "body-length: -1", true},
+		{".checkcommits.yaml", "ignore-source-branch: ['[']", true},
+		{".checkcommits.yaml", "profiles: [{name: foo, need-fixes: true}]", true},
+		{".checkcommits.yaml", "profiles: [{branches: ['(']}]", true},
+		{".checkcommits.toml", "need-fixes = ", true},
+		{".checkcommits.toml", "unknown-option = true", true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		path := filepath.Join(dir, d.name)
+
+		err := ioutil.WriteFile(path, []byte(d.contents), 0600)
+		assert.NoError(err, msg)
+
+		config, err := readConfigFile(path)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+
+		assert.True(*config.NeedFixes, msg)
+		assert.Nil(config.NeedSignOffs, msg)
+		assert.Equal(uint(100), *config.BodyLength, msg)
+		assert.Equal(conventionalSubjectStyle, *config.SubjectStyle, msg)
+		assert.Equal([]string{"^dependabot/"}, config.IgnoreSourceBranch, msg)
+		assert.Len(config.Profiles, 2, msg)
+		assert.Equal("stable", config.Profiles[0].Name, msg)
+		assert.Equal([]string{"^stable-"}, config.Profiles[0].Branches, msg)
+		assert.Equal(uint(60), *config.Profiles[0].SubjectLength, msg)
+		assert.False(*config.Profiles[1].NeedFixes, msg)
+	}
+
+	_, err = readConfigFile(filepath.Join(dir, "does-not-exist.yaml"))
+	assert.Error(err)
+}
+
+func TestFindConfigFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path, err := findConfigFile(dir)
+	assert.NoError(err)
+	assert.Equal("", path)
+
+	yamlPath := filepath.Join(dir, ".checkcommits.yaml")
+
+	err = ioutil.WriteFile(yamlPath, []byte(testYAMLConfig), 0600)
+	assert.NoError(err)
+
+	path, err = findConfigFile(dir)
+	assert.NoError(err)
+	assert.Equal(yamlPath, path)
+
+	err = ioutil.WriteFile(filepath.Join(dir, ".checkcommits.toml"), []byte(testTOMLConfig), 0600)
+	assert.NoError(err)
+
+	_, err = findConfigFile(dir)
+	assert.Error(err, "ambiguous config file")
+}
+
+// setTestEnv sets the specified environment variables, returning a function
+// which restores their original values.
+func setTestEnv(assert *assert.Assertions, env map[string]string) func() {
+	var restores []func()
+
+	for key, value := range env {
+		old, set := os.LookupEnv(key)
+
+		err := os.Setenv(key, value)
+		assert.NoError(err)
+
+		restores = append(restores, func(key, old string, set bool) func() {
+			return func() {
+				if set {
+					_ = os.Setenv(key, old)
+				} else {
+					_ = os.Unsetenv(key)
+				}
+			}
+		}(key, old, set))
+	}
+
+	return func() {
+		for _, restore := range restores {
+			restore()
+		}
+	}
+}
+
+func TestLoadConfigFileOutsideRepo(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	restore := setTestEnv(assert, map[string]string{
+		"GIT_DIR": filepath.Join(dir, "not-a-repo"),
+	})
+	defer restore()
+
+	_, err = loadConfigFile("", true)
+	assert.Error(err)
+
+	configFile, err := loadConfigFile("", false)
+	assert.NoError(err)
+	assert.Equal(&ConfigFile{}, configFile)
+
+	// An explicit config file does not need a repository
+	path := filepath.Join(dir, ".checkcommits.yaml")
+
+	err = ioutil.WriteFile(path, []byte(testYAMLConfig), 0600)
+	assert.NoError(err)
+
+	configFile, err = loadConfigFile(path, true)
+	assert.NoError(err)
+	assert.NotEmpty(configFile.Profiles)
+}
+
+func TestConfigFileSettings(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, ".checkcommits.yaml")
+
+	err = ioutil.WriteFile(path, []byte(testYAMLConfig), 0600)
+	assert.NoError(err)
+
+	config, err := readConfigFile(path)
+	assert.NoError(err)
+
+	type testData struct {
+		dstBranch            string
+		srcBranch            string
+		expectedNeedFixes    bool
+		expectedNeedSignOffs *bool
+		expectedSubjectLen   *uint
+	}
+
+	yes := true
+	subjectLength := uint(60)
+
+	data := []testData{
+		{"main", "", true, nil, nil},
+		{"main", "topic", true, nil, nil},
+		{"stable-2.0", "topic", true, &yes, &subjectLength},
+		{"main", "bot-update", false, nil, nil},
+		{"stable-2.0", "bot-update", false, &yes, &subjectLength},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		settings := config.settings(d.dstBranch, d.srcBranch)
+
+		assert.Equal(d.expectedNeedFixes, *settings.NeedFixes, msg)
+		assert.Equal(d.expectedNeedSignOffs, settings.NeedSignOffs, msg)
+		assert.Equal(d.expectedSubjectLen, settings.SubjectLength, msg)
+
+		// Relative to the config file
+		assert.Equal(filepath.Join(dir, "subsystems.txt"), *settings.AllowedSubsystems, msg)
+	}
+
+	// The global settings should not be modified by the profiles
+	assert.True(*config.NeedFixes)
+	assert.Nil(config.NeedSignOffs)
+}
+
+func TestNewCommitConfigIssueRepo(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	restore := setTestEnv(assert, map[string]string{
+		"GIT_DIR":           filepath.Join(dir, ".git"),
+		"GITHUB_REPOSITORY": "",
+	})
+	defer restore()
+
+	commands := [][]string{
+		{"init", "-q", dir},
+		{"remote", "add", "origin", "https://github.com/kata-containers/tests.git"},
+		{"remote", "add", "upstream", "git@github.com:kata-containers/runtime.git"},
+	}
+
+	for _, args := range commands {
+		_, err := runGit(args...)
+		assert.NoError(err)
+	}
+
+	type testData struct {
+		args         []string
+		expectedRepo string
+		expectFail   bool
+	}
+
+	data := []testData{
+		{[]string{"--verify-issues"}, "kata-containers/tests", false},
+		{[]string{"--verify-issues", "--remote", "upstream"}, "kata-containers/runtime", false},
+		{[]string{"--verify-issues", "--remote", "upstream", "--issues-repo", "foo/bar"}, "foo/bar", false},
+
+		// Unknown remotes are an error rather than being ignored
+		{[]string{"--verify-issues", "--remote", "missing"}, "", true},
+		{[]string{"--verify-issues", "--remote", "missing", "--issues-repo", "foo/bar"}, "", true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		c, err := newTestContext(d.args)
+		assert.NoError(err, msg)
+
+		config, err := newCommitConfigFromSettings(c, CheckSettings{})
+		if d.expectFail {
+			if assert.Error(err, msg) {
+				assert.True(strings.Contains(err.Error(), `unknown remote "missing"`), "%s: %v", msg, err)
+			}
+
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedRepo, config.IssueRepo, msg)
+	}
+}
+
+func TestNewCommitConfigFromSettings(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	allowList := filepath.Join(dir, "subsystems.txt")
+
+	err = ioutil.WriteFile(allowList, []byte("agent\n"), 0600)
+	assert.NoError(err)
+
+	yes := true
+	bodyLength := uint(100)
+	style := conventionalSubjectStyle
+	invalidStyle := "invalid"
+	missingFile := filepath.Join(dir, "missing")
+
+	settings := CheckSettings{
+		NeedFixes:         &yes,
+		BodyLength:        &bodyLength,
+		SubjectStyle:      &style,
+		AllowedSubsystems: &allowList,
+	}
+
+	// No command-line options so the settings apply
+	c, err := newTestContext([]string{})
+	assert.NoError(err)
+
+	config, err := newCommitConfigFromSettings(c, settings)
+	assert.NoError(err)
+	assert.True(config.NeedFixes)
+	assert.False(config.NeedSOBS)
+	assert.Equal(100, config.MaxBodyLineLength)
+	assert.Equal(defaultMaxSubjectLineLength, config.MaxSubjectLineLength)
+	assert.Equal(conventionalSubjectStyle, config.SubjectGrammar.Name())
+	assert.Equal([]string{"agent"}, config.AllowedSubsystems)
+
+	// Command-line options override the settings
+	c, err = newTestContext([]string{
+		"--need-sign-offs",
+		"--body-length", "80",
+		"--subject-style", kataSubjectStyle,
+		"--allowed-subsystems", "",
+	})
+	assert.NoError(err)
+
+	config, err = newCommitConfigFromSettings(c, settings)
+	assert.NoError(err)
+	assert.True(config.NeedFixes)
+	assert.True(config.NeedSOBS)
+	assert.Equal(80, config.MaxBodyLineLength)
+	assert.Equal(kataSubjectStyle, config.SubjectGrammar.Name())
+	assert.Empty(config.AllowedSubsystems)
+
+	// No settings
+	config, err = newCommitConfigFromSettings(c, CheckSettings{})
+	assert.NoError(err)
+	assert.False(config.NeedFixes)
+
+	_, err = newCommitConfigFromSettings(c, CheckSettings{SubjectStyle: &invalidStyle})
+	assert.NoError(err, "overridden by command-line")
+
+	c, err = newTestContext([]string{})
+	assert.NoError(err)
+
+	_, err = newCommitConfigFromSettings(c, CheckSettings{SubjectStyle: &invalidStyle})
+	assert.Error(err)
+
+	_, err = newCommitConfigFromSettings(c, CheckSettings{AllowedSubsystems: &missingFile})
+	assert.Error(err)
+
+	_, err = newCommitConfigFromSettings(c, CheckSettings{DCOIdentities: &missingFile})
+	assert.Error(err)
+
+	c, err = newTestContext([]string{"--strict-dco"})
+	assert.NoError(err)
+
+	config, err = newCommitConfigFromSettings(c, CheckSettings{})
+	assert.NoError(err)
+	assert.True(config.StrictDCO)
+	assert.Nil(config.Identities)
+
+	maxFileSize := uint(1024)
+
+	config, err = newCommitConfigFromSettings(c, CheckSettings{
+		MaxFileSize:  &maxFileSize,
+		BlockedPaths: []string{"*.exe"},
+		NoMerges:     &yes,
+	})
+	assert.NoError(err)
+	assert.Equal(ContentPolicy{
+		MaxFileSize:  1024,
+		BlockedPaths: []string{"*.exe"},
+		NoMerges:     true,
+	}, config.Policy)
+
+	_, err = newCommitConfigFromSettings(c, CheckSettings{BlockedPaths: []string{"["}})
+	assert.Error(err)
+
+	// The default markers apply unless overridden, and an empty list
+	// disables the check.
+	assert.Equal(defaultWIPMarkers, config.WIPMarkers)
+	assert.False(config.NoDuplicateSubjects)
+
+	config, err = newCommitConfigFromSettings(c, CheckSettings{
+		WIPMarkers:          []string{},
+		NoDuplicateSubjects: &yes,
+	})
+	assert.NoError(err)
+	assert.Empty(config.WIPMarkers)
+	assert.True(config.NoDuplicateSubjects)
+
+	c, err = newTestContext([]string{"--wip-markers", "hack"})
+	assert.NoError(err)
+
+	config, err = newCommitConfigFromSettings(c, CheckSettings{WIPMarkers: []string{}})
+	assert.NoError(err)
+	assert.Equal([]string{"hack"}, config.WIPMarkers)
+}
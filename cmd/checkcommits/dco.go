@@ -0,0 +1,314 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	coAuthoredByString = "Co-authored-by"
+	reviewedByString   = "Reviewed-by"
+
+	// Added by "git cherry-pick -x". Like a sign-off, git recognises
+	// this as a trailer generated by git itself.
+	cherryPickPrefix = "(cherry picked from commit "
+
+	// Prefix of a domain in an identity mapping file.
+	domainPrefix = "@"
+)
+
+// Matches a trailer line (a "token: value" line). Note that git allows
+// whitespace between the token and the separator.
+var trailerPattern = regexp.MustCompile(`^([[:alnum:]-]+)[[:blank:]]*:[[:blank:]]*(.*)$`)
+
+// Trailer is a "key: value" line at the end of a commit message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// Identity is the name and email address of a person.
+type Identity struct {
+	Name  string
+	Email string
+}
+
+func (i Identity) String() string {
+	return fmt.Sprintf("%s <%s>", i.Name, i.Email)
+}
+
+// parseIdentity parses an identity in "Name <email>" format in the same way
+// as git: the email address is in the last pair of angle brackets and
+// everything before it is the name, so the name can contain characters (such
+// as commas) that are not allowed unquoted in an RFC 5322 address.
+func parseIdentity(value string) (Identity, error) {
+	start := strings.LastIndex(value, "<")
+	if start < 0 {
+		return Identity{}, fmt.Errorf("identity %q does not specify an email address in angle brackets", value)
+	}
+
+	end := strings.Index(value[start:], ">")
+	if end < 0 {
+		return Identity{}, fmt.Errorf("invalid identity %q (expected %q): no closing %q", value, "Name <email>", ">")
+	}
+
+	end += start
+
+	if rest := value[end+1:]; strings.TrimSpace(rest) != "" {
+		return Identity{}, fmt.Errorf("invalid identity %q (expected %q): unexpected text after email address", value, "Name <email>")
+	}
+
+	// Like git, allow the name to be quoted.
+	name := strings.TrimSpace(value[:start])
+	if len(name) >= 2 && strings.HasPrefix(name, `"`) && strings.HasSuffix(name, `"`) {
+		name = strings.TrimSpace(name[1 : len(name)-1])
+	}
+
+	if name == "" {
+		return Identity{}, fmt.Errorf("identity %q does not specify a name", value)
+	}
+
+	email := strings.TrimSpace(value[start+1 : end])
+	if !strings.Contains(email, "@") || strings.ContainsAny(email, " \t") {
+		return Identity{}, fmt.Errorf("identity %q does not specify a valid email address", value)
+	}
+
+	return Identity{Name: name, Email: email}, nil
+}
+
+// isBlankLine returns true if the specified line only contains whitespace.
+func isBlankLine(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// parseTrailers returns the trailers in the specified commit body, using the
+// same rules as git-interpret-trailers(1): trailers must be in the last
+// paragraph of the body which must either only contain trailers (and their
+// continuation lines), or contain at least one trailer generated by git (such
+// as a sign-off) and at least 25% trailers.
+func parseTrailers(body []string, sobString string) []Trailer {
+	end := len(body)
+
+	for end > 0 && isBlankLine(body[end-1]) {
+		end--
+	}
+
+	start := end
+
+	for start > 0 && !isBlankLine(body[start-1]) {
+		start--
+	}
+
+	var trailers []Trailer
+
+	trailerLines := 0
+	nonTrailerLines := 0
+	recognised := false
+
+	// Set if the previous line was a trailer (or a continuation of one).
+	inTrailer := false
+
+	for _, line := range body[start:end] {
+		if inTrailer && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			last := &trailers[len(trailers)-1]
+			last.Value += " " + strings.TrimSpace(line)
+			continue
+		}
+
+		if strings.HasPrefix(line, cherryPickPrefix) {
+			trailerLines++
+			recognised = true
+			inTrailer = false
+			continue
+		}
+
+		matches := trailerPattern.FindStringSubmatch(line)
+		if matches == nil {
+			nonTrailerLines++
+			inTrailer = false
+			continue
+		}
+
+		trailerLines++
+		inTrailer = true
+
+		if strings.EqualFold(matches[1], sobString) {
+			recognised = true
+		}
+
+		trailers = append(trailers, Trailer{
+			Key:   matches[1],
+			Value: strings.TrimSpace(matches[2]),
+		})
+	}
+
+	if trailerLines == 0 {
+		return nil
+	}
+
+	if nonTrailerLines > 0 && !(recognised && trailerLines*3 >= nonTrailerLines) {
+		return nil
+	}
+
+	return trailers
+}
+
+// IdentityMap maps the email addresses a person uses to a single canonical
+// address.
+type IdentityMap struct {
+	// Key: alias email address (lower case)
+	// Value: canonical email address (lower case)
+	emails map[string]string
+
+	// Key: alias domain (lower case)
+	// Value: canonical domain (lower case)
+	domains map[string]string
+}
+
+// readIdentityMap reads an identity mapping file. Each line contains a
+// canonical email address followed by its aliases, separated by whitespace.
+// Alternatively, a line can contain a canonical domain followed by its
+// aliases, each starting with "@". Blank lines and comments are ignored.
+func readIdentityMap(file string) (*IdentityMap, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	m := &IdentityMap{
+		emails:  make(map[string]string),
+		domains: make(map[string]string),
+	}
+
+	scanner := bufio.NewScanner(f)
+
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+
+		if i := strings.Index(line, allowListComment); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) == 1 {
+			return nil, fmt.Errorf("%s:%d: no aliases for %q", file, lineNum, fields[0])
+		}
+
+		isDomain := strings.HasPrefix(fields[0], domainPrefix)
+
+		for _, field := range fields {
+			if strings.HasPrefix(field, domainPrefix) != isDomain || (!isDomain && !strings.Contains(field, "@")) {
+				return nil, fmt.Errorf("%s:%d: cannot mix email addresses and domains: %q", file, lineNum, line)
+			}
+		}
+
+		canonical := fields[0]
+
+		for _, alias := range fields[1:] {
+			if isDomain {
+				m.domains[strings.TrimPrefix(alias, domainPrefix)] = strings.TrimPrefix(canonical, domainPrefix)
+			} else {
+				m.emails[alias] = canonical
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// canonicalEmail returns the canonical form of the specified email address.
+func (m *IdentityMap) canonicalEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if m == nil {
+		return email
+	}
+
+	if i := strings.LastIndex(email, "@"); i >= 0 {
+		if domain, ok := m.domains[email[i+1:]]; ok {
+			email = email[:i+1] + domain
+		}
+	}
+
+	if canonical, ok := m.emails[email]; ok {
+		return canonical
+	}
+
+	return email
+}
+
+// sameIdentity returns true if the specified identities belong to the same
+// person. Identities are compared using their (canonical) email address
+// since names are commonly written in different ways.
+func (m *IdentityMap) sameIdentity(a, b Identity) bool {
+	return m.canonicalEmail(a.Email) == m.canonicalEmail(b.Email)
+}
+
+// checkCommitDCO performs strict Developer Certificate of Origin checks on
+// the commit: all identity trailers must be well-formed and the commit must
+// have been signed off by its author or committer.
+func checkCommitDCO(config *CommitConfig, commit *Commit) []error {
+	var errs []error
+
+	identityKeys := []string{config.SobString, coAuthoredByString, reviewedByString}
+
+	var signOffs []Identity
+
+	for _, trailer := range parseTrailers(commit.body, config.SobString) {
+		for _, key := range identityKeys {
+			if !strings.EqualFold(trailer.Key, key) {
+				continue
+			}
+
+			identity, err := parseIdentity(trailer.Value)
+			if err != nil {
+				errs = append(errs, commitErrorf(commit, "invalid %v: %v", key, err))
+				break
+			}
+
+			if key == config.SobString {
+				signOffs = append(signOffs, identity)
+			}
+		}
+	}
+
+	if len(signOffs) == 0 {
+		return append(errs, commitErrorf(commit, "no valid %v trailer found", config.SobString))
+	}
+
+	var candidates []Identity
+
+	for _, value := range []string{commit.author, commit.committer} {
+		if identity, err := parseIdentity(value); err == nil {
+			candidates = append(candidates, identity)
+		}
+	}
+
+	for _, signOff := range signOffs {
+		for _, candidate := range candidates {
+			if config.Identities.sameIdentity(signOff, candidate) {
+				return errs
+			}
+		}
+	}
+
+	return append(errs, commitErrorf(commit, "no %v matches the author (%v) or committer (%v)",
+		config.SobString, commit.author, commit.committer))
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIdentityMap = `
+# Contributors using several addresses
+jo@example.com jo@personal.example.org  Jo.Bloggs@Example.NET
+
+@example.com @corp.example.com # domain aliases
+`
+
+func TestParseTrailers(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		body             []string
+		expectedTrailers []Trailer
+	}
+
+	sob := Trailer{defaultSobString, "Jo Bloggs <jo@example.com>"}
+
+	data := []testData{
+		{nil, nil},
+		{[]string{""}, nil},
+		{[]string{"body"}, nil},
+
+		{[]string{"Signed-off-by: Jo Bloggs <jo@example.com>"}, []Trailer{sob}},
+		{[]string{"body", "", "Signed-off-by: Jo Bloggs <jo@example.com>", "", ""}, []Trailer{sob}},
+		{[]string{"body", "", "Signed-off-by :  Jo Bloggs <jo@example.com> "}, []Trailer{sob}},
+		{[]string{"body", "", "Fixes: #1", "Reviewed-by: A <a@b.c>"}, []Trailer{
+			{"Fixes", "#1"},
+			{"Reviewed-by", "A <a@b.c>"},
+		}},
+
+		// Continuation lines
+		{[]string{"body", "", "Co-authored-by: Jo", "  Bloggs <jo@example.com>"}, []Trailer{
+			{"Co-authored-by", "Jo Bloggs <jo@example.com>"},
+		}},
+
+		// Trailers must be in the last paragraph
+		{[]string{"Signed-off-by: Jo Bloggs <jo@example.com>", "", "body"}, nil},
+
+		// Only whitespace separates the token from the separator
+		{[]string{"body", "", "Signed off by: Jo Bloggs <jo@example.com>"}, nil},
+
+		// A paragraph containing other lines only contains trailers if
+		// it contains a git-generated trailer and is at least 25%
+		// trailers.
+		{[]string{"body", "", "some text", "Fixes: #1"}, nil},
+		{[]string{"body", "", "some text", sob.Key + ": " + sob.Value}, []Trailer{sob}},
+		{[]string{"body", "", "(cherry picked from commit abc)", "text", "Fixes: #1"}, []Trailer{
+			{"Fixes", "#1"},
+		}},
+		{[]string{"a", "b", "c", "d", sob.Key + ": " + sob.Value}, nil},
+		{[]string{"a", "b", "c", sob.Key + ": " + sob.Value}, []Trailer{sob}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		trailers := parseTrailers(d.body, defaultSobString)
+		assert.Equal(d.expectedTrailers, trailers, msg)
+	}
+}
+
+func TestParseIdentity(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		value            string
+		expectedIdentity Identity
+		expectFail       bool
+	}
+
+	data := []testData{
+		{"", Identity{}, true},
+		{"Jo Bloggs", Identity{}, true},
+		{"jo@example.com", Identity{}, true},
+		{"<jo@example.com>", Identity{}, true},
+		{"Jo Bloggs <jo>", Identity{}, true},
+		{"Jo Bloggs <jo@example.com", Identity{}, true},
+		{"Jo Bloggs <jo@example.com> extra", Identity{}, true},
+		{"Jo Bloggs <jo @example.com>", Identity{}, true},
+		{`"" <jo@example.com>`, Identity{}, true},
+
+		{"Jo Bloggs <jo@example.com>", Identity{"Jo Bloggs", "jo@example.com"}, false},
+		{`"Bloggs, Jo" <jo@example.com>`, Identity{"Bloggs, Jo", "jo@example.com"}, false},
+		{"Bloggs, Jo <jo@example.com>", Identity{"Bloggs, Jo", "jo@example.com"}, false},
+		{"Jo (JB) Bloggs Jr. <jo@example.com>", Identity{"Jo (JB) Bloggs Jr.", "jo@example.com"}, false},
+		{"Jo <Bloggs> <jo@example.com>", Identity{"Jo <Bloggs>", "jo@example.com"}, false},
+		{"  Jo Bloggs   <jo@example.com>  ", Identity{"Jo Bloggs", "jo@example.com"}, false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		identity, err := parseIdentity(d.value)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedIdentity, identity, msg)
+	}
+}
+
+func TestReadIdentityMap(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "identities")
+
+	err = ioutil.WriteFile(file, []byte(testIdentityMap), 0600)
+	assert.NoError(err)
+
+	m, err := readIdentityMap(file)
+	assert.NoError(err)
+
+	type testData struct {
+		email             string
+		expectedCanonical string
+	}
+
+	data := []testData{
+		{"other@example.com", "other@example.com"},
+		{"jo@example.com", "jo@example.com"},
+		{"JO@personal.example.org", "jo@example.com"},
+		{"jo.bloggs@example.net", "jo@example.com"},
+		{"someone@corp.example.com", "someone@example.com"},
+		{"jo@corp.example.com", "jo@example.com"},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		assert.Equal(d.expectedCanonical, m.canonicalEmail(d.email), msg)
+	}
+
+	// No mapping
+	var none *IdentityMap
+	assert.Equal("jo@example.com", none.canonicalEmail(" Jo@Example.com"))
+
+	for _, invalid := range []string{
+		"jo@example.com",
+		"jo@example.com @example.org",
+		"@example.com jo@example.org",
+		"jo@example.com jo",
+	} {
+		err = ioutil.WriteFile(file, []byte(invalid), 0600)
+		assert.NoError(err)
+
+		_, err = readIdentityMap(file)
+		assert.Error(err, invalid)
+	}
+
+	_, err = readIdentityMap(filepath.Join(dir, "does-not-exist"))
+	assert.Error(err)
+}
+
+func TestCheckCommitDCO(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "identities")
+
+	err = ioutil.WriteFile(file, []byte(testIdentityMap), 0600)
+	assert.NoError(err)
+
+	identities, err := readIdentityMap(file)
+	assert.NoError(err)
+
+	config := createCommitConfig()
+	config.StrictDCO = true
+	config.Identities = identities
+
+	const author = "Jo Bloggs <jo@example.com>"
+	const committer = "Maintainer <maintainer@example.org>"
+
+	type testData struct {
+		body             []string
+		expectedProblems []string
+	}
+
+	data := []testData{
+		{[]string{"body", "", "Signed-off-by: Jo Bloggs <jo@example.com>"}, nil},
+		{[]string{"body", "", "Signed-off-by: J. Bloggs <JO@example.com>"}, nil},
+		{[]string{"body", "", "Signed-off-by: Jo Bloggs <jo@personal.example.org>"}, nil},
+		{[]string{"body", "", "Signed-off-by: Jo Bloggs <jo@corp.example.com>"}, nil},
+		{[]string{"body", "", "Signed-off-by: Maintainer <maintainer@example.org>"}, nil},
+		{[]string{"body", "", "Signed-off-by: Someone <someone@example.com>", "Signed-off-by: Jo Bloggs <jo@example.com>"}, nil},
+		{[]string{"body", "", "Co-authored-by: A <a@example.com>", "Signed-off-by: Jo Bloggs <jo@example.com>"}, nil},
+
+		{[]string{"body"}, []string{"no valid Signed-off-by trailer found"}},
+		{[]string{"Signed-off-by: Jo Bloggs <jo@example.com>", "", "body"}, []string{"no valid Signed-off-by trailer found"}},
+		{[]string{"body", "", "Signed-off-by: jo@example.com"}, []string{
+			"invalid Signed-off-by",
+			"no valid Signed-off-by trailer found",
+		}},
+		{[]string{"body", "", "Signed-off-by: Someone <someone@example.com>"}, []string{
+			"no Signed-off-by matches the author",
+		}},
+		{[]string{"body", "", "Reviewed-by: someone", "Signed-off-by: Jo Bloggs <jo@example.com>"}, []string{
+			"invalid Reviewed-by",
+		}},
+		{[]string{"body", "", "co-authored-by: <a@example.com>", "Signed-off-by: Jo Bloggs <jo@example.com>"}, []string{
+			"invalid Co-authored-by",
+		}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		commit := &Commit{
+			hash:      "abc",
+			subject:   "foo: bar",
+			body:      d.body,
+			author:    author,
+			committer: committer,
+		}
+
+		errs := checkCommitDCO(config, commit)
+		assert.Len(errs, len(d.expectedProblems), msg)
+
+		for j, e := range errs {
+			if j >= len(d.expectedProblems) {
+				break
+			}
+
+			assert.True(strings.Contains(e.Error(), d.expectedProblems[j]), "%s: %v", msg, e)
+		}
+	}
+
+	// Strict checks are only performed when enabled
+	commit := &Commit{
+		hash:      "abc",
+		subject:   "foo: bar",
+		body:      []string{"body", "", "Signed-off-by: Someone <someone@example.com>"},
+		author:    author,
+		committer: committer,
+	}
+
+	assert.Error(checkCommit(config, commit))
+
+	config.StrictDCO = false
+	assert.NoError(checkCommit(config, commit))
+}
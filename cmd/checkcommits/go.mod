@@ -3,6 +3,8 @@ module github.com/kata-containers/tests/cmd/checkcommits
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/stretchr/testify v1.7.0
 	github.com/urfave/cli v1.22.5
+	gopkg.in/yaml.v2 v2.4.0
 )
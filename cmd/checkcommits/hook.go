@@ -0,0 +1,478 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	commitMsgHook = "commit-msg"
+	prePushHook   = "pre-push"
+
+	// Identifies the hooks created by this tool.
+	hookMarker = "Installed by checkcommits"
+
+	// Lines starting with this are removed from commit messages by
+	// git-commit(1), unless core.commentChar specifies another
+	// character.
+	defaultCommentChar = "#"
+
+	// Everything from the line containing the comment character followed
+	// by this onwards is removed from commit messages by "git commit
+	// --verbose".
+	scissors = " ------------------------ >8 ------------------------"
+)
+
+// commitMsgHookScript checks the message of each new commit. The argument
+// is the path to this tool.
+const commitMsgHookScript = `#!/bin/sh
+# ` + hookMarker + `: run "checkcommits hook install --force" to update.
+#
+# Checks the message of each new commit.
+
+exec %s check-message "$1"
+`
+
+// prePushHookScript checks the commits being pushed to each branch against
+// the default branch of the remote. The arguments are the path to this tool
+// and the branch to use if the default branch of the remote is not known.
+const prePushHookScript = `#!/bin/sh
+# ` + hookMarker + `: run "checkcommits hook install --force" to update.
+#
+# Checks the commits being pushed to each branch.
+
+checkcommits=%s
+remote="$1"
+
+branch=$(git symbolic-ref --quiet --short "refs/remotes/$remote/HEAD" 2>/dev/null)
+branch=${branch#"$remote/"}
+[ -n "$branch" ] || branch=%s
+
+while read -r local_ref local_sha remote_ref remote_sha
+do
+	case "$local_ref" in
+	refs/heads/*) ;;
+	*) continue ;;
+	esac
+
+	# Deleting a branch
+	case "$local_sha" in
+	*[!0]*) ;;
+	*) continue ;;
+	esac
+
+	"$checkcommits" --remote "$remote" "$local_sha" "$branch" </dev/null || exit 1
+done
+`
+
+// shellQuote quotes the specified string for use in a shell script.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// hookScripts returns the contents of the hooks, by name.
+func hookScripts(program, branch string) map[string]string {
+	return map[string]string{
+		commitMsgHook: fmt.Sprintf(commitMsgHookScript, shellQuote(program)),
+		prePushHook:   fmt.Sprintf(prePushHookScript, shellQuote(program), shellQuote(branch)),
+	}
+}
+
+// installHooks writes the hooks that run program into the specified
+// directory. Existing hooks that were not created by this tool are only
+// replaced if force is set.
+func installHooks(dir, program, branch string, force bool) error {
+	scripts := hookScripts(program, branch)
+
+	names := []string{commitMsgHook, prePushHook}
+
+	// Check all the hooks before writing any of them
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+
+		existing, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if !force && !bytes.Contains(existing, []byte(hookMarker)) {
+			return fmt.Errorf("hook %q already exists (use --force to replace it)", path)
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+
+		// Hooks must be executable
+		// #nosec
+		if err := ioutil.WriteFile(path, []byte(scripts[name]), 0755); err != nil {
+			return err
+		}
+
+		// Make sure an existing hook is executable
+		// #nosec
+		if err := os.Chmod(path, 0755); err != nil {
+			return err
+		}
+
+		if verbose {
+			fmt.Printf("Installed %v hook %v\n", name, path)
+		}
+	}
+
+	return nil
+}
+
+// getHooksDir returns the directory containing the hooks of the repository.
+func getHooksDir() (string, error) {
+	lines, err := runGit("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return "", errors.New("failed to determine hooks directory")
+	}
+
+	return lines[0], nil
+}
+
+func hookInstallAction(c *cli.Context) error {
+	if c.NArg() != 0 {
+		return errors.New("Too many arguments. Run with '--help' for usage")
+	}
+
+	program, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	dir, err := getHooksDir()
+	if err != nil {
+		return err
+	}
+
+	return installHooks(dir, program, c.String("branch"), c.Bool("force"))
+}
+
+// gitCommentChar returns the character git-commit(1) uses to start comment
+// lines in commit messages.
+func gitCommentChar() string {
+	lines, err := runGit("config", "core.commentChar")
+	if err != nil || len(lines) == 0 {
+		// Not set
+		return defaultCommentChar
+	}
+
+	// With "auto", git chooses a character that is not used in the
+	// message template, which cannot be determined here.
+	if char := lines[0]; char != "" && char != "auto" {
+		return char
+	}
+
+	return defaultCommentChar
+}
+
+// mergeInProgress returns true if a merge is being committed. git runs the
+// commit-msg hook for merge commits too.
+func mergeInProgress() bool {
+	_, err := runGit("rev-parse", "--quiet", "--verify", "MERGE_HEAD")
+	return err == nil
+}
+
+// parseCommitMessage returns the commit described by the specified commit
+// message, removing comments (lines starting with commentChar) and blank
+// lines in the same way as git-commit(1).
+func parseCommitMessage(message, commentChar string) (*Commit, error) {
+	var lines []string
+
+	for _, line := range strings.Split(message, "\n") {
+		if line == commentChar+scissors {
+			break
+		}
+
+		if strings.HasPrefix(line, commentChar) {
+			continue
+		}
+
+		line = strings.TrimRightFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '\r'
+		})
+
+		// Collapse consecutive blank lines
+		if line == "" && (len(lines) == 0 || lines[len(lines)-1] == "") {
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) == 0 {
+		return nil, errors.New("empty commit message")
+	}
+
+	// Like git-log(1), treat the first paragraph as the subject.
+	end := 0
+	for end < len(lines) && lines[end] != "" {
+		end++
+	}
+
+	body := []string{}
+
+	if end < len(lines) {
+		body = lines[end+1:]
+	}
+
+	return &Commit{
+		subject: strings.Join(lines[:end], " "),
+		body:    body,
+	}, nil
+}
+
+// gitIdentity returns the identity ("Name <email>") git will use for a new
+// commit. variable is either "GIT_AUTHOR_IDENT" or "GIT_COMMITTER_IDENT".
+// When running a hook, git sets the environment so that any author
+// specified for the commit (for example with "--author" or "--amend") is
+// used.
+func gitIdentity(variable string) (string, error) {
+	lines, err := runGit("var", variable)
+	if err != nil {
+		return "", err
+	}
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no %v from git", variable)
+	}
+
+	// Remove the timestamp and time zone
+	ident := lines[0]
+
+	i := strings.LastIndex(ident, ">")
+	if i < 0 {
+		return "", fmt.Errorf("invalid %v from git: %q", variable, ident)
+	}
+
+	return ident[:i+1], nil
+}
+
+// checkMessage returns all the problems found with the specified commit
+// message. Checks which apply to a range of commits (such as requiring a
+// "Fixes #XXX") are not performed.
+func checkMessage(config *CommitConfig, message, commentChar string) ([]error, error) {
+	commit, err := parseCommitMessage(message, commentChar)
+	if err != nil {
+		return nil, err
+	}
+
+	// The commit has not been created yet, so the sign-offs are
+	// compared with the identities git will use to create it.
+	if config.StrictDCO {
+		commit.author, err = gitIdentity("GIT_AUTHOR_IDENT")
+		if err != nil {
+			return nil, err
+		}
+
+		commit.committer, err = gitIdentity("GIT_COMMITTER_IDENT")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	problems := checkCommitErrors(config, commit)
+
+	if config.IssueTracker != nil {
+		issueErrs, err := verifyIssues(config, commit, newFixesRefsPattern(config.FixesString))
+		if err != nil {
+			return nil, err
+		}
+
+		problems = append(problems, issueErrs...)
+	}
+
+	return problems, nil
+}
+
+// displayMessageProblems displays the problems found in the commit message
+// read from the specified file.
+func displayMessageProblems(w io.Writer, file string, problems []error) error {
+	if _, err := fmt.Fprintf(w, "Commit message %v:\n", file); err != nil {
+		return err
+	}
+
+	for _, problem := range problems {
+		if _, err := fmt.Fprintf(w, "  - %s\n", problemMessage(problem)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// problemMessage returns the description of the problem, without the
+// (blank) commit hash.
+func problemMessage(problem error) string {
+	var commitErr *CommitError
+
+	if errors.As(problem, &commitErr) {
+		return commitErr.Message
+	}
+
+	return problem.Error()
+}
+
+func checkMessageAction(c *cli.Context) error {
+	if c.Bool("debug") {
+		verbose = true
+	}
+
+	if c.NArg() != 1 {
+		return errors.New("Need commit message file. Run with '--help' for usage")
+	}
+
+	// Merge commits are not checked, in the same way as merge commits
+	// in a range.
+	if mergeInProgress() {
+		return nil
+	}
+
+	file := c.Args().First()
+
+	message, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	configFile, err := loadConfigFile(c.String("config"), true)
+	if err != nil {
+		return err
+	}
+
+	// The current branch is the source branch of the commit.
+	srcBranch := ""
+
+	if lines, err := runGit("symbolic-ref", "--quiet", "--short", "HEAD"); err == nil && len(lines) > 0 {
+		srcBranch = lines[0]
+	}
+
+	config, err := newCommitConfigFromSettings(c, configFile.settings(c.String("branch"), srcBranch))
+	if err != nil {
+		return err
+	}
+
+	problems, err := checkMessage(config, string(message), gitCommentChar())
+	if err != nil {
+		return err
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if err := displayMessageProblems(os.Stderr, file, problems); err != nil {
+		return err
+	}
+
+	if len(problems) == 1 {
+		return errors.New(problemMessage(problems[0]))
+	}
+
+	return fmt.Errorf("found %d problems", len(problems))
+}
+
+// Options which only apply when checking a range of commits.
+var rangeOnlyFlags = map[string]bool{
+	"remote":                     true,
+	"mbox":                       true,
+	"need-fixes":                 true,
+	"ignore-fixes-for-subsystem": true,
+	"ignore-source-branch":       true,
+	"format":                     true,
+	"no-duplicate-subjects":      true,
+	"max-file-size":              true,
+	"blocked-paths":              true,
+	"separate-vendor":            true,
+	"no-merges":                  true,
+	"no-binary-files":            true,
+	"require-spdx":               true,
+}
+
+// checkMessageFlags returns the command-line options which apply to
+// checking a single commit message.
+func checkMessageFlags() []cli.Flag {
+	var flags []cli.Flag
+
+	for _, flag := range appFlags() {
+		// Remove any short name
+		name := strings.Split(flag.GetName(), ",")[0]
+
+		if !rangeOnlyFlags[name] {
+			flags = append(flags, flag)
+		}
+	}
+
+	return flags
+}
+
+// appCommands returns the commands supported in addition to checking a
+// range of commits.
+func appCommands() []cli.Command {
+	branchFlag := cli.StringFlag{
+		Name:  "branch",
+		Usage: "Destination `branch` used to select the config file profiles",
+		Value: defaultBranch,
+	}
+
+	return []cli.Command{
+		{
+			Name:      "check-message",
+			Usage:     "Check a commit message file (for example from a commit-msg hook)",
+			ArgsUsage: "<file>",
+			Flags:     append(checkMessageFlags(), branchFlag),
+			Action:    checkMessageAction,
+		},
+		{
+			Name:  "hook",
+			Usage: "Manage git hooks",
+			Subcommands: []cli.Command{
+				{
+					Name:  "install",
+					Usage: fmt.Sprintf("Install %s and %s hooks that run this tool", commitMsgHook, prePushHook),
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "force",
+							Usage: "Replace existing hooks",
+						},
+						cli.StringFlag{
+							Name:  "branch",
+							Usage: fmt.Sprintf("Destination `branch` checked by the %s hook if the default branch of the remote is not known", prePushHook),
+							Value: defaultBranch,
+						},
+					},
+					Action: hookInstallAction,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommitMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		message         string
+		expectedSubject string
+		expectedBody    []string
+		expectFail      bool
+	}
+
+	data := []testData{
+		{"", "", nil, true},
+		{"\n\n", "", nil, true},
+		{"# Please enter the commit message\n#\n", "", nil, true},
+
+		{"foo: bar", "foo: bar", []string{}, false},
+		{"foo: bar\n", "foo: bar", []string{}, false},
+		{"\n\nfoo: bar  \n\n\n", "foo: bar", []string{}, false},
+		{"foo: bar\nbaz\n", "foo: bar baz", []string{}, false},
+		{"foo: bar\n\nbody\n\n\nSigned-off-by: me\n", "foo: bar", []string{"body", "", "Signed-off-by: me"}, false},
+		{"foo: bar\n# comment\n\nbody\n# comment\n", "foo: bar", []string{"body"}, false},
+		{"foo: bar\n\nbody\n#" + scissors + "\ndiff --git a/foo b/foo\n", "foo: bar", []string{"body"}, false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		commit, err := parseCommitMessage(d.message, defaultCommentChar)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal("", commit.hash, msg)
+		assert.Equal(d.expectedSubject, commit.subject, msg)
+		assert.Equal(d.expectedBody, commit.body, msg)
+	}
+
+	// With core.commentChar set, lines starting with "#" are kept.
+	commit, err := parseCommitMessage("foo: bar\n; comment\n\n#1 body\n;"+scissors+"\ndiff\n", ";")
+	assert.NoError(err)
+	assert.Equal("foo: bar", commit.subject)
+	assert.Equal([]string{"#1 body"}, commit.body)
+}
+
+func TestCheckMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	config := createCommitConfig()
+
+	type testData struct {
+		message          string
+		expectedProblems []string
+		expectFail       bool
+	}
+
+	long := strings.Repeat("x", defaultMaxSubjectLineLength)
+
+	data := []testData{
+		{"", nil, true},
+
+		{"foo: bar\n\nbody\n\nSigned-off-by: me@foo.com\n", nil, false},
+
+		// A "Fixes #XXX" applies to the whole range of commits
+		{"foo: bar\n\nbody\n\nSigned-off-by: me@foo.com\n# Fixes #1\n", nil, false},
+
+		{"foo bar\n\nbody\n\nSigned-off-by: me@foo.com\n", []string{"Failed to find subsystem"}, false},
+		{"foo: " + long + "\n\nbody\n\nSigned-off-by: me@foo.com\n", []string{"subject too long"}, false},
+		{"foo: bar\n\nbody\n", []string{"no Signed-off-by specified"}, false},
+		{"foo " + long + "\n\nbody\n", []string{
+			"Failed to find subsystem",
+			"subject too long",
+			"no Signed-off-by specified",
+		}, false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		problems, err := checkMessage(config, d.message, defaultCommentChar)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Len(problems, len(d.expectedProblems), msg)
+
+		for j, problem := range problems {
+			if j >= len(d.expectedProblems) {
+				break
+			}
+
+			assert.True(strings.HasPrefix(problemMessage(problem), d.expectedProblems[j]), "%s: %v", msg, problem)
+		}
+	}
+
+	problems, err := checkMessage(config, "foo bar\n\nbody\n", defaultCommentChar)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+
+	err = displayMessageProblems(&buf, "COMMIT_EDITMSG", problems)
+	assert.NoError(err)
+
+	expected := "Commit message COMMIT_EDITMSG:\n" +
+		"  - Failed to find subsystem in subject: \"foo bar\"\n" +
+		"  - no Signed-off-by specified\n"
+
+	assert.Equal(expected, buf.String())
+}
+
+func TestCheckMessageStrictDCO(t *testing.T) {
+	assert := assert.New(t)
+
+	// git sets these when running the commit-msg hook
+	restore := setTestEnv(assert, map[string]string{
+		"GIT_AUTHOR_NAME":     "Jo Bloggs",
+		"GIT_AUTHOR_EMAIL":    "jo@example.com",
+		"GIT_COMMITTER_NAME":  "Maintainer",
+		"GIT_COMMITTER_EMAIL": "maintainer@example.org",
+	})
+	defer restore()
+
+	author, err := gitIdentity("GIT_AUTHOR_IDENT")
+	assert.NoError(err)
+	assert.Equal("Jo Bloggs <jo@example.com>", author)
+
+	config := createCommitConfig()
+	config.StrictDCO = true
+
+	type testData struct {
+		message          string
+		expectedProblems []string
+	}
+
+	data := []testData{
+		{"foo: bar\n\nbody\n\nSigned-off-by: Jo Bloggs <jo@example.com>\n", nil},
+		{"foo: bar\n\nbody\n\nSigned-off-by: Maintainer <maintainer@example.org>\n", nil},
+
+		{"foo: bar\n\nbody\n\nSigned-off-by: Someone <someone@example.com>\n", []string{
+			"no Signed-off-by matches the author (Jo Bloggs <jo@example.com>)",
+		}},
+		{"foo: bar\n\nbody\n\nSigned-off-by: jo@example.com\n", []string{
+			"invalid Signed-off-by",
+			"no valid Signed-off-by trailer found",
+		}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		problems, err := checkMessage(config, d.message, defaultCommentChar)
+		assert.NoError(err, msg)
+		assert.Len(problems, len(d.expectedProblems), msg)
+
+		for j, problem := range problems {
+			if j >= len(d.expectedProblems) {
+				break
+			}
+
+			assert.True(strings.HasPrefix(problemMessage(problem), d.expectedProblems[j]), "%s: %v", msg, problem)
+		}
+	}
+}
+
+func TestCheckMessageRepoState(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	gitDir := filepath.Join(dir, ".git")
+
+	restore := setTestEnv(assert, map[string]string{
+		"GIT_DIR":             gitDir,
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"HOME":                dir,
+	})
+	defer restore()
+
+	commands := [][]string{
+		{"init", "-q", dir},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	}
+
+	for _, args := range commands {
+		_, err := runGit(args...)
+		assert.NoError(err)
+	}
+
+	assert.Equal(defaultCommentChar, gitCommentChar())
+	assert.False(mergeInProgress())
+
+	for char, expected := range map[string]string{
+		"auto": defaultCommentChar,
+		";":    ";",
+	} {
+		_, err = runGit("config", "core.commentChar", char)
+		assert.NoError(err)
+
+		assert.Equal(expected, gitCommentChar(), char)
+	}
+
+	// Committing a merge
+	head, err := runGit("rev-parse", "HEAD")
+	assert.NoError(err)
+
+	err = ioutil.WriteFile(filepath.Join(gitDir, "MERGE_HEAD"), []byte(head[0]+"\n"), 0644)
+	assert.NoError(err)
+
+	assert.True(mergeInProgress())
+}
+
+func TestCheckMessageFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	names := make(map[string]bool)
+
+	for _, flag := range checkMessageFlags() {
+		names[flag.GetName()] = true
+	}
+
+	assert.True(names["config"])
+	assert.True(names["strict-dco"])
+	assert.True(names["need-sign-offs, s"])
+
+	for name := range rangeOnlyFlags {
+		assert.False(names[name], name)
+	}
+}
+
+func TestInstallHooks(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	hooksDir := filepath.Join(dir, "hooks")
+	program := "/path/to/it's/checkcommits"
+
+	err = installHooks(hooksDir, program, "main", false)
+	assert.NoError(err)
+
+	for _, name := range []string{commitMsgHook, prePushHook} {
+		path := filepath.Join(hooksDir, name)
+
+		st, err := os.Stat(path)
+		assert.NoError(err, name)
+		assert.True(st.Mode()&0100 != 0, "hook %v should be executable", name)
+
+		contents, err := ioutil.ReadFile(path)
+		assert.NoError(err, name)
+		assert.Contains(string(contents), hookMarker, name)
+		assert.Contains(string(contents), `'/path/to/it'\''s/checkcommits'`, name)
+	}
+
+	// Hooks created by this tool are replaced
+	err = installHooks(hooksDir, program, "master", false)
+	assert.NoError(err)
+
+	contents, err := ioutil.ReadFile(filepath.Join(hooksDir, prePushHook))
+	assert.NoError(err)
+	assert.Contains(string(contents), "branch='master'")
+
+	// Other hooks are not replaced unless forced
+	custom := []byte("#!/bin/sh\nexit 0\n")
+
+	err = ioutil.WriteFile(filepath.Join(hooksDir, commitMsgHook), custom, 0700)
+	assert.NoError(err)
+
+	err = installHooks(hooksDir, program, "main", false)
+	assert.Error(err)
+
+	contents, err = ioutil.ReadFile(filepath.Join(hooksDir, commitMsgHook))
+	assert.NoError(err)
+	assert.Equal(custom, contents)
+
+	err = installHooks(hooksDir, program, "main", true)
+	assert.NoError(err)
+
+	contents, err = ioutil.ReadFile(filepath.Join(hooksDir, commitMsgHook))
+	assert.NoError(err)
+	assert.Contains(string(contents), hookMarker)
+}
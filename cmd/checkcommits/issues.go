@@ -0,0 +1,282 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	// Time to wait for the issue tracker to respond.
+	issueTrackerTimeout = 30 * time.Second
+
+	// Matches an issue reference: an optional "org/repo" followed by
+	// "#" and the issue number.
+	issueRefRegex = `(?:[\w.-]+/[\w.-]+)?#\d+`
+)
+
+var (
+	errIssueNotFound = errors.New("issue not found")
+
+	issueRefPattern = regexp.MustCompile(`(?:([\w.-]+/[\w.-]+))?#(\d+)`)
+
+	// Matches the "org/repo" part of a GitHub remote URL, for example:
+	//
+	//   https://github.com/org/repo.git
+	//   git@github.com:org/repo.git
+	githubRemotePattern = regexp.MustCompile(`github\.com[:/]([\w.-]+/[\w.-]+?)(?:\.git)?/?$`)
+)
+
+// IssueRef is a reference to an issue in a commit message.
+type IssueRef struct {
+	// Repository in "org/repo" format.
+	Repo   string
+	Number int
+}
+
+func (r IssueRef) String() string {
+	return fmt.Sprintf("%s#%d", r.Repo, r.Number)
+}
+
+// Issue describes an issue (or pull request) in an issue tracker.
+type Issue struct {
+	Ref IssueRef
+
+	// Set if the issue is actually a pull request.
+	PullRequest bool
+
+	Closed bool
+}
+
+// IssueTracker provides details of issues.
+type IssueTracker interface {
+	// GetIssue returns the specified issue, or errIssueNotFound if the
+	// issue does not exist.
+	GetIssue(ref IssueRef) (Issue, error)
+}
+
+// githubIssue is the part of the GitHub REST API issue object used.
+type githubIssue struct {
+	State string `json:"state"`
+
+	// Only set for pull requests.
+	PullRequest *struct{} `json:"pull_request"`
+}
+
+// githubTracker uses the GitHub REST API to query issues.
+type githubTracker struct {
+	apiURL string
+	token  string
+	client *http.Client
+}
+
+// newGitHubTracker creates an issue tracker for the GitHub instance with
+// the specified API URL. If token is not blank, it is used to authenticate.
+func newGitHubTracker(apiURL, token string) *githubTracker {
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	return &githubTracker{
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		client: &http.Client{Timeout: issueTrackerTimeout},
+	}
+}
+
+func (g *githubTracker) GetIssue(ref IssueRef) (Issue, error) {
+	url := fmt.Sprintf("%s/repos/%s/issues/%d", g.apiURL, ref.Repo, ref.Number)
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return Issue{}, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	if g.token != "" {
+		req.Header.Set("Authorization", "Bearer "+g.token)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return Issue{}, err
+	}
+
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		// Deleted issues are reported as "gone"
+		return Issue{}, errIssueNotFound
+	default:
+		return Issue{}, fmt.Errorf("failed to query issue %v: %v", ref, resp.Status)
+	}
+
+	var details githubIssue
+
+	if err := json.NewDecoder(resp.Body).Decode(&details); err != nil {
+		return Issue{}, fmt.Errorf("invalid response for issue %v: %v", ref, err)
+	}
+
+	return Issue{
+		Ref:         ref,
+		PullRequest: details.PullRequest != nil,
+		Closed:      details.State == "closed",
+	}, nil
+}
+
+// cachedIssue records the result of querying an issue.
+type cachedIssue struct {
+	issue Issue
+	err   error
+}
+
+// cachingTracker is an issue tracker which only queries each issue once.
+type cachingTracker struct {
+	tracker IssueTracker
+
+	cache map[IssueRef]cachedIssue
+}
+
+func newCachingTracker(tracker IssueTracker) *cachingTracker {
+	return &cachingTracker{
+		tracker: tracker,
+		cache:   make(map[IssueRef]cachedIssue),
+	}
+}
+
+func (c *cachingTracker) GetIssue(ref IssueRef) (Issue, error) {
+	if result, ok := c.cache[ref]; ok {
+		return result.issue, result.err
+	}
+
+	issue, err := c.tracker.GetIssue(ref)
+
+	// Don't cache failures to query the tracker.
+	if err == nil || err == errIssueNotFound {
+		c.cache[ref] = cachedIssue{issue, err}
+	}
+
+	return issue, err
+}
+
+// newFixesRefsPattern returns a pattern matching the specified fixes prefix
+// followed by a list of issue references.
+func newFixesRefsPattern(fixesPrefix string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(?i:%s\s*:?\s*(%s(?:\s*(?:,|and)\s*%s)*))`,
+		regexp.QuoteMeta(fixesPrefix), issueRefRegex, issueRefRegex))
+}
+
+// findIssueRefs returns all the issues the specified line claims to fix.
+// References that do not specify a repository refer to defaultRepo.
+func findIssueRefs(pattern *regexp.Regexp, defaultRepo, line string) ([]IssueRef, error) {
+	var refs []IssueRef
+
+	for _, matches := range pattern.FindAllStringSubmatch(line, -1) {
+		// matches[1]: the list of references
+		for _, refMatches := range issueRefPattern.FindAllStringSubmatch(matches[1], -1) {
+			// refMatches[1]: repository (optional)
+			// refMatches[2]: issue number
+			repo := refMatches[1]
+			if repo == "" {
+				repo = defaultRepo
+			}
+
+			if repo == "" {
+				return nil, fmt.Errorf("cannot determine repository for issue %q", refMatches[0])
+			}
+
+			number, err := strconv.Atoi(refMatches[2])
+			if err != nil {
+				return nil, err
+			}
+
+			refs = append(refs, IssueRef{Repo: repo, Number: number})
+		}
+	}
+
+	return refs, nil
+}
+
+// verifyIssues checks that all the issues the commit claims to fix exist
+// in the issue tracker, are not pull requests and are still open. It returns
+// the problems found, or an error if the issue tracker could not be queried.
+func verifyIssues(config *CommitConfig, commit *Commit, pattern *regexp.Regexp) ([]error, error) {
+	var errs []error
+
+	lines := append([]string{commit.subject}, commit.body...)
+
+	for _, line := range lines {
+		refs, err := findIssueRefs(pattern, config.IssueRepo, line)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range refs {
+			issue, err := config.IssueTracker.GetIssue(ref)
+			if err == errIssueNotFound {
+				errs = append(errs, commitErrorf(commit, "%s references issue %v which does not exist", config.FixesString, ref))
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			if issue.PullRequest {
+				errs = append(errs, commitErrorf(commit, "%s references %v which is a pull request, not an issue", config.FixesString, ref))
+			} else if issue.Closed {
+				errs = append(errs, commitErrorf(commit, "%s references issue %v which is closed", config.FixesString, ref))
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// getIssueRepo returns the GitHub repository ("org/repo") of the specified
+// git remote URL.
+func getIssueRepo(remoteURL string) (string, error) {
+	matches := githubRemotePattern.FindStringSubmatch(remoteURL)
+	if matches == nil {
+		return "", fmt.Errorf("cannot determine GitHub repository from remote URL %q", remoteURL)
+	}
+
+	return matches[1], nil
+}
+
+// getRemoteURL returns the URL of the specified git remote.
+func getRemoteURL(remote string) (string, error) {
+	lines, err := runGit("remote", "get-url", remote)
+	if err != nil {
+		return "", fmt.Errorf("unknown remote %q: %v", remote, err)
+	}
+
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no URL for remote %q", remote)
+	}
+
+	return lines[0], nil
+}
+
+// getRemoteIssueRepo returns the GitHub repository of the specified git
+// remote.
+func getRemoteIssueRepo(remote string) (string, error) {
+	url, err := getRemoteURL(remote)
+	if err != nil {
+		return "", err
+	}
+
+	return getIssueRepo(url)
+}
@@ -0,0 +1,362 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testIssueRepo = "org/repo"
+
+// fakeTracker is an in-memory issue tracker which records the number of
+// times each issue is queried.
+type fakeTracker struct {
+	issues  map[IssueRef]Issue
+	queries map[IssueRef]int
+
+	// If set, returned for all queries.
+	err error
+}
+
+func newFakeTracker(issues ...Issue) *fakeTracker {
+	t := &fakeTracker{
+		issues:  make(map[IssueRef]Issue),
+		queries: make(map[IssueRef]int),
+	}
+
+	for _, issue := range issues {
+		t.issues[issue.Ref] = issue
+	}
+
+	return t
+}
+
+func (t *fakeTracker) GetIssue(ref IssueRef) (Issue, error) {
+	t.queries[ref]++
+
+	if t.err != nil {
+		return Issue{}, t.err
+	}
+
+	issue, ok := t.issues[ref]
+	if !ok {
+		return Issue{}, errIssueNotFound
+	}
+
+	return issue, nil
+}
+
+func testIssues() []Issue {
+	return []Issue{
+		{Ref: IssueRef{testIssueRepo, 1}},
+		{Ref: IssueRef{testIssueRepo, 2}, Closed: true},
+		{Ref: IssueRef{testIssueRepo, 3}, PullRequest: true},
+		{Ref: IssueRef{"other/repo", 4}},
+	}
+}
+
+func TestFindIssueRefs(t *testing.T) {
+	assert := assert.New(t)
+
+	pattern := newFixesRefsPattern(testFixesString)
+
+	type testData struct {
+		line         string
+		defaultRepo  string
+		expectedRefs []IssueRef
+		expectFail   bool
+	}
+
+	data := []testData{
+		{"", testIssueRepo, nil, false},
+		{"no issues", testIssueRepo, nil, false},
+		{"See #1", testIssueRepo, nil, false},
+		{"Fixes # 1", testIssueRepo, nil, false},
+
+		{"Fixes #1", testIssueRepo, []IssueRef{{testIssueRepo, 1}}, false},
+		{"fixes: #12", testIssueRepo, []IssueRef{{testIssueRepo, 12}}, false},
+		{"foo: Fixes #1", testIssueRepo, []IssueRef{{testIssueRepo, 1}}, false},
+		{"Fixes other/repo#3", testIssueRepo, []IssueRef{{"other/repo", 3}}, false},
+		{"Fixes other/repo#3", "", []IssueRef{{"other/repo", 3}}, false},
+		{"Fixes #1, #2 and other/repo#3", testIssueRepo, []IssueRef{
+			{testIssueRepo, 1},
+			{testIssueRepo, 2},
+			{"other/repo", 3},
+		}, false},
+		{"fixes #123, #234. Fixes: #3456.", testIssueRepo, []IssueRef{
+			{testIssueRepo, 123},
+			{testIssueRepo, 234},
+			{testIssueRepo, 3456},
+		}, false},
+
+		// Issues not following the prefix are not fixed
+		{"Fixes #1. See also #2", testIssueRepo, []IssueRef{{testIssueRepo, 1}}, false},
+
+		{"Fixes #1", "", nil, true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		refs, err := findIssueRefs(pattern, d.defaultRepo, d.line)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedRefs, refs, msg)
+	}
+}
+
+func TestVerifyIssues(t *testing.T) {
+	assert := assert.New(t)
+
+	config := createCommitConfig()
+	config.IssueTracker = newFakeTracker(testIssues()...)
+	config.IssueRepo = testIssueRepo
+
+	pattern := newFixesRefsPattern(config.FixesString)
+
+	type testData struct {
+		subject          string
+		body             []string
+		expectedProblems []string
+	}
+
+	data := []testData{
+		{"foo: bar", []string{"body"}, nil},
+		{"foo: bar", []string{"Fixes #1"}, nil},
+		{"foo: bar", []string{"Fixes other/repo#4"}, nil},
+		{"foo: bar fixes #1", []string{"body"}, nil},
+
+		{"foo: bar", []string{"Fixes #2"}, []string{"issue org/repo#2 which is closed"}},
+		{"foo: bar", []string{"Fixes #3"}, []string{"org/repo#3 which is a pull request, not an issue"}},
+		{"foo: bar", []string{"Fixes #99"}, []string{"issue org/repo#99 which does not exist"}},
+		{"foo: bar", []string{"Fixes other/repo#1"}, []string{"issue other/repo#1 which does not exist"}},
+		{"foo: fixes #2", []string{"Fixes #1, #3", "Fixes: #99"}, []string{
+			"issue org/repo#2 which is closed",
+			"org/repo#3 which is a pull request, not an issue",
+			"issue org/repo#99 which does not exist",
+		}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		commit := &Commit{
+			hash:    "abc",
+			subject: d.subject,
+			body:    d.body,
+		}
+
+		errs, err := verifyIssues(config, commit, pattern)
+		assert.NoError(err, msg)
+		assert.Len(errs, len(d.expectedProblems), msg)
+
+		for j, e := range errs {
+			if j >= len(d.expectedProblems) {
+				break
+			}
+
+			assert.True(strings.HasSuffix(e.Error(), d.expectedProblems[j]), "%s: %v", msg, e)
+		}
+	}
+
+	// Failure to query the tracker is not a problem with the commit
+	tracker := newFakeTracker()
+	tracker.err = errors.New("tracker unavailable")
+	config.IssueTracker = tracker
+
+	_, err := verifyIssues(config, &Commit{subject: "foo: bar", body: []string{"Fixes #1"}}, pattern)
+	assert.Error(err)
+}
+
+func TestCachingTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeTracker(testIssues()...)
+	tracker := newCachingTracker(fake)
+
+	found := IssueRef{testIssueRepo, 1}
+	missing := IssueRef{testIssueRepo, 99}
+
+	for i := 0; i < 3; i++ {
+		issue, err := tracker.GetIssue(found)
+		assert.NoError(err)
+		assert.Equal(found, issue.Ref)
+
+		_, err = tracker.GetIssue(missing)
+		assert.Equal(errIssueNotFound, err)
+	}
+
+	assert.Equal(1, fake.queries[found])
+	assert.Equal(1, fake.queries[missing])
+
+	// Errors should not be cached
+	fake.err = errors.New("tracker unavailable")
+	other := IssueRef{testIssueRepo, 2}
+
+	for i := 0; i < 2; i++ {
+		_, err := tracker.GetIssue(other)
+		assert.Error(err)
+	}
+
+	assert.Equal(2, fake.queries[other])
+}
+
+// newFakeGitHubServer creates a server which implements the GitHub REST API
+// issues endpoint for the specified issues.
+func newFakeGitHubServer(token string, issues ...Issue) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		for _, issue := range issues {
+			path := fmt.Sprintf("/repos/%s/issues/%d", issue.Ref.Repo, issue.Ref.Number)
+			if r.URL.Path != path {
+				continue
+			}
+
+			state := "open"
+			if issue.Closed {
+				state = "closed"
+			}
+
+			pr := ""
+			if issue.PullRequest {
+				pr = `, "pull_request": {"url": "https://example.com"}`
+			}
+
+			fmt.Fprintf(w, `{"number": %d, "state": %q%s}`, issue.Ref.Number, state, pr)
+			return
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+func TestGitHubTracker(t *testing.T) {
+	assert := assert.New(t)
+
+	const token = "secret"
+
+	server := newFakeGitHubServer(token, testIssues()...)
+	defer server.Close()
+
+	tracker := newGitHubTracker(server.URL+"/", token)
+
+	for _, expected := range testIssues() {
+		issue, err := tracker.GetIssue(expected.Ref)
+		assert.NoError(err)
+		assert.Equal(expected, issue)
+	}
+
+	_, err := tracker.GetIssue(IssueRef{testIssueRepo, 99})
+	assert.Equal(errIssueNotFound, err)
+
+	tracker = newGitHubTracker(server.URL, "wrong token")
+
+	_, err = tracker.GetIssue(IssueRef{testIssueRepo, 1})
+	assert.Error(err)
+	assert.NotEqual(errIssueNotFound, err)
+
+	assert.Equal(defaultGitHubAPIURL, newGitHubTracker("", "").apiURL)
+}
+
+func TestGetIssueRepo(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		url          string
+		expectedRepo string
+		expectFail   bool
+	}
+
+	data := []testData{
+		{"", "", true},
+		{"/some/local/path", "", true},
+		{"https://gitlab.com/org/repo.git", "", true},
+
+		{"https://github.com/org/repo", "org/repo", false},
+		{"https://github.com/org/repo/", "org/repo", false},
+		{"https://github.com/org/repo.git", "org/repo", false},
+		{"git@github.com:org/repo.git", "org/repo", false},
+		{"ssh://git@github.com/kata-containers/tests.git", "kata-containers/tests", false},
+		{"https://github.com/org/repo.name.git", "org/repo.name", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		repo, err := getIssueRepo(d.url)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedRepo, repo, msg)
+	}
+}
+
+func TestCheckCommitsDetailsVerifyIssues(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeTracker(testIssues()...)
+
+	config := createCommitConfig()
+	config.IssueTracker = newCachingTracker(fake)
+	config.IssueRepo = testIssueRepo
+
+	makeCommit := func(hash, fixes string) Commit {
+		return Commit{
+			hash:    hash,
+			subject: "foo: bar",
+			body:    []string{"body", fixes, "Signed-off-by: me@foo.com"},
+		}
+	}
+
+	commits := []Commit{
+		makeCommit("a", "Fixes #1"),
+		makeCommit("b", "Fixes #2"),
+		makeCommit("c", "Fixes #1"),
+	}
+
+	err := checkCommitsDetails(config, commits)
+	assert.Error(err)
+
+	violations, ok := err.(Violations)
+	assert.True(ok)
+	assert.Len(violations, 1)
+	assert.Equal("b", violations[0].Commit)
+
+	// Results should be cached
+	assert.Equal(1, fake.queries[IssueRef{testIssueRepo, 1}])
+
+	config.FoundFixes = false
+
+	err = checkCommitsDetails(config, commits[:1])
+	assert.NoError(err)
+
+	fake.err = errors.New("tracker unavailable")
+	config.IssueTracker = fake
+
+	err = checkCommitsDetails(config, commits[:1])
+	assert.Error(err)
+
+	_, ok = err.(Violations)
+	assert.False(ok)
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+const (
+	// Status of a file added by a commit (as displayed by git-diff(1)).
+	fileAdded   = "A"
+	fileDeleted = "D"
+
+	// Mode of a submodule in the output of git-diff-tree(1).
+	submoduleMode = "160000"
+
+	// Name of the directories containing vendored code.
+	vendorDir = "vendor"
+
+	// New source files must contain this in their first spdxHeaderLines
+	// lines.
+	spdxTag         = "SPDX-License-Identifier:"
+	spdxHeaderLines = 10
+)
+
+// Files which are normally changed along with the vendored code.
+var vendorManifests = map[string]bool{
+	"go.mod":     true,
+	"go.sum":     true,
+	"Gopkg.toml": true,
+	"Gopkg.lock": true,
+	"Cargo.toml": true,
+	"Cargo.lock": true,
+}
+
+// File extensions of the source files which require an SPDX licence header.
+var spdxSourceExtensions = map[string]bool{
+	".c":    true,
+	".go":   true,
+	".h":    true,
+	".py":   true,
+	".rs":   true,
+	".sh":   true,
+	".bash": true,
+}
+
+// FileChange describes a file changed by a commit.
+type FileChange struct {
+	Path string
+
+	// The git-diff(1) status letter ("A" for added, "M" for
+	// modified, ...).
+	Status string
+
+	// Hash of the new contents of the file (unset if deleted).
+	Blob string
+
+	// Size of the new contents of the file, in bytes.
+	Size int64
+
+	Binary bool
+
+	// The first lines of the file. Only set for new files if required
+	// by the content policy.
+	Header []string
+}
+
+// ContentPolicy describes the checks performed on the files changed by each
+// commit. All checks are disabled by default.
+type ContentPolicy struct {
+	// Maximum size in bytes of an added or modified file (0 for
+	// unlimited).
+	MaxFileSize uint64
+
+	// Glob patterns matching the paths commits must not change.
+	BlockedPaths []string
+
+	// If set, changes to vendored code must be in their own commit.
+	SeparateVendor bool
+
+	// If set, the range of commits must not contain merge commits.
+	NoMerges bool
+
+	// If set, commits must not add or modify binary files.
+	NoBinaryFiles bool
+
+	// If set, new source files must have an SPDX licence header.
+	RequireSPDX bool
+}
+
+// needsChanges returns true if the policy requires the files changed by
+// each commit.
+func (p *ContentPolicy) needsChanges() bool {
+	return p.MaxFileSize > 0 ||
+		len(p.BlockedPaths) > 0 ||
+		p.SeparateVendor ||
+		p.NoBinaryFiles ||
+		p.RequireSPDX
+}
+
+// needsHeader returns true if the policy requires the first lines of the
+// specified new file.
+func (p *ContentPolicy) needsHeader(file string) bool {
+	return p.RequireSPDX && isSPDXSource(file)
+}
+
+// isSPDXSource returns true if the file is a source file that requires an
+// SPDX licence header. Vendored code is excluded.
+func isSPDXSource(file string) bool {
+	return spdxSourceExtensions[path.Ext(file)] && !isVendorPath(file)
+}
+
+// isVendorPath returns true if the file is part of the vendored code.
+func isVendorPath(file string) bool {
+	for _, dir := range strings.Split(path.Dir(file), "/") {
+		if dir == vendorDir {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchPathGlob returns true if the file matches the glob pattern. A
+// pattern without a slash matches any file or directory with a matching
+// name, so "*.exe" matches "bin/tool.exe" and "build" matches everything
+// below any "build" directory. A pattern containing a slash matches the
+// path relative to the top-level directory of the repository, or any
+// directory at that path.
+func matchPathGlob(pattern, file string) bool {
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "/"), "/")
+
+	components := strings.Split(file, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, component := range components {
+			if matched, _ := path.Match(pattern, component); matched {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for i := range components {
+		prefix := strings.Join(components[:i+1], "/")
+
+		if matched, _ := path.Match(pattern, prefix); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate checks the glob patterns in the policy.
+func (p *ContentPolicy) validate() error {
+	for _, pattern := range p.BlockedPaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid blocked path pattern %q: %v", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// checkCommitContent returns all the problems found with the files changed
+// by the commit.
+func checkCommitContent(policy *ContentPolicy, commit *Commit) []error {
+	var errs []error
+
+	var vendorFile, otherFile string
+
+	for _, change := range commit.changes {
+		for _, pattern := range policy.BlockedPaths {
+			if matchPathGlob(pattern, change.Path) {
+				errs = append(errs, commitErrorf(commit, "changes blocked path %q (matches %q)", change.Path, pattern))
+				break
+			}
+		}
+
+		if isVendorPath(change.Path) {
+			vendorFile = change.Path
+		} else if !vendorManifests[path.Base(change.Path)] {
+			otherFile = change.Path
+		}
+
+		if change.Status == fileDeleted {
+			continue
+		}
+
+		if policy.NoBinaryFiles && change.Binary {
+			errs = append(errs, commitErrorf(commit, "binary file %q not permitted", change.Path))
+		}
+
+		if policy.MaxFileSize > 0 && uint64(change.Size) > policy.MaxFileSize {
+			errs = append(errs, commitErrorf(commit, "file %q too large (max %v bytes, got %v)",
+				change.Path, policy.MaxFileSize, change.Size))
+		}
+
+		if policy.RequireSPDX && change.Status == fileAdded && isSPDXSource(change.Path) && !hasSPDXHeader(change.Header) {
+			errs = append(errs, commitErrorf(commit, "new file %q has no %q licence header", change.Path, spdxTag))
+		}
+	}
+
+	if policy.SeparateVendor && vendorFile != "" && otherFile != "" {
+		errs = append(errs, commitErrorf(commit, "vendor changes (%q) must be in a separate commit to other changes (%q)",
+			vendorFile, otherFile))
+	}
+
+	return errs
+}
+
+// hasSPDXHeader returns true if the specified lines contain an SPDX licence
+// identifier.
+func hasSPDXHeader(lines []string) bool {
+	for i, line := range lines {
+		if i >= spdxHeaderLines {
+			break
+		}
+
+		if strings.Contains(line, spdxTag) {
+			return true
+		}
+	}
+
+	return false
+}
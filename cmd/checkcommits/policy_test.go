@@ -0,0 +1,272 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testBlob1 = "45b983be36b73c0788dc9cbcb76cbb80fc7bb057"
+	testBlob2 = "88768efdf77ec78c9a995f94881793be6a41752b"
+	testBlob3 = "65ef226608e282dd5451753dfa9dbb21f230a731"
+	nullHash  = "0000000000000000000000000000000000000000"
+)
+
+// Output of "git diff-tree --stdin --raw --numstat -z" for testHash1 (which
+// adds a text file, a binary file and a submodule) and testHash2 (which
+// modifies the text file and deletes the binary file).
+var testDiffTree = strings.Join([]string{
+	testHash1,
+	":000000 100644 " + nullHash + " " + testBlob1 + " A", "a.txt",
+	":000000 100644 " + nullHash + " " + testBlob2 + " A", "dir with space/b.bin",
+	":000000 160000 " + nullHash + " " + testHash3 + " A", "submodule",
+	"1\t0\ta.txt",
+	"-\t-\tdir with space/b.bin",
+	"1\t0\tsubmodule",
+	testHash2,
+	":100644 100644 " + testBlob1 + " " + testBlob3 + " M", "a.txt",
+	":100644 000000 " + testBlob2 + " " + nullHash + " D", "dir with space/b.bin",
+	"1\t0\ta.txt",
+	"-\t-\tdir with space/b.bin",
+}, gitLogRecordSeparator) + gitLogRecordSeparator
+
+// fakeChangeSource is a commit source which also provides the files changed
+// by each commit.
+type fakeChangeSource struct {
+	fakeCommitSource
+
+	changes map[string][]FileChange
+}
+
+func (s *fakeChangeSource) LoadChanges(commits []Commit, needHeader func(path string) bool) error {
+	for i := range commits {
+		commits[i].changes = s.changes[commits[i].hash]
+	}
+
+	return nil
+}
+
+func TestParseDiffTree(t *testing.T) {
+	assert := assert.New(t)
+
+	changes, err := parseDiffTree(testDiffTree)
+	assert.NoError(err)
+
+	expected := map[string][]FileChange{
+		testHash1: {
+			{Path: "a.txt", Status: fileAdded, Blob: testBlob1},
+			{Path: "dir with space/b.bin", Status: fileAdded, Blob: testBlob2, Binary: true},
+			{Path: "submodule", Status: fileAdded},
+		},
+		testHash2: {
+			{Path: "a.txt", Status: "M", Blob: testBlob3},
+			{Path: "dir with space/b.bin", Status: fileDeleted, Binary: true},
+		},
+	}
+
+	assert.Equal(expected, changes)
+
+	changes, err = parseDiffTree("")
+	assert.NoError(err)
+	assert.Empty(changes)
+
+	for _, invalid := range []string{
+		":000000 100644 " + nullHash + " " + testBlob1 + " A\x00a.txt\x00",
+		testHash1 + "\x00:000000 100644 A\x00a.txt\x00",
+		testHash1 + "\x00:000000 100644 " + nullHash + " " + testBlob1 + " A\x00",
+		"1\t0\ta.txt\x00",
+	} {
+		_, err = parseDiffTree(invalid)
+		assert.Error(err, invalid)
+	}
+}
+
+func TestMatchPathGlob(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		pattern       string
+		path          string
+		expectMatched bool
+	}
+
+	data := []testData{
+		{"*.exe", "tool.exe", true},
+		{"*.exe", "bin/tool.exe", true},
+		{"*.exe", "tool.exe.txt", false},
+		{"build", "build/out/a.o", true},
+		{"build", "src/build/a.o", true},
+		{"build", "builder/a.o", false},
+		{"build/", "build/a.o", true},
+
+		{"docs/*.png", "docs/a.png", true},
+		{"docs/*.png", "other/docs/a.png", false},
+		{"docs/*.png", "docs/images/a.png", false},
+		{"/docs/images", "docs/images/a.png", true},
+		{"docs/images/", "docs/images/big/a.png", true},
+		{"docs/images", "docs/image.png", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		assert.Equal(d.expectMatched, matchPathGlob(d.pattern, d.path), msg)
+	}
+
+	assert.NoError((&ContentPolicy{BlockedPaths: []string{"*.exe"}}).validate())
+	assert.Error((&ContentPolicy{BlockedPaths: []string{"["}}).validate())
+}
+
+func TestCheckCommitContent(t *testing.T) {
+	assert := assert.New(t)
+
+	const spdxHeader = "// SPDX-License-Identifier: Apache-2.0"
+
+	type testData struct {
+		policy           ContentPolicy
+		changes          []FileChange
+		expectedProblems []string
+	}
+
+	data := []testData{
+		// No policy
+		{ContentPolicy{}, []FileChange{
+			{Path: "vendor/a.go", Status: fileAdded, Size: 1000},
+			{Path: "b.exe", Status: fileAdded, Binary: true},
+		}, nil},
+
+		{ContentPolicy{MaxFileSize: 100}, []FileChange{
+			{Path: "a", Status: fileAdded, Size: 100},
+			{Path: "b", Status: "M", Size: 99},
+			{Path: "c", Status: fileDeleted},
+		}, nil},
+		{ContentPolicy{MaxFileSize: 100}, []FileChange{
+			{Path: "a", Status: fileAdded, Size: 101},
+			{Path: "b", Status: "M", Size: 200},
+		}, []string{`file "a" too large`, `file "b" too large`}},
+
+		{ContentPolicy{BlockedPaths: []string{"*.exe", "secrets"}}, []FileChange{
+			{Path: "a.go", Status: fileAdded},
+		}, nil},
+		{ContentPolicy{BlockedPaths: []string{"*.exe", "secrets"}}, []FileChange{
+			{Path: "bin/a.exe", Status: fileDeleted},
+			{Path: "config/secrets/key", Status: "M"},
+		}, []string{`changes blocked path "bin/a.exe"`, `changes blocked path "config/secrets/key"`}},
+
+		{ContentPolicy{NoBinaryFiles: true}, []FileChange{
+			{Path: "a.png", Status: fileDeleted, Binary: true},
+			{Path: "b.txt", Status: fileAdded},
+		}, nil},
+		{ContentPolicy{NoBinaryFiles: true}, []FileChange{
+			{Path: "a.png", Status: fileAdded, Binary: true},
+		}, []string{`binary file "a.png"`}},
+
+		{ContentPolicy{SeparateVendor: true}, []FileChange{
+			{Path: "vendor/github.com/foo/bar.go", Status: "M"},
+			{Path: "go.mod", Status: "M"},
+			{Path: "cmd/tool/go.sum", Status: "M"},
+		}, nil},
+		{ContentPolicy{SeparateVendor: true}, []FileChange{
+			{Path: "main.go", Status: "M"},
+		}, nil},
+		{ContentPolicy{SeparateVendor: true}, []FileChange{
+			{Path: "cmd/tool/vendor/github.com/foo/bar.go", Status: fileAdded},
+			{Path: "cmd/tool/main.go", Status: "M"},
+		}, []string{"vendor changes"}},
+
+		{ContentPolicy{RequireSPDX: true}, []FileChange{
+			{Path: "a.go", Status: fileAdded, Header: []string{"// Copyright", "//", spdxHeader}},
+			{Path: "b.go", Status: "M"},
+			{Path: "vendor/c.go", Status: fileAdded},
+			{Path: "README.md", Status: fileAdded},
+		}, nil},
+		{ContentPolicy{RequireSPDX: true}, []FileChange{
+			{Path: "a.go", Status: fileAdded, Header: []string{"package main"}},
+			{Path: "scripts/b.sh", Status: fileAdded},
+		}, []string{`new file "a.go"`, `new file "scripts/b.sh"`}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		commit := &Commit{
+			hash:    "abc",
+			subject: "foo: bar",
+			changes: d.changes,
+		}
+
+		errs := checkCommitContent(&d.policy, commit)
+		assert.Len(errs, len(d.expectedProblems), msg)
+
+		for j, e := range errs {
+			if j >= len(d.expectedProblems) {
+				break
+			}
+
+			assert.True(strings.Contains(e.Error(), d.expectedProblems[j]), "%s: %v", msg, e)
+		}
+	}
+
+	// The header is only required for new source files
+	policy := &ContentPolicy{RequireSPDX: true}
+	assert.True(policy.needsHeader("a.go"))
+	assert.False(policy.needsHeader("a.md"))
+	assert.False(policy.needsHeader("vendor/a.go"))
+	assert.False((&ContentPolicy{}).needsHeader("a.go"))
+}
+
+func TestPreChecksContentPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	commits, err := parseGitLog(testGitLog)
+	assert.NoError(err)
+
+	commits = commits[:1]
+
+	merge := commits[0]
+	merge.hash = testHash2
+	merge.parents = []string{testHash1, testHash3}
+	merge.subject = "Merge branch 'topic'"
+	merge.body = []string{}
+
+	source := &fakeChangeSource{
+		fakeCommitSource: fakeCommitSource{commits: append(commits, merge)},
+		changes: map[string][]FileChange{
+			testHash1: {{Path: "a.exe", Status: fileAdded}},
+		},
+	}
+
+	// Merge commits are ignored by default
+	config := createCommitConfig()
+	err = preChecks(config, source, defaultCommit, defaultBranch)
+	assert.NoError(err)
+
+	config = createCommitConfig()
+	config.Policy.NoMerges = true
+	config.Policy.BlockedPaths = []string{"*.exe"}
+
+	err = preChecks(config, source, defaultCommit, defaultBranch)
+	assert.Error(err)
+
+	violations, ok := err.(Violations)
+	assert.True(ok)
+	assert.Len(violations, 2)
+	assert.Equal(testHash1, violations[0].Commit)
+	assert.Equal(testHash2, violations[1].Commit)
+	assert.Equal("merge commits not permitted", violations[1].Message)
+
+	// The commit source must provide the changes
+	config = createCommitConfig()
+	config.Policy.NoBinaryFiles = true
+
+	err = preChecks(config, &source.fakeCommitSource, defaultCommit, defaultBranch)
+	assert.Error(err)
+}
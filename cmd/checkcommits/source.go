@@ -0,0 +1,511 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/mail"
+	"os"
+	"regexp"
+	"strings"
+)
+
+const (
+	// Separates the commits in the output of "git log -z". Commit
+	// messages cannot contain this character.
+	gitLogRecordSeparator = "\x00"
+
+	// Format of each commit in the output of "git log". The hashes,
+	// identities and subject line cannot contain newlines, so the
+	// body is simply everything after the subject line.
+	gitLogFormat = "%H%n%P%n%an <%ae>%n%cn <%ce>%n%s%n%b"
+
+	// Number of fields in gitLogFormat.
+	gitLogFields = 6
+
+	// The remote the destination branch is compared against.
+	defaultRemote = "origin"
+)
+
+var (
+	// Matches the first line of each message in an mbox created by
+	// git-format-patch(1), for example:
+	//
+	//   From 0123456789abcdef0123456789abcdef01234567 Mon Sep 17 00:00:00 2001
+	mboxFromPattern = regexp.MustCompile(`^From ([[:xdigit:]]{40}) `)
+
+	// Matches the prefix git-format-patch(1) adds to the subject line
+	// (for example "[PATCH 1/3] ").
+	patchPrefixPattern = regexp.MustCompile(`^\s*\[[^]]*\]\s*`)
+)
+
+// CommitSource provides the commits to check.
+type CommitSource interface {
+	// Commits returns the commits in commit that are not in branch,
+	// oldest first.
+	Commits(commit, branch string) ([]Commit, error)
+}
+
+// ChangeSource is implemented by commit sources which can describe the
+// files changed by each commit.
+type ChangeSource interface {
+	// LoadChanges sets the files changed by each of the specified
+	// commits. The first lines of each added file are only loaded if
+	// needHeader returns true for its path.
+	LoadChanges(commits []Commit, needHeader func(path string) bool) error
+}
+
+// gitCommitSource reads commits from the git repository in the current
+// directory.
+type gitCommitSource struct {
+	// The branch is compared with the branch of the same name in this
+	// remote.
+	remote string
+}
+
+func newGitCommitSource(remote string) *gitCommitSource {
+	if remote == "" {
+		remote = defaultRemote
+	}
+
+	return &gitCommitSource{
+		remote: remote,
+	}
+}
+
+// Commits runs a single "git log" for the whole range.
+func (s *gitCommitSource) Commits(commit, branch string) ([]Commit, error) {
+	if commit == "" {
+		return nil, errNoCommit
+	}
+
+	if branch == "" {
+		return nil, errNoBranch
+	}
+
+	output, err := runGitOutput("log",
+		"--reverse",
+		"-z",
+		fmt.Sprintf("--format=%s", gitLogFormat),
+		fmt.Sprintf("%s/%s..%s", s.remote, branch, commit),
+		"--")
+	if err != nil {
+		return nil, err
+	}
+
+	return parseGitLog(output)
+}
+
+// parseGitLog parses the output of "git log -z" using gitLogFormat.
+func parseGitLog(output string) ([]Commit, error) {
+	commits := []Commit{}
+
+	output = strings.TrimSuffix(output, gitLogRecordSeparator)
+	if output == "" {
+		return commits, nil
+	}
+
+	for _, record := range strings.Split(output, gitLogRecordSeparator) {
+		fields := strings.SplitN(record, "\n", gitLogFields)
+		if len(fields) != gitLogFields {
+			return nil, fmt.Errorf("invalid git log record: %q", record)
+		}
+
+		hash := fields[0]
+
+		if fields[4] == "" {
+			return nil, fmt.Errorf("Commit %v: empty subject", hash)
+		}
+
+		commits = append(commits, Commit{
+			hash:      hash,
+			parents:   strings.Fields(fields[1]),
+			author:    fields[2],
+			committer: fields[3],
+			subject:   fields[4],
+			body:      splitBody(fields[5]),
+		})
+	}
+
+	return commits, nil
+}
+
+// LoadChanges runs a single git-diff-tree(1) for all the commits, and
+// git-cat-file(1) to find the size and contents of the changed files.
+func (s *gitCommitSource) LoadChanges(commits []Commit, needHeader func(path string) bool) error {
+	if len(commits) == 0 {
+		return nil
+	}
+
+	var hashes []string
+
+	for _, commit := range commits {
+		hashes = append(hashes, commit.hash)
+	}
+
+	output, err := runGitWithInput(strings.Join(hashes, "\n")+"\n",
+		"diff-tree",
+		"--stdin",
+		"--root",
+		"-r",
+		"--no-renames",
+		"--raw",
+		"--numstat",
+		"--abbrev=40",
+		"-z")
+	if err != nil {
+		return err
+	}
+
+	changes, err := parseDiffTree(output)
+	if err != nil {
+		return err
+	}
+
+	var blobs []string
+	var headerBlobs []string
+
+	for _, commitChanges := range changes {
+		for _, change := range commitChanges {
+			if change.Blob == "" {
+				continue
+			}
+
+			blobs = append(blobs, change.Blob)
+
+			if change.Status == fileAdded && !change.Binary && needHeader != nil && needHeader(change.Path) {
+				headerBlobs = append(headerBlobs, change.Blob)
+			}
+		}
+	}
+
+	sizes, err := getBlobSizes(blobs)
+	if err != nil {
+		return err
+	}
+
+	headers, err := getBlobHeaders(headerBlobs)
+	if err != nil {
+		return err
+	}
+
+	for i := range commits {
+		commitChanges := changes[commits[i].hash]
+
+		for j := range commitChanges {
+			change := &commitChanges[j]
+
+			change.Size = sizes[change.Blob]
+			change.Header = headers[change.Blob]
+		}
+
+		commits[i].changes = commitChanges
+	}
+
+	return nil
+}
+
+// parseDiffTree parses the output of "git diff-tree --stdin --raw --numstat
+// -z" and returns the files changed, by commit hash.
+//
+// The output is a list of NUL-separated fields. Each commit hash is followed
+// by a pair of fields for each file changed (":<modes> <hashes> <status>"
+// and the path) and then a field for each file changed
+// ("<added>\t<deleted>\t<path>").
+func parseDiffTree(output string) (map[string][]FileChange, error) {
+	changes := make(map[string][]FileChange)
+
+	fields := strings.Split(strings.TrimSuffix(output, gitLogRecordSeparator), gitLogRecordSeparator)
+
+	hash := ""
+
+	// Index of each change in the current commit, by path.
+	index := make(map[string]int)
+
+	for i := 0; i < len(fields); i++ {
+		field := strings.TrimPrefix(fields[i], "\n")
+
+		switch {
+		case field == "":
+			continue
+
+		case strings.HasPrefix(field, ":"):
+			// :<old mode> <new mode> <old hash> <new hash> <status>
+			raw := strings.Fields(field)
+			if hash == "" || len(raw) != 5 || i+1 >= len(fields) {
+				return nil, fmt.Errorf("invalid git diff-tree output: %q", field)
+			}
+
+			i++
+			file := fields[i]
+
+			change := FileChange{
+				Path:   file,
+				Status: raw[4][:1],
+			}
+
+			// Submodules refer to a commit in another repository
+			if change.Status != fileDeleted && raw[1] != submoduleMode {
+				change.Blob = raw[3]
+			}
+
+			index[file] = len(changes[hash])
+			changes[hash] = append(changes[hash], change)
+
+		case strings.Contains(field, "\t"):
+			// <added>\t<deleted>\t<path>
+			numstat := strings.SplitN(field, "\t", 3)
+			if hash == "" || len(numstat) != 3 {
+				return nil, fmt.Errorf("invalid git diff-tree output: %q", field)
+			}
+
+			// Binary files are displayed as "-\t-\t<path>"
+			if j, ok := index[numstat[2]]; ok && numstat[0] == "-" {
+				changes[hash][j].Binary = true
+			}
+
+		default:
+			hash = field
+			index = make(map[string]int)
+		}
+	}
+
+	return changes, nil
+}
+
+// getBlobSizes returns the sizes of the specified blobs, by hash.
+func getBlobSizes(blobs []string) (map[string]int64, error) {
+	sizes := make(map[string]int64)
+
+	if len(blobs) == 0 {
+		return sizes, nil
+	}
+
+	output, err := runGitWithInput(strings.Join(blobs, "\n")+"\n", "cat-file", "--batch-check")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		// <hash> <type> <size>
+		var hash, objectType string
+		var size int64
+
+		if _, err := fmt.Sscanf(line, "%s %s %d", &hash, &objectType, &size); err != nil {
+			return nil, fmt.Errorf("invalid git cat-file output: %q", line)
+		}
+
+		sizes[hash] = size
+	}
+
+	return sizes, nil
+}
+
+// getBlobHeaders returns the first lines of the specified blobs, by hash.
+func getBlobHeaders(blobs []string) (map[string][]string, error) {
+	headers := make(map[string][]string)
+
+	if len(blobs) == 0 {
+		return headers, nil
+	}
+
+	output, err := runGitWithInput(strings.Join(blobs, "\n")+"\n", "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each blob is displayed as "<hash> <type> <size>\n<contents>\n"
+	for output != "" {
+		end := strings.Index(output, "\n")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid git cat-file output: %q", output)
+		}
+
+		var hash, objectType string
+		var size int
+
+		if _, err := fmt.Sscanf(output[:end], "%s %s %d", &hash, &objectType, &size); err != nil {
+			return nil, fmt.Errorf("invalid git cat-file output: %q", output[:end])
+		}
+
+		start := end + 1
+		if start+size > len(output) {
+			return nil, fmt.Errorf("truncated git cat-file output for %v", hash)
+		}
+
+		lines := strings.SplitN(output[start:start+size], "\n", spdxHeaderLines+1)
+		if len(lines) > spdxHeaderLines {
+			lines = lines[:spdxHeaderLines]
+		}
+
+		headers[hash] = lines
+
+		output = strings.TrimPrefix(output[start+size:], "\n")
+	}
+
+	return headers, nil
+}
+
+// splitBody returns the lines of the specified commit body.
+func splitBody(body string) []string {
+	lines := strings.Split(body, "\n")
+
+	// Remove last line if empty
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// mboxCommitSource reads commits from an mbox file created by
+// "git format-patch --stdout".
+type mboxCommitSource struct {
+	path string
+}
+
+func newMboxCommitSource(path string) *mboxCommitSource {
+	return &mboxCommitSource{
+		path: path,
+	}
+}
+
+// Commits returns all the commits in the mbox: the commit range is ignored
+// since the mbox only contains the commits to check. An mbox does not
+// record the committer.
+func (s *mboxCommitSource) Commits(commit, branch string) ([]Commit, error) {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	commits, err := readMbox(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mbox %q: %v", s.path, err)
+	}
+
+	return commits, nil
+}
+
+// readMbox returns the commits in the mbox, in the order they appear.
+func readMbox(r io.Reader) ([]Commit, error) {
+	var hashes []string
+	var messages []string
+	var message strings.Builder
+
+	scanner := bufio.NewScanner(r)
+
+	// Allow for long lines in patches
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if matches := mboxFromPattern.FindStringSubmatch(line); matches != nil {
+			if len(hashes) > 0 {
+				messages = append(messages, message.String())
+				message.Reset()
+			}
+
+			hashes = append(hashes, matches[1])
+			continue
+		}
+
+		if len(hashes) == 0 {
+			return nil, errors.New("no commits found")
+		}
+
+		message.WriteString(line)
+		message.WriteString("\n")
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(hashes) > 0 {
+		messages = append(messages, message.String())
+	}
+
+	commits := []Commit{}
+
+	for i, hash := range hashes {
+		commit, err := parsePatchMessage(hash, messages[i])
+		if err != nil {
+			return nil, err
+		}
+
+		commits = append(commits, commit)
+	}
+
+	return commits, nil
+}
+
+// parsePatchMessage returns the commit described by the specified email
+// created by git-format-patch(1).
+func parsePatchMessage(hash, message string) (Commit, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(message))
+	if err != nil {
+		return Commit{}, fmt.Errorf("Commit %v: %v", hash, err)
+	}
+
+	decoder := new(mime.WordDecoder)
+
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		return Commit{}, fmt.Errorf("Commit %v: invalid subject: %v", hash, err)
+	}
+
+	subject = patchPrefixPattern.ReplaceAllString(subject, "")
+	if subject == "" {
+		return Commit{}, fmt.Errorf("Commit %v: empty subject", hash)
+	}
+
+	author := ""
+
+	if address, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		author = Identity{Name: address.Name, Email: address.Address}.String()
+	}
+
+	bytes, err := ioutil.ReadAll(msg.Body)
+	if err != nil {
+		return Commit{}, fmt.Errorf("Commit %v: %v", hash, err)
+	}
+
+	var body []string
+
+	for _, line := range splitBody(string(bytes)) {
+		// The commit message is followed by the diffstat and the
+		// patch.
+		if line == "---" || strings.HasPrefix(line, "diff --git ") {
+			break
+		}
+
+		body = append(body, line)
+	}
+
+	// Remove the blank lines before the separator
+	for len(body) > 0 && isBlankLine(body[len(body)-1]) {
+		body = body[:len(body)-1]
+	}
+
+	if body == nil {
+		body = []string{}
+	}
+
+	return Commit{
+		hash:    hash,
+		subject: subject,
+		body:    body,
+		author:  author,
+	}, nil
+}
@@ -0,0 +1,217 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testHash1 = "0123456789abcdef0123456789abcdef01234567"
+	testHash2 = "89abcdef0123456789abcdef0123456789abcdef"
+	testHash3 = "fedcba9876543210fedcba9876543210fedcba98"
+)
+
+// Output of "git log -z" using gitLogFormat for two commits.
+var testGitLog = strings.Join([]string{
+	testHash1 + "\n" +
+		testHash3 + "\n" +
+		"Jo Bloggs <jo@example.com>\n" +
+		"Jo Bloggs <jo@example.com>\n" +
+		"foo: Add bar\n" +
+		"Body line 1\n" +
+		"\n" +
+		"Fixes #1\n" +
+		"\n" +
+		"Signed-off-by: Jo Bloggs <jo@example.com>\n",
+	testHash2 + "\n" +
+		testHash1 + "\n" +
+		"A N Other <another@example.com>\n" +
+		"Maintainer <maintainer@example.com>\n" +
+		"docs: Subject only\n",
+}, gitLogRecordSeparator) + gitLogRecordSeparator
+
+// Output of "git format-patch --stdout" for the same commits as testGitLog.
+const testMbox = `From 0123456789abcdef0123456789abcdef01234567 Mon Sep 17 00:00:00 2001
+From: Jo Bloggs <jo@example.com>
+Date: Mon, 1 Jan 2024 00:00:00 +0000
+Subject: [PATCH 1/2] foo: Add bar
+
+Body line 1
+
+Fixes #1
+
+Signed-off-by: Jo Bloggs <jo@example.com>
+---
+ foo.go | 1 +
+ 1 file changed, 1 insertion(+)
+
+diff --git a/foo.go b/foo.go
+--- a/foo.go
++++ b/foo.go
+@@ -1 +1,2 @@
+ package foo
++// bar
+--
+2.40.0
+
+
+From 89abcdef0123456789abcdef0123456789abcdef Mon Sep 17 00:00:00 2001
+From: =?UTF-8?q?A=20N=20Other?= <another@example.com>
+Date: Mon, 1 Jan 2024 00:00:00 +0000
+Subject: [PATCH 2/2] docs: Subject
+ only
+
+---
+ README.md | 1 +
+ 1 file changed, 1 insertion(+)
+`
+
+// fakeCommitSource returns the same commits for any range.
+type fakeCommitSource struct {
+	commits []Commit
+	err     error
+}
+
+func (s *fakeCommitSource) Commits(commit, branch string) ([]Commit, error) {
+	return s.commits, s.err
+}
+
+func TestParseGitLog(t *testing.T) {
+	assert := assert.New(t)
+
+	commits, err := parseGitLog(testGitLog)
+	assert.NoError(err)
+
+	expected := []Commit{
+		{
+			hash:      testHash1,
+			parents:   []string{testHash3},
+			subject:   "foo: Add bar",
+			body:      []string{"Body line 1", "", "Fixes #1", "", "Signed-off-by: Jo Bloggs <jo@example.com>"},
+			author:    "Jo Bloggs <jo@example.com>",
+			committer: "Jo Bloggs <jo@example.com>",
+		},
+		{
+			hash:      testHash2,
+			parents:   []string{testHash1},
+			subject:   "docs: Subject only",
+			body:      []string{},
+			author:    "A N Other <another@example.com>",
+			committer: "Maintainer <maintainer@example.com>",
+		},
+	}
+
+	assert.Equal(expected, commits)
+
+	commits, err = parseGitLog("")
+	assert.NoError(err)
+	assert.Empty(commits)
+	assert.NotNil(commits)
+
+	_, err = parseGitLog(testHash1 + "\n\nJo Bloggs <jo@example.com>\n" + gitLogRecordSeparator)
+	assert.Error(err)
+
+	_, err = parseGitLog(testHash1 + "\n\na <a@b.c>\na <a@b.c>\n\nbody\n" + gitLogRecordSeparator)
+	assert.Error(err, "empty subject")
+}
+
+func TestReadMbox(t *testing.T) {
+	assert := assert.New(t)
+
+	commits, err := readMbox(strings.NewReader(testMbox))
+	assert.NoError(err)
+
+	expected, err := parseGitLog(testGitLog)
+	assert.NoError(err)
+
+	// An mbox does not record the committer or parents
+	for i := range expected {
+		expected[i].committer = ""
+		expected[i].parents = nil
+	}
+
+	assert.Equal(expected, commits)
+
+	commits, err = readMbox(strings.NewReader(""))
+	assert.NoError(err)
+	assert.Empty(commits)
+
+	_, err = readMbox(strings.NewReader("not an mbox\n"))
+	assert.Error(err)
+
+	_, err = readMbox(strings.NewReader("From " + testHash1 + " Mon Sep 17 00:00:00 2001\nFrom: a <a@b.c>\n\nbody\n"))
+	assert.Error(err, "no subject")
+}
+
+func TestMboxCommitSource(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "patches.mbox")
+
+	err = ioutil.WriteFile(path, []byte(testMbox), 0600)
+	assert.NoError(err)
+
+	commits, err := newMboxCommitSource(path).Commits(defaultCommit, defaultBranch)
+	assert.NoError(err)
+	assert.Len(commits, 2)
+
+	_, err = newMboxCommitSource(filepath.Join(dir, "does-not-exist")).Commits(defaultCommit, defaultBranch)
+	assert.Error(err)
+}
+
+func TestPreChecks(t *testing.T) {
+	assert := assert.New(t)
+
+	commits, err := parseGitLog(testGitLog)
+	assert.NoError(err)
+
+	type testData struct {
+		source     CommitSource
+		commit     string
+		branch     string
+		expectFail bool
+	}
+
+	data := []testData{
+		{nil, defaultCommit, defaultBranch, true},
+		{&fakeCommitSource{}, "", defaultBranch, true},
+		{&fakeCommitSource{}, defaultCommit, "", true},
+		{&fakeCommitSource{err: errors.New("failed")}, defaultCommit, defaultBranch, true},
+
+		// No commits in range
+		{&fakeCommitSource{commits: []Commit{}}, defaultCommit, defaultBranch, false},
+
+		{&fakeCommitSource{commits: commits[:1]}, defaultCommit, defaultBranch, false},
+
+		// The second commit has no body
+		{&fakeCommitSource{commits: commits}, defaultCommit, defaultBranch, true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		err := preChecks(createCommitConfig(), d.source, d.commit, d.branch)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+	}
+}
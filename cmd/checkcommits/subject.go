@@ -0,0 +1,248 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// The "subsystem: summary" subject format used by Kata Containers.
+	kataSubjectStyle = "kata"
+
+	// The "type(scope)!: summary" subject format defined by
+	// https://www.conventionalcommits.org.
+	conventionalSubjectStyle = "conventional"
+
+	defaultSubjectStyle = kataSubjectStyle
+
+	// Separates multiple subsystems (or scopes) in a subject.
+	subsystemSeparator = ","
+
+	// Introduces a comment in an allow-list file.
+	allowListComment = "#"
+)
+
+var (
+	kataSubjectPattern = regexp.MustCompile(`^[[:blank:]]*([^:[:blank:]]*)[[:blank:]]*:`)
+
+	// matches[1]: type
+	// matches[2]: scope (without parentheses)
+	// matches[3]: breaking change marker
+	// matches[4]: description
+	conventionalSubjectPattern = regexp.MustCompile(`^([[:alpha:]]+)(?:\(([^()[:blank:]]+)\))?(!?): (.*)$`)
+
+	// The commit types recommended by the Conventional Commits
+	// specification.
+	defaultConventionalTypes = []string{
+		"build",
+		"chore",
+		"ci",
+		"docs",
+		"feat",
+		"fix",
+		"perf",
+		"refactor",
+		"revert",
+		"style",
+		"test",
+	}
+)
+
+// SubjectGrammar describes the format of a commit subject line.
+type SubjectGrammar interface {
+	// Name returns the name of the subject style.
+	Name() string
+
+	// Parse checks the specified subject against the grammar, returning
+	// the subsystem (or equivalent) the change applies to, which may be
+	// blank if the grammar allows it.
+	Parse(subject string) (subsystem string, err error)
+
+	// RequiresSubsystem returns true if the subsystem is a mandatory
+	// part of the subject, so a blank subsystem is not in any allow list.
+	RequiresSubsystem() bool
+}
+
+// kataSubjectGrammar handles subjects of the form "subsystem: summary".
+type kataSubjectGrammar struct{}
+
+func (kataSubjectGrammar) Name() string {
+	return kataSubjectStyle
+}
+
+func (kataSubjectGrammar) RequiresSubsystem() bool {
+	return true
+}
+
+func (kataSubjectGrammar) Parse(subject string) (string, error) {
+	matches := kataSubjectPattern.FindStringSubmatch(subject)
+
+	if matches == nil || len(matches) != 2 {
+		return "", fmt.Errorf("Failed to find subsystem in subject: %q", subject)
+	}
+
+	// matches[0]: the entire matching string
+	// matches[1] the subsystem name (without the colon)
+	return matches[1], nil
+}
+
+// conventionalSubjectGrammar handles Conventional Commits subjects of the
+// form "type(scope)!: summary" where the scope and breaking change marker
+// are optional.
+type conventionalSubjectGrammar struct {
+	// Key: type name
+	types map[string]bool
+}
+
+func newConventionalSubjectGrammar(types []string) conventionalSubjectGrammar {
+	g := conventionalSubjectGrammar{
+		types: make(map[string]bool),
+	}
+
+	for _, t := range types {
+		g.types[t] = true
+	}
+
+	return g
+}
+
+func (conventionalSubjectGrammar) Name() string {
+	return conventionalSubjectStyle
+}
+
+// RequiresSubsystem returns false since the scope is optional.
+func (conventionalSubjectGrammar) RequiresSubsystem() bool {
+	return false
+}
+
+// typeNames returns the sorted list of valid types.
+func (g conventionalSubjectGrammar) typeNames() []string {
+	var names []string
+
+	for name := range g.types {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Parse returns the scope (which is optional).
+func (g conventionalSubjectGrammar) Parse(subject string) (string, error) {
+	matches := conventionalSubjectPattern.FindStringSubmatch(subject)
+	if matches == nil {
+		return "", fmt.Errorf("Subject not of the form %q: %q", "type(scope)!: description", subject)
+	}
+
+	commitType := matches[1]
+	scope := matches[2]
+	description := matches[4]
+
+	if !g.types[commitType] {
+		return "", fmt.Errorf("Invalid type %q in subject (expected one of %s): %q",
+			commitType, strings.Join(g.typeNames(), ", "), subject)
+	}
+
+	if strings.TrimSpace(description) == "" {
+		return "", fmt.Errorf("No description in subject: %q", subject)
+	}
+
+	return scope, nil
+}
+
+// newSubjectGrammar returns the grammar for the specified subject style.
+func newSubjectGrammar(style string) (SubjectGrammar, error) {
+	switch style {
+	case kataSubjectStyle:
+		return kataSubjectGrammar{}, nil
+	case conventionalSubjectStyle:
+		return newConventionalSubjectGrammar(defaultConventionalTypes), nil
+	}
+
+	return nil, fmt.Errorf("invalid subject style %q (expected %q or %q)",
+		style, kataSubjectStyle, conventionalSubjectStyle)
+}
+
+// readAllowList reads the specified file which contains one subsystem (or
+// glob pattern matching subsystems) per line. Blank lines and comments are
+// ignored.
+func readAllowList(file string) ([]string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var patterns []string
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if i := strings.Index(line, allowListComment); i >= 0 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if _, err := path.Match(line, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q in allow list %q: %v", line, file, err)
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(patterns) == 0 {
+		return nil, fmt.Errorf("allow list %q is empty", file)
+	}
+
+	return patterns, nil
+}
+
+// checkSubsystemAllowed returns an error if any of the subsystems (or
+// scopes) in the specified subsystem is not in the allow list. If the allow
+// list or the subsystem is empty, no check is performed.
+func checkSubsystemAllowed(allowed []string, subsystem string) error {
+	if len(allowed) == 0 || subsystem == "" {
+		return nil
+	}
+
+	for _, name := range strings.Split(subsystem, subsystemSeparator) {
+		name = strings.TrimSpace(name)
+
+		found := false
+
+		for _, pattern := range allowed {
+			// Patterns have already been validated
+			if matched, _ := path.Match(pattern, name); matched {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return fmt.Errorf("subsystem %q not in allow list", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSubjectGrammar(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, style := range []string{kataSubjectStyle, conventionalSubjectStyle} {
+		grammar, err := newSubjectGrammar(style)
+		assert.NoError(err)
+		assert.Equal(style, grammar.Name())
+	}
+
+	_, err := newSubjectGrammar("")
+	assert.Error(err)
+
+	_, err = newSubjectGrammar("foo")
+	assert.Error(err)
+}
+
+func TestConventionalSubjectGrammar(t *testing.T) {
+	assert := assert.New(t)
+
+	grammar, err := newSubjectGrammar(conventionalSubjectStyle)
+	assert.NoError(err)
+
+	type testData struct {
+		subject       string
+		expectedScope string
+		expectFail    bool
+	}
+
+	data := []testData{
+		{"", "", true},
+		{"foo", "", true},
+		{"subsystem: A subject", "", true},
+		{"feat", "", true},
+		{"feat:", "", true},
+		{"feat: ", "", true},
+		{"feat:  ", "", true},
+		{"feat:no space", "", true},
+		{"feat (agent): space before scope", "", true},
+		{"feat(): empty scope", "", true},
+		{"feat(agent: unterminated scope", "", true},
+		{"feat(a b): space in scope", "", true},
+		{"feat!!: double breaking marker", "", true},
+		{"feat(agent) !: space before breaking marker", "", true},
+		{"feature: invalid type", "", true},
+		{"Feat: invalid type", "", true},
+
+		{"feat: add a feature", "", false},
+		{"fix: fix a bug", "", false},
+		{"feat!: breaking change", "", false},
+		{"feat(agent): add a feature", "agent", false},
+		{"feat(agent)!: breaking change", "agent", false},
+		{"fix(runtime,agent): multiple scopes", "runtime,agent", false},
+		{"docs(virtcontainers/pkg): nested scope", "virtcontainers/pkg", false},
+		{"chore(release): 1.2.3", "release", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		scope, err := grammar.Parse(d.subject)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedScope, scope, msg)
+	}
+}
+
+func TestReadAllowList(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "allowed")
+
+	_, err = readAllowList(file)
+	assert.Error(err, "file does not exist")
+
+	type testData struct {
+		contents         string
+		expectedPatterns []string
+		expectFail       bool
+	}
+
+	data := []testData{
+		{"", nil, true},
+		{"\n\n", nil, true},
+		{"# just a comment\n", nil, true},
+		{"[invalid\n", nil, true},
+
+		{"agent\n", []string{"agent"}, false},
+		{"agent\nruntime", []string{"agent", "runtime"}, false},
+		{"# components\n\n  agent  \nruntime # comment\nvirtcontainers/*\n", []string{"agent", "runtime", "virtcontainers/*"}, false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		err := ioutil.WriteFile(file, []byte(d.contents), 0600)
+		assert.NoError(err, msg)
+
+		patterns, err := readAllowList(file)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedPatterns, patterns, msg)
+	}
+}
+
+func TestCheckSubsystemAllowed(t *testing.T) {
+	assert := assert.New(t)
+
+	allowed := []string{"agent", "runtime", "virtcontainers/*"}
+
+	type testData struct {
+		allowed    []string
+		subsystem  string
+		expectFail bool
+	}
+
+	data := []testData{
+		{nil, "", false},
+		{nil, "anything", false},
+		{allowed, "", false},
+
+		{allowed, "agent", false},
+		{allowed, "runtime", false},
+		{allowed, "agent,runtime", false},
+		{allowed, "agent, runtime", false},
+		{allowed, "virtcontainers/pkg", false},
+
+		{allowed, "foo", true},
+		{allowed, "agents", true},
+		{allowed, "agent,foo", true},
+		{allowed, "virtcontainers", true},
+		{allowed, "virtcontainers/pkg/foo", true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		err := checkSubsystemAllowed(d.allowed, d.subsystem)
+		if d.expectFail {
+			assert.Error(err, msg)
+		} else {
+			assert.NoError(err, msg)
+		}
+	}
+}
+
+func TestCheckCommitSubjectGrammar(t *testing.T) {
+	assert := assert.New(t)
+
+	kataConfig := createCommitConfig()
+	kataConfig.AllowedSubsystems = []string{"agent", "release"}
+
+	conventionalConfig := createCommitConfig()
+	conventionalConfig.SubjectGrammar = newConventionalSubjectGrammar(defaultConventionalTypes)
+	conventionalConfig.AllowedSubsystems = []string{"agent", "release"}
+
+	type testData struct {
+		config            *CommitConfig
+		subject           string
+		expectedSubsystem string
+		expectFail        bool
+		expectFixes       bool
+	}
+
+	data := []testData{
+		{kataConfig, "agent: a subject", "agent", false, false},
+		{kataConfig, "agent: fixes #1", "agent", false, true},
+		{kataConfig, `Revert "agent: a subject"`, "agent", false, false},
+		{kataConfig, "runtime: a subject", "", true, false},
+		{kataConfig, "feat(agent): a subject", "", true, false},
+		{kataConfig, ": a subject", "", true, false},
+		{kataConfig, " : a subject", "", true, false},
+
+		{conventionalConfig, "feat: a subject", "", false, false},
+		{conventionalConfig, "feat(agent)!: a subject", "agent", false, false},
+		{conventionalConfig, "fix(agent): fixes #1", "agent", false, true},
+		{conventionalConfig, `Revert "fix(agent): a subject"`, "agent", false, false},
+		{conventionalConfig, "fix(runtime): a subject", "", true, false},
+		{conventionalConfig, "agent: a subject", "", true, false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		d.config.FoundFixes = false
+
+		commit := &Commit{
+			subject: d.subject,
+		}
+
+		err := checkCommitSubject(d.config, commit)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedSubsystem, commit.subsystem, msg)
+		assert.Equal(d.expectFixes, d.config.FoundFixes, msg)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	textFormat   = "text"
+	jsonFormat   = "json"
+	githubFormat = "github"
+
+	defaultFormat = textFormat
+)
+
+// CommitError describes a problem with a particular commit.
+type CommitError struct {
+	Hash    string
+	Message string
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("Commit %v: %s", e.Hash, e.Message)
+}
+
+// commitErrorf creates an error describing a problem with the specified
+// commit.
+func commitErrorf(commit *Commit, format string, args ...interface{}) error {
+	return &CommitError{
+		Hash:    commit.hash,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// firstError returns the first error in the list, or nil if the list is
+// empty.
+func firstError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs[0]
+}
+
+// Violation describes a single problem found by the checks.
+type Violation struct {
+	// Commit the problem was found in. Unset if the problem applies to
+	// the whole range of commits (such as no commit specifying a
+	// "Fixes #XXX").
+	Commit  string
+	Subject string
+
+	Message string
+}
+
+// Violations is the list of all problems found in a range of commits,
+// in the order they were found.
+type Violations []Violation
+
+// newViolation creates a violation for the specified commit from the
+// specified error.
+func newViolation(commit *Commit, err error) Violation {
+	v := Violation{
+		Commit:  commit.hash,
+		Subject: commit.subject,
+		Message: err.Error(),
+	}
+
+	var commitErr *CommitError
+
+	if errors.As(err, &commitErr) {
+		v.Message = commitErr.Message
+	}
+
+	return v
+}
+
+func (v Violations) Error() string {
+	if len(v) == 1 {
+		return v[0].String()
+	}
+
+	return fmt.Sprintf("found %d problems", len(v))
+}
+
+// String returns the violation in the same format as the errors it was
+// created from.
+func (v Violation) String() string {
+	if v.Commit == "" {
+		return v.Message
+	}
+
+	return (&CommitError{Hash: v.Commit, Message: v.Message}).Error()
+}
+
+// commits returns the hashes of the commits with problems, in the order
+// they were found.
+func (v Violations) commits() []string {
+	var hashes []string
+
+	seen := make(map[string]bool)
+
+	for _, violation := range v {
+		if violation.Commit == "" || seen[violation.Commit] {
+			continue
+		}
+
+		seen[violation.Commit] = true
+		hashes = append(hashes, violation.Commit)
+	}
+
+	return hashes
+}
+
+// byCommit returns the violations grouped by commit hash. Problems that do
+// not relate to a particular commit are recorded against a blank hash.
+func (v Violations) byCommit() map[string]Violations {
+	groups := make(map[string]Violations)
+
+	for _, violation := range v {
+		groups[violation.Commit] = append(groups[violation.Commit], violation)
+	}
+
+	return groups
+}
+
+// violationDisplayFunc displays the specified violations.
+type violationDisplayFunc func(w io.Writer, violations Violations) error
+
+var violationFormats = map[string]violationDisplayFunc{
+	textFormat:   displayViolationsText,
+	jsonFormat:   displayViolationsJSON,
+	githubFormat: displayViolationsGitHub,
+}
+
+// formatNames returns the sorted list of output formats.
+func formatNames() []string {
+	var names []string
+
+	for name := range violationFormats {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// checkFormat returns an error if the specified output format is not
+// supported.
+func checkFormat(format string) error {
+	if _, ok := violationFormats[format]; !ok {
+		return fmt.Errorf("invalid format %q (expected one of %s)",
+			format, strings.Join(formatNames(), ", "))
+	}
+
+	return nil
+}
+
+// displayViolations displays the specified violations in the specified
+// format.
+func displayViolations(w io.Writer, format string, violations Violations) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+
+	return violationFormats[format](w, violations)
+}
+
+// displayViolationsText displays the violations grouped by commit.
+func displayViolationsText(w io.Writer, violations Violations) error {
+	groups := violations.byCommit()
+
+	for _, hash := range violations.commits() {
+		group := groups[hash]
+
+		if _, err := fmt.Fprintf(w, "Commit %v (%q):\n", hash, group[0].Subject); err != nil {
+			return err
+		}
+
+		for _, violation := range group {
+			if _, err := fmt.Fprintf(w, "  - %s\n", violation.Message); err != nil {
+				return err
+			}
+		}
+	}
+
+	if group := groups[""]; len(group) > 0 {
+		if _, err := fmt.Fprintf(w, "All commits:\n"); err != nil {
+			return err
+		}
+
+		for _, violation := range group {
+			if _, err := fmt.Fprintf(w, "  - %s\n", violation.Message); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// violationsReport is the JSON representation of the violations.
+type violationsReport struct {
+	Commits []commitViolations `json:"commits"`
+
+	// Problems that do not relate to a particular commit.
+	General []string `json:"general"`
+}
+
+type commitViolations struct {
+	Commit   string   `json:"commit"`
+	Subject  string   `json:"subject"`
+	Problems []string `json:"problems"`
+}
+
+// displayViolationsJSON displays the violations as a JSON document, grouped
+// by commit.
+func displayViolationsJSON(w io.Writer, violations Violations) error {
+	report := violationsReport{
+		Commits: []commitViolations{},
+		General: []string{},
+	}
+
+	groups := violations.byCommit()
+
+	for _, hash := range violations.commits() {
+		group := groups[hash]
+
+		entry := commitViolations{
+			Commit:  hash,
+			Subject: group[0].Subject,
+		}
+
+		for _, violation := range group {
+			entry.Problems = append(entry.Problems, violation.Message)
+		}
+
+		report.Commits = append(report.Commits, entry)
+	}
+
+	for _, violation := range groups[""] {
+		report.General = append(report.General, violation.Message)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+// escapeGitHubData escapes the specified string for use in a GitHub Actions
+// workflow command.
+func escapeGitHubData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	s = strings.ReplaceAll(s, "\n", "%0A")
+
+	return s
+}
+
+// displayViolationsGitHub displays the violations as GitHub Actions error
+// annotations.
+func displayViolationsGitHub(w io.Writer, violations Violations) error {
+	groups := violations.byCommit()
+
+	hashes := append(violations.commits(), "")
+
+	for _, hash := range hashes {
+		for _, violation := range groups[hash] {
+			_, err := fmt.Fprintf(w, "::error title=checkcommits::%s\n",
+				escapeGitHubData(violation.String()))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2017-2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testViolations = Violations{
+	{Commit: "abc", Subject: "foo: bar", Message: "body line 3 too long"},
+	{Commit: "def", Subject: "no subsystem", Message: "Failed to find subsystem"},
+	{Message: `No "Fixes" found`},
+	{Commit: "abc", Subject: "foo: bar", Message: "no Signed-off-by specified"},
+}
+
+func TestNewViolation(t *testing.T) {
+	assert := assert.New(t)
+
+	commit := &Commit{hash: "abc", subject: "foo: bar"}
+
+	v := newViolation(commit, commitErrorf(commit, "problem %d", 1))
+	assert.Equal(Violation{Commit: "abc", Subject: "foo: bar", Message: "problem 1"}, v)
+	assert.Equal("Commit abc: problem 1", v.String())
+
+	v = newViolation(commit, errors.New("other error"))
+	assert.Equal("other error", v.Message)
+}
+
+func TestViolationsError(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("Commit abc: body line 3 too long", testViolations[:1].Error())
+	assert.Equal(`No "Fixes" found`, testViolations[2:3].Error())
+	assert.Equal("found 4 problems", testViolations.Error())
+
+	assert.Equal([]string{"abc", "def"}, testViolations.commits())
+}
+
+func TestDisplayViolations(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+
+	err := displayViolations(&buf, "invalid", testViolations)
+	assert.Error(err)
+
+	buf.Reset()
+	err = displayViolations(&buf, textFormat, testViolations)
+	assert.NoError(err)
+	assert.Equal(`Commit abc ("foo: bar"):
+  - body line 3 too long
+  - no Signed-off-by specified
+Commit def ("no subsystem"):
+  - Failed to find subsystem
+All commits:
+  - No "Fixes" found
+`, buf.String())
+
+	buf.Reset()
+	err = displayViolations(&buf, jsonFormat, testViolations)
+	assert.NoError(err)
+
+	var report violationsReport
+
+	err = json.Unmarshal(buf.Bytes(), &report)
+	assert.NoError(err)
+	assert.Equal(violationsReport{
+		Commits: []commitViolations{
+			{
+				Commit:   "abc",
+				Subject:  "foo: bar",
+				Problems: []string{"body line 3 too long", "no Signed-off-by specified"},
+			},
+			{
+				Commit:   "def",
+				Subject:  "no subsystem",
+				Problems: []string{"Failed to find subsystem"},
+			},
+		},
+		General: []string{`No "Fixes" found`},
+	}, report)
+
+	buf.Reset()
+	err = displayViolations(&buf, githubFormat, Violations{
+		{Commit: "abc", Message: "100% wrong\nreally"},
+		{Message: "general"},
+	})
+	assert.NoError(err)
+	assert.Equal("::error title=checkcommits::Commit abc: 100%25 wrong%0Areally\n"+
+		"::error title=checkcommits::general\n", buf.String())
+}
@@ -8,10 +8,61 @@ package main
 import (
 	"errors"
 	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
+const (
+	// Maximum length of a label name permitted by GitHub.
+	maxLabelNameLength = 50
+
+	// Minimum contrast ratio between a label's colour and its text
+	// (WCAG 2 level AA for normal text).
+	minContrastRatio = 4.5
+
+	// Colours of the text GitHub displays on light and dark labels.
+	githubDarkTextColour  = "000000"
+	githubLightTextColour = "ffffff"
+)
+
+// Matches a colour in the format GitHub requires.
+var colourPattern = regexp.MustCompile(`^[[:xdigit:]]{6}$`)
+
+// Problems records all the problems found with a labels database. Errors
+// make the database invalid whereas warnings do not.
+type Problems struct {
+	Errors   []string
+	Warnings []string
+}
+
+func (p *Problems) errorf(format string, args ...interface{}) {
+	p.Errors = append(p.Errors, fmt.Sprintf(format, args...))
+}
+
+func (p *Problems) warnf(format string, args ...interface{}) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Err returns an error describing all the errors found, or nil if there
+// are none.
+func (p *Problems) Err() error {
+	switch len(p.Errors) {
+	case 0:
+		return nil
+	case 1:
+		return errors.New(p.Errors[0])
+	}
+
+	return fmt.Errorf("found %d problems:\n    %s", len(p.Errors), strings.Join(p.Errors, "\n    "))
+}
+
 func containsWhitespace(s string) bool {
 	for _, ch := range s {
 		if unicode.IsSpace(ch) {
@@ -36,71 +87,257 @@ func isLower(s string) bool {
 	return true
 }
 
-func checkCategory(c Category) error {
+// relativeLuminance returns the WCAG 2 relative luminance of the colour,
+// which must be valid.
+func relativeLuminance(colour string) float64 {
+	value, _ := strconv.ParseUint(colour, 16, 32)
+
+	var luminance float64
+
+	for i, weight := range []float64{0.2126, 0.7152, 0.0722} {
+		channel := float64((value>>uint(16-8*i))&0xff) / 255
+
+		if channel <= 0.03928 {
+			channel /= 12.92
+		} else {
+			channel = math.Pow((channel+0.055)/1.055, 2.4)
+		}
+
+		luminance += weight * channel
+	}
+
+	return luminance
+}
+
+// contrastRatio returns the WCAG 2 contrast ratio between two valid
+// colours (from 1 to 21).
+func contrastRatio(colour1, colour2 string) float64 {
+	l1 := relativeLuminance(colour1)
+	l2 := relativeLuminance(colour2)
+
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// githubTextColour returns the colour of the text GitHub displays on a
+// label of the specified valid colour, based on its perceived brightness.
+func githubTextColour(colour string) string {
+	value, _ := strconv.ParseUint(colour, 16, 32)
+
+	red := (value >> 16) & 0xff
+	green := (value >> 8) & 0xff
+	blue := value & 0xff
+
+	if (red*299+green*587+blue*114)/1000 >= 128 {
+		return githubDarkTextColour
+	}
+
+	return githubLightTextColour
+}
+
+func checkURL(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid scheme %q", u.Scheme)
+	}
+
+	if u.Host == "" {
+		return errors.New("no host")
+	}
+
+	return nil
+}
+
+func checkCategory(p *Problems, c Category) {
 	if c.Name == "" {
-		return fmt.Errorf("category name cannot be blank: %+v", c)
+		p.errorf("category name cannot be blank: %+v", c)
 	}
 
 	if containsWhitespace(c.Name) {
-		return fmt.Errorf("category name cannot contain whitespace: %+v", c)
+		p.errorf("category name cannot contain whitespace: %+v", c)
 	}
 
 	if !isLower(c.Name) {
-		return fmt.Errorf("category name must be all lower case: %+v", c)
+		p.errorf("category name must be all lower case: %+v", c)
+	}
+
+	if c.URL != "" {
+		if err := checkURL(c.URL); err != nil {
+			p.errorf("category URL is invalid (%v): %+v", err, c)
+		}
 	}
 
 	if c.Description == "" {
-		return fmt.Errorf("category description cannot be blank: %+v", c)
+		p.errorf("category description cannot be blank: %+v", c)
+		return
 	}
 
 	first := c.Description[0]
 
 	if !unicode.IsUpper(rune(first)) {
-		return fmt.Errorf("category description needs initial capital letter: %+v", c)
+		p.errorf("category description needs initial capital letter: %+v", c)
 	}
 
 	if !strings.HasSuffix(c.Description, ".") {
-		return fmt.Errorf("category description needs trailing period: %+v", c)
+		p.errorf("category description needs trailing period: %+v", c)
 	}
-
-	return nil
 }
 
-func checkLabel(l Label) error {
+func checkLabel(p *Problems, l Label) {
 	if l.Name == "" {
-		return fmt.Errorf("label name cannot be blank: %+v", l)
+		p.errorf("label name cannot be blank: %+v", l)
 	}
 
 	if !isLower(l.Name) {
-		return fmt.Errorf("label name must be all lower case: %+v", l)
+		p.errorf("label name must be all lower case: %+v", l)
 	}
 
 	if containsWhitespace(l.Name) {
-		return fmt.Errorf("label name cannot contain whitespace: %+v", l)
+		p.errorf("label name cannot contain whitespace: %+v", l)
+	}
+
+	if utf8.RuneCountInString(l.Name) > maxLabelNameLength {
+		p.warnf("label name longer than %d characters (GitHub limit): %+v", maxLabelNameLength, l)
 	}
 
 	if l.Description == "" {
-		return fmt.Errorf("label description cannot be blank: %+v", l)
+		p.errorf("label description cannot be blank: %+v", l)
+	} else if first := l.Description[0]; !unicode.IsUpper(rune(first)) {
+		p.errorf("label description needs initial capital letter: %+v", l)
 	}
 
-	first := l.Description[0]
+	if l.CategoryName == "" {
+		p.errorf("label category name cannot be blank: %+v", l)
+	}
 
-	if !unicode.IsUpper(rune(first)) {
-		return fmt.Errorf("label description needs initial capital letter: %+v", l)
+	switch {
+	case l.Colour == "":
+		p.errorf("label colour cannot be blank: %+v", l)
+	case strings.Contains(l.Colour, defaultColourVariable):
+		p.errorf("label colour %s not expanded (generate the labels database from the template): %+v", defaultColourVariable, l)
+	case !colourPattern.MatchString(l.Colour):
+		p.errorf("label colour must be 6 hex digits: %+v", l)
+	default:
+		text := githubTextColour(l.Colour)
+
+		if ratio := contrastRatio(l.Colour, text); ratio < minContrastRatio {
+			p.warnf("label colour has poor contrast with text colour %s (ratio %.1f, minimum %.1f): %+v",
+				text, ratio, minContrastRatio, l)
+		}
 	}
 
-	if l.CategoryName == "" {
-		return fmt.Errorf("label category name cannot be blank: %+v", l)
+	if l.From != "" && l.From == l.Name {
+		p.errorf("label cannot be renamed from itself: %+v", l)
+	}
+
+	checkLabelMetadata(p, l)
+}
+
+// checkLabelMetadata checks the optional values used to apply the label
+// automatically.
+func checkLabelMetadata(p *Problems, l Label) {
+	paths := make(map[string]bool)
+
+	for _, glob := range l.Paths {
+		if glob == "" {
+			p.errorf("label path cannot be blank: %+v", l)
+			continue
+		}
+
+		if _, err := path.Match(glob, ""); err != nil {
+			p.errorf("label path %q is not a valid glob: %+v", glob, l)
+		}
+
+		if paths[glob] {
+			p.errorf("duplicate label path %q: %+v", glob, l)
+		}
+
+		paths[glob] = true
+	}
+
+	keywords := make(map[string]bool)
+
+	for _, keyword := range l.Keywords {
+		if keyword == "" {
+			p.errorf("label keyword cannot be blank: %+v", l)
+			continue
+		}
+
+		// Titles are matched ignoring case
+		lower := strings.ToLower(keyword)
+
+		if keywords[lower] {
+			p.errorf("duplicate label keyword %q: %+v", keyword, l)
+		}
+
+		keywords[lower] = true
 	}
+}
+
+// checkRenames checks the "From" values of the labels.
+func checkRenames(p *Problems, lf *LabelsFile) {
+	// Key: label name
+	// Value: name of the label it is renamed from
+	from := make(map[string]string)
 
-	if l.Colour == "" {
-		return fmt.Errorf("label colour cannot be blank: %+v", l)
+	for _, l := range lf.Labels {
+		from[l.Name] = l.From
 	}
 
-	return nil
+	// Labels already found to be part of a cycle
+	inCycle := make(map[string]bool)
+
+	for _, l := range lf.Labels {
+		if l.From == "" || l.From == l.Name {
+			continue
+		}
+
+		if _, ok := from[l.From]; ok {
+			p.errorf("label renamed from %q which still exists: %+v", l.From, l)
+		}
+
+		if inCycle[l.Name] {
+			continue
+		}
+
+		// Follow the chain of renames
+		chain := []string{l.Name}
+		seen := map[string]bool{l.Name: true}
+
+		for name := l.From; name != ""; name = from[name] {
+			chain = append(chain, name)
+
+			if name == l.Name {
+				for _, n := range chain {
+					inCycle[n] = true
+				}
+
+				p.errorf("rename cycle: %s", strings.Join(chain, " <- "))
+				break
+			}
+
+			if seen[name] {
+				// A cycle not involving this label
+				break
+			}
+
+			seen[name] = true
+		}
+	}
 }
 
-func checkLabelsAndCategories(lf *LabelsFile) error {
+// checkProblems returns all the problems found in the categories and
+// labels of the labels database.
+func checkProblems(lf *LabelsFile) *Problems {
+	p := &Problems{}
+
 	catCount := 0
 
 	var catNameMap map[string]int
@@ -115,44 +352,40 @@ func checkLabelsAndCategories(lf *LabelsFile) error {
 	labelDescMap = make(map[string]int)
 
 	for _, c := range lf.Categories {
-		if err := checkCategory(c); err != nil {
-			return err
-		}
+		checkCategory(p, c)
 
 		catCount++
 
 		if _, ok := catNameMap[c.Name]; ok {
-			return fmt.Errorf("duplicate category name: %+v", c)
+			p.errorf("duplicate category name: %+v", c)
 		}
 
 		catNameMap[c.Name] = 0
 
 		if _, ok := catDescMap[c.Description]; ok {
-			return fmt.Errorf("duplicate category description: %+v", c)
+			p.errorf("duplicate category description: %+v", c)
 		}
 
 		catDescMap[c.Description] = 0
 	}
 
 	if catCount == 0 {
-		return errors.New("no categories found")
+		p.errorf("no categories found")
 	}
 
 	labelCount := 0
 
 	for _, l := range lf.Labels {
-		if err := checkLabel(l); err != nil {
-			return err
-		}
+		checkLabel(p, l)
 
 		if _, ok := labelNameMap[l.Name]; ok {
-			return fmt.Errorf("duplicate label name: %+v", l)
+			p.errorf("duplicate label name: %+v", l)
 		}
 
 		labelNameMap[l.Name] = 0
 
 		if _, ok := labelDescMap[l.Description]; ok {
-			return fmt.Errorf("duplicate label description: %+v", l)
+			p.errorf("duplicate label description: %+v", l)
 		}
 
 		labelDescMap[l.Description] = 0
@@ -164,7 +397,8 @@ func checkLabelsAndCategories(lf *LabelsFile) error {
 		var value int
 		var ok bool
 		if value, ok = catNameMap[catName]; !ok {
-			return fmt.Errorf("invalid category %v found for label %+v", catName, l)
+			p.errorf("invalid category %v found for label %+v", catName, l)
+			continue
 		}
 
 		// Record category name seen and count of occurrences
@@ -173,17 +407,29 @@ func checkLabelsAndCategories(lf *LabelsFile) error {
 	}
 
 	if labelCount == 0 {
-		return errors.New("no labels found")
+		p.errorf("no labels found")
 	}
 
+	checkRenames(p, lf)
+
 	if debug {
 		fmt.Printf("DEBUG: category count: %v\n", catCount)
 		fmt.Printf("DEBUG: label count: %v\n", labelCount)
 	}
 
-	for name, count := range catNameMap {
+	var catNames []string
+
+	for name := range catNameMap {
+		catNames = append(catNames, name)
+	}
+
+	sort.Strings(catNames)
+
+	for _, name := range catNames {
+		count := catNameMap[name]
+
 		if count == 0 {
-			return fmt.Errorf("category %v not used", name)
+			p.errorf("category %v not used", name)
 		}
 
 		if debug {
@@ -192,25 +438,35 @@ func checkLabelsAndCategories(lf *LabelsFile) error {
 		}
 	}
 
-	return nil
+	return p
 }
 
-func check(lf *LabelsFile) error {
+func checkLabelsAndCategories(lf *LabelsFile) error {
+	return checkProblems(lf).Err()
+}
+
+// checkAll returns all the problems found in the labels database.
+func checkAll(lf *LabelsFile) *Problems {
+	p := &Problems{}
+
 	if lf.Description == "" {
-		return errors.New("description cannot be blank")
+		p.errorf("description cannot be blank")
 	}
 
 	if lf.Repo == "" {
-		return errors.New("repo cannot be blank")
+		p.errorf("repo cannot be blank")
+	} else if strings.Contains(lf.Repo, repoSlugVariable) {
+		p.errorf("repo %s not expanded (generate the labels database from the template)", repoSlugVariable)
 	}
 
-	if len(lf.Categories) == 0 {
-		return errors.New("no categories")
-	}
+	labelProblems := checkProblems(lf)
 
-	if len(lf.Labels) == 0 {
-		return errors.New("no labels")
-	}
+	p.Errors = append(p.Errors, labelProblems.Errors...)
+	p.Warnings = append(p.Warnings, labelProblems.Warnings...)
 
-	return checkLabelsAndCategories(lf)
+	return p
+}
+
+func check(lf *LabelsFile) error {
+	return checkAll(lf).Err()
 }
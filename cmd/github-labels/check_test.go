@@ -0,0 +1,253 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContrastRatio(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.InDelta(21.0, contrastRatio("000000", "ffffff"), 0.01)
+	assert.InDelta(21.0, contrastRatio("ffffff", "000000"), 0.01)
+	assert.InDelta(1.0, contrastRatio("7057ff", "7057ff"), 0.01)
+	assert.InDelta(4.0, contrastRatio("ff0000", "ffffff"), 0.01)
+
+	assert.Equal(githubDarkTextColour, githubTextColour("ffffff"))
+	assert.Equal(githubDarkTextColour, githubTextColour("fbca04"))
+	assert.Equal(githubLightTextColour, githubTextColour("000000"))
+	assert.Equal(githubLightTextColour, githubTextColour("b60205"))
+}
+
+func TestCheckLabel(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ededed"}
+
+	type testData struct {
+		change           func(l *Label)
+		expectedErrors   []string
+		expectedWarnings []string
+	}
+
+	data := []testData{
+		{func(l *Label) {}, nil, nil},
+
+		{func(l *Label) { l.Name = "" }, []string{"name cannot be blank"}, nil},
+		{func(l *Label) { l.Name = "Bug" }, []string{"must be all lower case"}, nil},
+		{func(l *Label) { l.Name = "a bug" }, []string{"cannot contain whitespace"}, nil},
+		{func(l *Label) { l.Name = strings.Repeat("a", 50) }, nil, nil},
+		{func(l *Label) { l.Name = strings.Repeat("a", 51) }, nil, []string{"longer than 50 characters"}},
+
+		{func(l *Label) { l.Description = "" }, []string{"description cannot be blank"}, nil},
+		{func(l *Label) { l.Description = "something" }, []string{"initial capital letter"}, nil},
+		{func(l *Label) { l.CategoryName = "" }, []string{"category name cannot be blank"}, nil},
+
+		{func(l *Label) { l.Colour = "" }, []string{"colour cannot be blank"}, nil},
+		{func(l *Label) { l.Colour = "DEFAULT_COLOUR" }, []string{"DEFAULT_COLOUR not expanded"}, nil},
+		{func(l *Label) { l.Colour = "#ededed" }, []string{"6 hex digits"}, nil},
+		{func(l *Label) { l.Colour = "fff" }, []string{"6 hex digits"}, nil},
+		{func(l *Label) { l.Colour = "eeeeeg" }, []string{"6 hex digits"}, nil},
+		{func(l *Label) { l.Colour = "EDEDED" }, nil, nil},
+		{func(l *Label) { l.Colour = "ff0000" }, nil, []string{"poor contrast with text colour ffffff"}},
+
+		{func(l *Label) { l.From = "bug" }, []string{"renamed from itself"}, nil},
+
+		{func(l *Label) { l.Paths = []string{"*.go", "docs/**"}; l.Keywords = []string{"crash", "panic"} }, nil, nil},
+		{func(l *Label) { l.Paths = []string{""} }, []string{"path cannot be blank"}, nil},
+		{func(l *Label) { l.Paths = []string{"[a-"} }, []string{"not a valid glob"}, nil},
+		{func(l *Label) { l.Paths = []string{"*.go", "*.go"} }, []string{"duplicate label path"}, nil},
+		{func(l *Label) { l.Keywords = []string{""} }, []string{"keyword cannot be blank"}, nil},
+		{func(l *Label) { l.Keywords = []string{"Crash", "crash"} }, []string{"duplicate label keyword"}, nil},
+
+		// All problems are reported
+		{func(l *Label) {
+			l.Name = "A " + strings.Repeat("b", 50)
+			l.Description = ""
+			l.Colour = "red"
+		}, []string{"lower case", "whitespace", "description cannot be blank", "6 hex digits"}, []string{"longer than 50"}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		l := valid
+		d.change(&l)
+
+		p := &Problems{}
+		checkLabel(p, l)
+
+		if assert.Len(p.Errors, len(d.expectedErrors), "%s: %v", msg, p.Errors) {
+			for j, expected := range d.expectedErrors {
+				assert.True(strings.Contains(p.Errors[j], expected), "%s: %v", msg, p.Errors[j])
+			}
+		}
+
+		if assert.Len(p.Warnings, len(d.expectedWarnings), "%s: %v", msg, p.Warnings) {
+			for j, expected := range d.expectedWarnings {
+				assert.True(strings.Contains(p.Warnings[j], expected), "%s: %v", msg, p.Warnings[j])
+			}
+		}
+	}
+}
+
+func TestCheckCategory(t *testing.T) {
+	assert := assert.New(t)
+
+	valid := Category{Name: "test", Description: "Test category.", URL: "https://example.com/docs"}
+
+	type testData struct {
+		change         func(c *Category)
+		expectedErrors []string
+	}
+
+	data := []testData{
+		{func(c *Category) {}, nil},
+		{func(c *Category) { c.URL = "" }, nil},
+		{func(c *Category) { c.URL = "http://example.com" }, nil},
+
+		{func(c *Category) { c.URL = "example.com/docs" }, []string{"URL is invalid"}},
+		{func(c *Category) { c.URL = "ftp://example.com" }, []string{"URL is invalid"}},
+		{func(c *Category) { c.URL = "https://" }, []string{"URL is invalid"}},
+		{func(c *Category) { c.URL = "https://exa mple.com" }, []string{"URL is invalid"}},
+
+		{func(c *Category) { c.Name = "" }, []string{"name cannot be blank"}},
+		{func(c *Category) { c.Description = "test category" }, []string{"initial capital letter", "trailing period"}},
+		{func(c *Category) { c.Name = "A b"; c.Description = "" }, []string{"whitespace", "lower case", "description cannot be blank"}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		c := valid
+		d.change(&c)
+
+		p := &Problems{}
+		checkCategory(p, c)
+
+		assert.Empty(p.Warnings, msg)
+
+		if assert.Len(p.Errors, len(d.expectedErrors), "%s: %v", msg, p.Errors) {
+			for j, expected := range d.expectedErrors {
+				assert.True(strings.Contains(p.Errors[j], expected), "%s: %v", msg, p.Errors[j])
+			}
+		}
+	}
+}
+
+func TestCheckRenames(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		// Each element is "name<-from"
+		renames        []string
+		expectedErrors []string
+	}
+
+	data := []testData{
+		{[]string{"a", "b", "c"}, nil},
+		{[]string{"a<-x", "b<-y", "c"}, nil},
+
+		{[]string{"a<-b", "b"}, []string{`renamed from "b" which still exists`}},
+
+		{[]string{"a<-b", "b<-a"}, []string{
+			`renamed from "b" which still exists`,
+			"rename cycle: a <- b <- a",
+			`renamed from "a" which still exists`,
+		}},
+		{[]string{"a<-b", "b<-c", "c<-a", "d<-a"}, []string{
+			`renamed from "b" which still exists`,
+			"rename cycle: a <- b <- c <- a",
+			`renamed from "c" which still exists`,
+			`renamed from "a" which still exists`,
+			`renamed from "a" which still exists`,
+		}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		lf := newTestLabelsFile()
+
+		for _, rename := range d.renames {
+			fields := strings.SplitN(rename, "<-", 2)
+
+			l := Label{Name: fields[0]}
+			if len(fields) > 1 {
+				l.From = fields[1]
+			}
+
+			lf.Labels = append(lf.Labels, l)
+		}
+
+		p := &Problems{}
+		checkRenames(p, lf)
+
+		if assert.Len(p.Errors, len(d.expectedErrors), "%s: %v", msg, p.Errors) {
+			for j, expected := range d.expectedErrors {
+				assert.True(strings.Contains(p.Errors[j], expected), "%s: %v", msg, p.Errors[j])
+			}
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestLabelsFile(
+		Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ededed"},
+	)
+
+	assert.NoError(check(lf))
+	assert.NoError(checkLabelsAndCategories(lf))
+
+	lf.Repo = "REPO_SLUG"
+	assert.Error(check(lf))
+	assert.NoError(checkLabelsAndCategories(lf))
+
+	// All problems are reported at once
+	lf = &LabelsFile{
+		Categories: Categories{
+			{Name: "test", Description: "Test category.", URL: "not a url"},
+			{Name: "unused", Description: "Unused category."},
+		},
+		Labels: Labels{
+			{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "DEFAULT_COLOUR"},
+			{Name: "defect", Description: "Something is broken", CategoryName: "invalid", Colour: "ededed", From: "bug"},
+		},
+	}
+
+	p := checkAll(lf)
+
+	expected := []string{
+		"description cannot be blank",
+		"repo cannot be blank",
+		"category URL is invalid",
+		"DEFAULT_COLOUR not expanded",
+		"duplicate label description",
+		"invalid category invalid",
+		`renamed from "bug" which still exists`,
+		"category unused not used",
+	}
+
+	if assert.Len(p.Errors, len(expected), "%v", p.Errors) {
+		for i, e := range expected {
+			assert.True(strings.Contains(p.Errors[i], e), p.Errors[i])
+		}
+	}
+
+	err := p.Err()
+	assert.Error(err)
+	assert.True(strings.HasPrefix(err.Error(), fmt.Sprintf("found %d problems:\n", len(expected))), err)
+
+	assert.Nil((&Problems{Warnings: []string{"warning"}}).Err())
+	assert.Equal("problem", (&Problems{Errors: []string{"problem"}}).Err().Error())
+}
@@ -15,6 +15,16 @@ func cleanString(s string) string {
 	return result
 }
 
+func cleanStrings(strs []string) []string {
+	var result []string
+
+	for _, s := range strs {
+		result = append(result, cleanString(s))
+	}
+
+	return result
+}
+
 func cleanLabel(l Label) Label {
 	return Label{
 		Name:         cleanString(l.Name),
@@ -22,6 +32,8 @@ func cleanLabel(l Label) Label {
 		CategoryName: cleanString(l.CategoryName),
 		Colour:       cleanString(l.Colour),
 		From:         cleanString(l.From),
+		Paths:        cleanStrings(l.Paths),
+		Keywords:     cleanStrings(l.Keywords),
 	}
 }
 
@@ -0,0 +1,90 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+type DiffKind string
+
+const (
+	// Label in the database but not the repository.
+	diffMissing DiffKind = "missing"
+
+	// Label in the repository but not the database.
+	diffExtra DiffKind = "extra"
+
+	// Label in the repository with the name specified by a database
+	// label's "From" value (or with a name that only differs in case).
+	diffRenamed DiffKind = "renamed"
+
+	diffRecoloured  DiffKind = "recoloured"
+	diffRedescribed DiffKind = "redescribed"
+)
+
+// LabelDifference describes a single difference between the labels
+// database and the labels in a repository.
+type LabelDifference struct {
+	Kind DiffKind `json:"kind"`
+
+	// Name of the label in the database (or in the repository for
+	// extra labels).
+	Label string `json:"label"`
+
+	// Value in the repository (name, colour or description depending on
+	// the kind of difference). Unset for missing and extra labels.
+	Current string `json:"current"`
+
+	// Value in the database.
+	Expected string `json:"expected"`
+}
+
+// LabelsDiff is the result of comparing the labels database with the
+// labels in a repository.
+type LabelsDiff struct {
+	// The repository compared against (or the snapshot file name).
+	Repo string `json:"repo"`
+
+	Differences []LabelDifference `json:"differences"`
+}
+
+// diffLabels compares the labels database with the existing labels in a
+// repository. The differences are those that the sync command would
+// resolve when pruning.
+func diffLabels(lf *LabelsFile, repo string, existing []RemoteLabel) *LabelsDiff {
+	diff := &LabelsDiff{
+		Repo: repo,
+	}
+
+	add := func(kind DiffKind, label, current, expected string) {
+		diff.Differences = append(diff.Differences, LabelDifference{
+			Kind:     kind,
+			Label:    label,
+			Current:  current,
+			Expected: expected,
+		})
+	}
+
+	for _, change := range planSync(lf, existing, true) {
+		switch change.Action {
+		case syncCreate:
+			add(diffMissing, change.New.Name, "", "")
+		case syncDelete:
+			add(diffExtra, change.Old.Name, "", "")
+		default:
+			if change.Old.Name != change.New.Name {
+				add(diffRenamed, change.New.Name, change.Old.Name, change.New.Name)
+			}
+
+			if normaliseColour(change.Old.Colour) != change.New.Colour {
+				add(diffRecoloured, change.New.Name, change.Old.Colour, change.New.Colour)
+			}
+
+			if change.Old.Description != change.New.Description {
+				add(diffRedescribed, change.New.Name, change.Old.Description, change.New.Description)
+			}
+		}
+	}
+
+	return diff
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestLabelsFile(
+		Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ee0701"},
+		Label{Name: "good-first-issue", Description: "Suitable for a new contributor", CategoryName: "test", Colour: "7057ff", From: "good first issue"},
+		Label{Name: "needs-review", Description: "Needs a review", CategoryName: "test", Colour: "ededed"},
+		Label{Name: "question", Description: "Needs an answer", CategoryName: "test", Colour: "cc317c"},
+		Label{Name: "wip", Description: "Work in progress", CategoryName: "test", Colour: "b60205"},
+	)
+
+	existing := []RemoteLabel{
+		{"bug", "EE0701", "Something is broken"},
+		{"good first issue", "7057ff", ""},
+		{"needs-review", "000000", "Needs a review"},
+		{"WIP", "b60205", "Work in progress"},
+		{"wontfix", "ffffff", ""},
+	}
+
+	diff := diffLabels(lf, testRepo, existing)
+	assert.Equal(testRepo, diff.Repo)
+
+	assert.Equal([]LabelDifference{
+		{diffRenamed, "good-first-issue", "good first issue", "good-first-issue"},
+		{diffRedescribed, "good-first-issue", "", "Suitable for a new contributor"},
+		{diffRecoloured, "needs-review", "000000", "ededed"},
+		{diffRenamed, "wip", "WIP", "wip"},
+		{diffMissing, "question", "", ""},
+		{diffExtra, "wontfix", "", ""},
+	}, diff.Differences)
+
+	diff = diffLabels(lf, testRepo, []RemoteLabel{
+		{"bug", "ee0701", "Something is broken"},
+		{"good-first-issue", "7057ff", "Suitable for a new contributor"},
+		{"needs-review", "ededed", "Needs a review"},
+		{"question", "cc317c", "Needs an answer"},
+		{"wip", "b60205", "Work in progress"},
+	})
+	assert.Empty(diff.Differences)
+}
+
+func TestDisplayDiff(t *testing.T) {
+	assert := assert.New(t)
+
+	diff := &LabelsDiff{
+		Repo: testRepo,
+		Differences: []LabelDifference{
+			{diffRecoloured, "needs-review", "000000", "ededed"},
+			{diffRedescribed, "good-first-issue", "", "Suitable for a new contributor"},
+			{diffMissing, "question", "", ""},
+		},
+	}
+
+	type testData struct {
+		newHandler    func(file *os.File) DisplayHandler
+		expectedLines []string
+	}
+
+	data := []testData{
+		{NewDisplayText, []string{
+			"Differences from kata-containers/tests (count: 3):",
+			`    needs-review: recoloured (current "000000", expected "ededed")`,
+			`    good-first-issue: redescribed (current "", expected "Suitable for a new contributor")`,
+			"    question: missing",
+		}},
+		{NewDisplayTSV, []string{
+			"Label\tDifference\tCurrent\tExpected",
+			"needs-review\trecoloured\t000000\tededed",
+			"good-first-issue\tredescribed\t\tSuitable for a new contributor",
+			"question\tmissing\t\t",
+		}},
+	}
+
+	for _, d := range data {
+		file, err := ioutil.TempFile("", "")
+		assert.NoError(err)
+		defer os.Remove(file.Name())
+
+		err = d.newHandler(file).DisplayDiff(diff)
+		assert.NoError(err)
+		assert.NoError(file.Close())
+
+		bytes, err := ioutil.ReadFile(file.Name())
+		assert.NoError(err)
+
+		lines := strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n")
+		assert.Equal(d.expectedLines, lines)
+	}
+
+	// Names and colours are quoted in markdown
+	record := diffToRecord(diff.Differences[0], true)
+	assert.Equal([]string{"`needs-review`", "recoloured", "`000000`", "`ededed`"}, record)
+
+	record = diffToRecord(diff.Differences[1], true)
+	assert.Equal([]string{"`good-first-issue`", "redescribed", "", "Suitable for a new contributor"}, record)
+}
@@ -17,6 +17,9 @@ var outputFile = os.Stdout
 type DisplayHandler interface {
 	DisplayLabels(lf *LabelsFile) error
 	DisplayCategories(lf *LabelsFile, showLabels bool) error
+	DisplayDiff(diff *LabelsDiff) error
+	DisplayUsage(usage *Usage) error
+	DisplayDrift(drift []RepoDrift) error
 }
 
 // DisplayHandlers encapsulates the list of available display handlers.
@@ -33,6 +36,7 @@ func NewDisplayHandlers() *DisplayHandlers {
 	if handlers == nil {
 		handlers = make(map[string]DisplayHandler)
 
+		handlers["json"] = NewDisplayJSON(outputFile)
 		handlers["md"] = NewDisplayMD(outputFile)
 		handlers[textFormat] = NewDisplayText(outputFile)
 		handlers["tsv"] = NewDisplayTSV(outputFile)
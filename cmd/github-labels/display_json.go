@@ -0,0 +1,96 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+type displayJSON struct {
+	encoder *json.Encoder
+}
+
+// jsonCategory is a category with the names of the labels it contains.
+type jsonCategory struct {
+	Category
+
+	Labels []string `json:"labels"`
+}
+
+func NewDisplayJSON(file *os.File) DisplayHandler {
+	j := &displayJSON{}
+
+	j.encoder = json.NewEncoder(file)
+	j.encoder.SetIndent("", "  ")
+
+	return j
+}
+
+func (d *displayJSON) DisplayLabels(lf *LabelsFile) error {
+	labels := lf.Labels
+	if labels == nil {
+		labels = Labels{}
+	}
+
+	return d.encoder.Encode(labels)
+}
+
+func (d *displayJSON) DisplayCategories(lf *LabelsFile, showLabels bool) error {
+	if !showLabels {
+		categories := lf.Categories
+		if categories == nil {
+			categories = Categories{}
+		}
+
+		return d.encoder.Encode(categories)
+	}
+
+	categories := []jsonCategory{}
+
+	for _, c := range lf.Categories {
+		labels, err := getLabelsByCategory(c.Name, lf)
+		if err != nil {
+			return err
+		}
+
+		names := []string{}
+
+		for _, l := range labels {
+			names = append(names, l.Name)
+		}
+
+		categories = append(categories, jsonCategory{
+			Category: c,
+			Labels:   names,
+		})
+	}
+
+	return d.encoder.Encode(categories)
+}
+
+func (d *displayJSON) DisplayDiff(diff *LabelsDiff) error {
+	// Display an empty list rather than null
+	if diff.Differences == nil {
+		empty := *diff
+		empty.Differences = []LabelDifference{}
+		diff = &empty
+	}
+
+	return d.encoder.Encode(diff)
+}
+
+func (d *displayJSON) DisplayDrift(drift []RepoDrift) error {
+	if drift == nil {
+		drift = []RepoDrift{}
+	}
+
+	return d.encoder.Encode(drift)
+}
+
+func (d *displayJSON) DisplayUsage(usage *Usage) error {
+	return d.encoder.Encode(usage)
+}
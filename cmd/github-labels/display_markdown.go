@@ -6,17 +6,25 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/olekukonko/tablewriter"
 )
 
 type displayMD struct {
+	file   *os.File
 	writer *tablewriter.Table
 }
 
 func NewDisplayMD(file *os.File) DisplayHandler {
-	md := &displayMD{}
+	return newDisplayMD(file)
+}
+
+func newDisplayMD(file *os.File) *displayMD {
+	md := &displayMD{
+		file: file,
+	}
 
 	md.writer = tablewriter.NewWriter(file)
 	md.writer.SetCenterSeparator("|")
@@ -38,6 +46,9 @@ func (d *displayMD) render(headerFields []string, records [][]string) {
 	d.writer.SetHeader(headerFields)
 	d.writer.AppendBulk(records)
 	d.writer.Render()
+
+	// Allow another table to be rendered
+	d.writer.ClearRows()
 }
 
 func (d *displayMD) DisplayLabels(lf *LabelsFile) error {
@@ -55,6 +66,41 @@ func (d *displayMD) DisplayLabels(lf *LabelsFile) error {
 	return nil
 }
 
+func (d *displayMD) DisplayDiff(diff *LabelsDiff) error {
+	var records [][]string
+
+	for _, difference := range diff.Differences {
+		record := diffToRecord(difference, true)
+		records = append(records, record)
+	}
+
+	headerFields := diffHeaderRecord()
+
+	d.render(headerFields, records)
+
+	return nil
+}
+
+func (d *displayMD) DisplayUsage(usage *Usage) error {
+	headerFields := usageHeaderRecord()
+
+	d.render(headerFields, usageToRecords(usage, true))
+
+	return nil
+}
+
+func (d *displayMD) DisplayDrift(drift []RepoDrift) error {
+	var records [][]string
+
+	for _, r := range drift {
+		records = append(records, driftToRecord(r))
+	}
+
+	d.render(driftHeaderRecord(), records)
+
+	return nil
+}
+
 func (d *displayMD) DisplayCategories(lf *LabelsFile, showLabels bool) error {
 	headerFields := categoryHeaderRecord(showLabels)
 
@@ -73,3 +119,46 @@ func (d *displayMD) DisplayCategories(lf *LabelsFile, showLabels bool) error {
 
 	return nil
 }
+
+// DisplayGuide displays a guide to the labels in each category, including
+// when each label is applied automatically.
+func (d *displayMD) DisplayGuide(lf *LabelsFile) error {
+	_, err := fmt.Fprintf(d.file, "# Labels for %s\n\n", lf.Repo)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range lf.Categories {
+		labels, err := getLabelsByCategory(c.Name, lf)
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(d.file, "## `%s`\n\n%s\n\n", c.Name, c.Description)
+		if err != nil {
+			return err
+		}
+
+		if c.URL != "" {
+			_, err = fmt.Fprintf(d.file, "See %s.\n\n", c.URL)
+			if err != nil {
+				return err
+			}
+		}
+
+		var records [][]string
+
+		for _, l := range labels {
+			records = append(records, labelToGuideRecord(l))
+		}
+
+		d.render(guideHeaderRecord(), records)
+
+		_, err = fmt.Fprintf(d.file, "\n")
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -47,6 +47,108 @@ func (d *displayText) displayLabel(l Label) error {
 	return err
 }
 
+func (d *displayText) DisplayDiff(diff *LabelsDiff) error {
+	_, err := fmt.Fprintf(d.file, "Differences from %s (count: %d):\n", diff.Repo, len(diff.Differences))
+	if err != nil {
+		return err
+	}
+
+	for _, difference := range diff.Differences {
+		switch difference.Kind {
+		case diffMissing, diffExtra:
+			_, err = fmt.Fprintf(d.file, "    %s: %s\n",
+				difference.Label,
+				difference.Kind)
+		default:
+			_, err = fmt.Fprintf(d.file, "    %s: %s (current %q, expected %q)\n",
+				difference.Label,
+				difference.Kind,
+				difference.Current,
+				difference.Expected)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *displayText) DisplayDrift(drift []RepoDrift) error {
+	_, err := fmt.Fprintf(d.file, "Drift (repositories: %d):\n", len(drift))
+	if err != nil {
+		return err
+	}
+
+	for _, r := range drift {
+		if r.Error != "" {
+			_, err = fmt.Fprintf(d.file, "    %s: %s\n", r.Repo, driftStatus(r))
+		} else {
+			_, err = fmt.Fprintf(d.file, "    %s: %s (missing: %d, extra: %d, renamed: %d, recoloured: %d, redescribed: %d)\n",
+				r.Repo,
+				driftStatus(r),
+				r.Missing,
+				r.Extra,
+				r.Renamed,
+				r.Recoloured,
+				r.Redescribed)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *displayText) DisplayUsage(usage *Usage) error {
+	_, err := fmt.Fprintf(d.file, "Usage in %s (open: %d, closed: %d):\n", usage.Repo, usage.Open, usage.Closed)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(d.file, "Labels (count: %d):\n", len(usage.Labels))
+	if err != nil {
+		return err
+	}
+
+	for _, u := range usage.Labels {
+		status := usageStatus(u)
+		if status != "" {
+			status = fmt.Sprintf(" [%s]", status)
+		}
+
+		_, err = fmt.Fprintf(d.file, "    %s (category %q, open: %d, closed: %d)%s\n",
+			u.Name,
+			u.Category,
+			u.Open,
+			u.Closed,
+			status)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(d.file, "Categories (count: %d):\n", len(usage.Categories))
+	if err != nil {
+		return err
+	}
+
+	for _, u := range usage.Categories {
+		_, err = fmt.Fprintf(d.file, "    %s (open: %d, closed: %d)\n",
+			u.Name,
+			u.Open,
+			u.Closed)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *displayText) DisplayCategories(lf *LabelsFile, showLabels bool) error {
 	_, err := fmt.Fprintf(d.file, "Categories (count: %d):\n", len(lf.Categories))
 	if err != nil {
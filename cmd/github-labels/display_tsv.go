@@ -43,6 +43,59 @@ func (d *displayTSV) DisplayLabels(lf *LabelsFile) error {
 	return d.writer.Error()
 }
 
+func (d *displayTSV) DisplayDiff(diff *LabelsDiff) error {
+	record := diffHeaderRecord()
+	if err := d.writer.Write(record); err != nil {
+		return err
+	}
+
+	for _, difference := range diff.Differences {
+		record := diffToRecord(difference, false)
+
+		if err := d.writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	d.writer.Flush()
+
+	return d.writer.Error()
+}
+
+func (d *displayTSV) DisplayUsage(usage *Usage) error {
+	record := usageHeaderRecord()
+	if err := d.writer.Write(record); err != nil {
+		return err
+	}
+
+	for _, record := range usageToRecords(usage, false) {
+		if err := d.writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	d.writer.Flush()
+
+	return d.writer.Error()
+}
+
+func (d *displayTSV) DisplayDrift(drift []RepoDrift) error {
+	record := driftHeaderRecord()
+	if err := d.writer.Write(record); err != nil {
+		return err
+	}
+
+	for _, r := range drift {
+		if err := d.writer.Write(driftToRecord(r)); err != nil {
+			return err
+		}
+	}
+
+	d.writer.Flush()
+
+	return d.writer.Error()
+}
+
 func (d *displayTSV) DisplayCategories(lf *LabelsFile, showLabels bool) error {
 	record := categoryHeaderRecord(showLabels)
 	if err := d.writer.Write(record); err != nil {
@@ -0,0 +1,300 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Indentation used by the labels databases and templates.
+const documentIndent = 2
+
+// Document is a labels database or template which can be edited without
+// losing its comments, block scalars or key order.
+type Document struct {
+	// Lines before the content, up to and including the document start
+	// marker ("---").
+	header []string
+
+	root *yaml.Node
+
+	// Mapping keys preceded by a blank line.
+	blankKeys map[*yaml.Node]bool
+
+	// Sequences whose items are separated by blank lines.
+	blankItems map[*yaml.Node]bool
+}
+
+// commentLines returns the number of lines in the comment.
+func commentLines(comment string) int {
+	if comment == "" {
+		return 0
+	}
+
+	return strings.Count(comment, "\n") + 1
+}
+
+// startLine returns the line number of the first line of the node,
+// including its comments.
+func startLine(node *yaml.Node) int {
+	line := node.Line - commentLines(node.HeadComment)
+
+	if node.Kind == yaml.MappingNode && len(node.Content) > 0 {
+		key := node.Content[0]
+
+		if keyLine := key.Line - commentLines(key.HeadComment); keyLine < line {
+			line = keyLine
+		}
+	}
+
+	return line
+}
+
+// documentStart returns the number of lines before the content up to and
+// including the document start marker, or zero if there is no marker.
+func documentStart(lines []string) int {
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+
+		if line == "---" {
+			return i + 1
+		}
+
+		if line != "" && !strings.HasPrefix(line, "#") {
+			break
+		}
+	}
+
+	return 0
+}
+
+// parseDocument parses a labels database or template.
+func parseDocument(data []byte) (*Document, error) {
+	root := &yaml.Node{}
+
+	err := yaml.Unmarshal(data, root)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.Kind != yaml.DocumentNode || len(root.Content) != 1 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("labels database must be a mapping")
+	}
+
+	// Check the structure
+	lf := LabelsFile{}
+
+	err = root.Decode(&lf)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{
+		root:       root,
+		blankKeys:  make(map[*yaml.Node]bool),
+		blankItems: make(map[*yaml.Node]bool),
+	}
+
+	lines := strings.Split(string(data), "\n")
+
+	if start := documentStart(lines); start > 0 {
+		doc.header = lines[:start]
+
+		// The parser attaches the comments before the marker to the
+		// first key, so only keep those after it.
+		mapping := root.Content[0]
+
+		root.HeadComment = ""
+		mapping.HeadComment = ""
+
+		if len(mapping.Content) > 0 {
+			key := mapping.Content[0]
+
+			comments := strings.TrimSpace(strings.Join(lines[start:key.Line-1], "\n"))
+
+			key.HeadComment = comments
+		}
+	}
+
+	// isBlank determines if the line before the node is blank
+	isBlank := func(node *yaml.Node) bool {
+		line := startLine(node) - 1
+
+		return line >= 1 && line <= len(lines) && strings.TrimSpace(lines[line-1]) == ""
+	}
+
+	var walk func(node *yaml.Node)
+
+	walk = func(node *yaml.Node) {
+		switch node.Kind {
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if i > 0 && isBlank(node.Content[i]) {
+					doc.blankKeys[node.Content[i]] = true
+				}
+			}
+
+		case yaml.SequenceNode:
+			for i, item := range node.Content {
+				if i > 0 && isBlank(item) {
+					doc.blankItems[node] = true
+				}
+			}
+		}
+
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+
+	walk(root)
+
+	return doc, nil
+}
+
+// readDocument reads a labels database or template.
+func readDocument(file string) (*Document, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDocument(data)
+}
+
+// Bytes returns the document, restoring the blank lines the encoder does not
+// preserve.
+func (d *Document) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(documentIndent)
+
+	err := encoder.Encode(d.root)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the lines of the encoded nodes
+	encoded := &yaml.Node{}
+
+	err = yaml.Unmarshal(buf.Bytes(), encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lines to insert a blank line before
+	blank := make(map[int]bool)
+
+	var walk func(node, encoded *yaml.Node)
+
+	walk = func(node, encoded *yaml.Node) {
+		if len(node.Content) != len(encoded.Content) {
+			return
+		}
+
+		for i, child := range node.Content {
+			switch {
+			case node.Kind == yaml.MappingNode && d.blankKeys[child]:
+				blank[startLine(encoded.Content[i])] = true
+			case node.Kind == yaml.SequenceNode && d.blankItems[node] && i > 0:
+				blank[startLine(encoded.Content[i])] = true
+			}
+
+			walk(child, encoded.Content[i])
+		}
+	}
+
+	walk(d.root, encoded)
+
+	var result []string
+
+	result = append(result, d.header...)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	for i, line := range lines {
+		if blank[i+1] {
+			result = append(result, "")
+		}
+
+		result = append(result, line)
+	}
+
+	return []byte(strings.Join(result, "\n") + "\n"), nil
+}
+
+// WriteFile saves the document to the specified file.
+func (d *Document) WriteFile(file string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(file, data, fileMode)
+}
+
+// mappingValue returns the value of the key in the mapping, or nil if the
+// key does not exist.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// nodeName returns the name of a category or label.
+func nodeName(node *yaml.Node) string {
+	if value := mappingValue(node, "name"); value != nil {
+		return value.Value
+	}
+
+	return ""
+}
+
+// sequence returns the sequence of categories or labels, or nil if the
+// document does not contain any.
+func (d *Document) sequence(key string) *yaml.Node {
+	node := mappingValue(d.root.Content[0], key)
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	return node
+}
+
+// Sort orders the categories and labels by name. Comments move with the
+// category or label they precede.
+func (d *Document) Sort() {
+	for _, key := range []string{"categories", "labels"} {
+		seq := d.sequence(key)
+		if seq == nil {
+			continue
+		}
+
+		sort.SliceStable(seq.Content, func(i, j int) bool {
+			return nodeName(seq.Content[i]) < nodeName(seq.Content[j])
+		})
+	}
+}
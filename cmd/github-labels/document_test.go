@@ -0,0 +1,165 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testUnsortedTemplate = `# Header comment.
+#
+
+---
+description: |
+  Labels for testing.
+
+  - A list in a block scalar
+  - Which must be preserved
+
+categories:
+  - name: type
+    description: Type of issue.
+
+  # Comment about the api category.
+  - name: api
+    description: |
+      Change related to an Application Programming
+      Interface.
+
+repo: REPO_SLUG
+
+labels:
+  - name: question
+    description: "Needs	an answer"
+    category: type
+    color: DEFAULT_COLOUR
+
+  - name: bug # Trailing comment.
+    description: Something is broken
+    category: type
+    color: ee0701
+
+  # Comment about the api-change label.
+  - name: api-change
+    description: API change
+    category: api
+    color: DEFAULT_COLOUR
+`
+
+const testSortedTemplate = `# Header comment.
+#
+
+---
+description: |
+  Labels for testing.
+
+  - A list in a block scalar
+  - Which must be preserved
+
+categories:
+  # Comment about the api category.
+  - name: api
+    description: |
+      Change related to an Application Programming
+      Interface.
+
+  - name: type
+    description: Type of issue.
+
+repo: REPO_SLUG
+
+labels:
+  # Comment about the api-change label.
+  - name: api-change
+    description: API change
+    category: api
+    color: DEFAULT_COLOUR
+
+  - name: bug # Trailing comment.
+    description: Something is broken
+    category: type
+    color: ee0701
+
+  - name: question
+    description: "Needs\tan answer"
+    category: type
+    color: DEFAULT_COLOUR
+`
+
+func TestDocumentSort(t *testing.T) {
+	assert := assert.New(t)
+
+	doc, err := parseDocument([]byte(testUnsortedTemplate))
+	assert.NoError(err)
+
+	doc.Sort()
+
+	data, err := doc.Bytes()
+	assert.NoError(err)
+	assert.Equal(testSortedTemplate, string(data))
+
+	// Values are not cleaned (the tab is escaped by the encoder)
+	lf := LabelsFile{}
+
+	err = doc.root.Decode(&lf)
+	assert.NoError(err)
+	assert.Equal("Needs\tan answer", lf.Labels[2].Description)
+
+	// Sorting is idempotent
+	doc, err = parseDocument(data)
+	assert.NoError(err)
+
+	doc.Sort()
+
+	data, err = doc.Bytes()
+	assert.NoError(err)
+	assert.Equal(testSortedTemplate, string(data))
+
+	// Unchanged documents are not modified
+	for _, template := range []string{testRepoTemplate, "labels: []\n"} {
+		doc, err = parseDocument([]byte(template))
+		assert.NoError(err)
+
+		data, err = doc.Bytes()
+		assert.NoError(err)
+		assert.Equal(template, string(data))
+	}
+
+	for _, invalid := range []string{"", "- a\n- b\n", "labels: {\n", "labels: foo\n"} {
+		_, err = parseDocument([]byte(invalid))
+		assert.Error(err, invalid)
+	}
+}
+
+func TestSortYAML(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	// The master template is sorted and keeps its formatting
+	file := filepath.Join(dir, "sorted.yaml")
+
+	err = sortYAML(labelsTemplate, file)
+	assert.NoError(err)
+
+	expected, err := ioutil.ReadFile(labelsTemplate)
+	assert.NoError(err)
+
+	sorted, err := ioutil.ReadFile(file)
+	assert.NoError(err)
+
+	assert.Equal(string(expected), string(sorted))
+
+	err = sortYAML(filepath.Join(dir, "does-not-exist"), file)
+	assert.Error(err)
+}
@@ -0,0 +1,319 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// findItem returns the index of the category or label with the specified
+// name in the sequence, or -1 if it does not exist.
+func findItem(seq *yaml.Node, name string) int {
+	if seq == nil {
+		return -1
+	}
+
+	for i, item := range seq.Content {
+		if nodeName(item) == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// isSorted determines if the items in the sequence are sorted by name.
+func isSorted(seq *yaml.Node) bool {
+	for i := 1; i < len(seq.Content); i++ {
+		if nodeName(seq.Content[i]) < nodeName(seq.Content[i-1]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// insertItem adds the item to the sequence, keeping it sorted by name if it
+// already is.
+func insertItem(seq *yaml.Node, item *yaml.Node) {
+	i := len(seq.Content)
+
+	if isSorted(seq) {
+		for i = 0; i < len(seq.Content); i++ {
+			if nodeName(item) < nodeName(seq.Content[i]) {
+				break
+			}
+		}
+	}
+
+	seq.Content = append(seq.Content, nil)
+	copy(seq.Content[i+1:], seq.Content[i:])
+	seq.Content[i] = item
+}
+
+// removeItem removes the item at the specified index from the sequence.
+func removeItem(seq *yaml.Node, i int) {
+	seq.Content = append(seq.Content[:i], seq.Content[i+1:]...)
+}
+
+// setMappingValue sets the value of the key in the mapping, adding the key
+// if it does not exist.
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	if node := mappingValue(mapping, key); node != nil {
+		node.SetString(value)
+		return
+	}
+
+	keyNode := &yaml.Node{}
+	keyNode.SetString(key)
+
+	valueNode := &yaml.Node{}
+	valueNode.SetString(value)
+
+	mapping.Content = append(mapping.Content, keyNode, valueNode)
+}
+
+// removeMappingValue removes the specified key and its value from the
+// mapping.
+func removeMappingValue(mapping *yaml.Node, key string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// sequenceForUpdate returns the sequence of categories or labels, creating
+// it if the document does not contain any.
+func (d *Document) sequenceForUpdate(key string) (*yaml.Node, error) {
+	mapping := d.root.Content[0]
+
+	node := mappingValue(mapping, key)
+
+	switch {
+	case node == nil:
+		keyNode := &yaml.Node{}
+		keyNode.SetString(key)
+
+		node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+
+		mapping.Content = append(mapping.Content, keyNode, node)
+	case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+		// An empty value
+		node.Kind = yaml.SequenceNode
+		node.Tag = "!!seq"
+		node.Value = ""
+	case node.Kind != yaml.SequenceNode:
+		return nil, fmt.Errorf("%s must be a list", key)
+	}
+
+	return node, nil
+}
+
+// labelsUsingCategory returns the names of the labels in the category.
+func (d *Document) labelsUsingCategory(category string) []string {
+	var names []string
+
+	seq := d.sequence("labels")
+	if seq == nil {
+		return nil
+	}
+
+	for _, item := range seq.Content {
+		if value := mappingValue(item, "category"); value != nil && value.Value == category {
+			names = append(names, nodeName(item))
+		}
+	}
+
+	return names
+}
+
+// AddLabel adds a new label.
+func (d *Document) AddLabel(l Label) error {
+	seq, err := d.sequenceForUpdate("labels")
+	if err != nil {
+		return err
+	}
+
+	if findItem(seq, l.Name) >= 0 {
+		return fmt.Errorf("label %q already exists", l.Name)
+	}
+
+	item := &yaml.Node{}
+
+	err = item.Encode(l)
+	if err != nil {
+		return err
+	}
+
+	insertItem(seq, item)
+
+	return nil
+}
+
+// RemoveLabel removes an existing label.
+func (d *Document) RemoveLabel(name string) error {
+	seq := d.sequence("labels")
+
+	i := findItem(seq, name)
+	if i < 0 {
+		return fmt.Errorf("label %q does not exist", name)
+	}
+
+	removeItem(seq, i)
+
+	return nil
+}
+
+// RenameLabel renames an existing label, recording the old name as the
+// label's "From" value so the label in the repository is renamed rather
+// than replaced. If the label already has a "From" value (since it has
+// already been renamed), it is kept since it is still the name of the label
+// in the repository.
+func (d *Document) RenameLabel(oldName, newName string) error {
+	seq := d.sequence("labels")
+
+	i := findItem(seq, oldName)
+	if i < 0 {
+		return fmt.Errorf("label %q does not exist", oldName)
+	}
+
+	if findItem(seq, newName) >= 0 {
+		return fmt.Errorf("label %q already exists", newName)
+	}
+
+	item := seq.Content[i]
+
+	setMappingValue(item, "name", newName)
+
+	from := mappingValue(item, "from")
+
+	switch {
+	case from == nil || from.Value == "":
+		setMappingValue(item, "from", oldName)
+	case from.Value == newName:
+		// Renamed back to the name in the repository
+		removeMappingValue(item, "from")
+	}
+
+	removeItem(seq, i)
+	insertItem(seq, item)
+
+	return nil
+}
+
+// AddCategory adds a new category.
+func (d *Document) AddCategory(c Category) error {
+	seq, err := d.sequenceForUpdate("categories")
+	if err != nil {
+		return err
+	}
+
+	if findItem(seq, c.Name) >= 0 {
+		return fmt.Errorf("category %q already exists", c.Name)
+	}
+
+	item := &yaml.Node{}
+
+	err = item.Encode(c)
+	if err != nil {
+		return err
+	}
+
+	insertItem(seq, item)
+
+	return nil
+}
+
+// RemoveCategory removes an existing category which is not used by any
+// labels.
+func (d *Document) RemoveCategory(name string) error {
+	seq := d.sequence("categories")
+
+	i := findItem(seq, name)
+	if i < 0 {
+		return fmt.Errorf("category %q does not exist", name)
+	}
+
+	if labels := d.labelsUsingCategory(name); len(labels) > 0 {
+		return fmt.Errorf("category %q is used by labels: %s", name, strings.Join(labels, ", "))
+	}
+
+	removeItem(seq, i)
+
+	return nil
+}
+
+// Check checks the categories and labels in the document, which may be a
+// template. A category which is not used by any labels is permitted so that
+// a category can be added before its labels ("check" and "generate" still
+// require every category to be used).
+func (d *Document) Check() error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// The repository is not checked, so only the colour needs expanding
+	expanded := bytes.Replace(data, []byte(defaultColourVariable), []byte(defaultColour), -1)
+
+	lf, err := parseYAML(expanded)
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+
+	for _, l := range lf.Labels {
+		used[l.CategoryName] = true
+	}
+
+	p := &Problems{}
+
+	var categories Categories
+
+	for _, c := range lf.Categories {
+		if used[c.Name] {
+			categories = append(categories, c)
+		} else {
+			checkCategory(p, c)
+		}
+	}
+
+	if err := p.Err(); err != nil {
+		return err
+	}
+
+	lf.Categories = categories
+
+	return checkLabelsAndCategories(lf)
+}
+
+// editYAML applies the edit to the labels database or template and saves
+// it if the result passes the checks.
+func editYAML(file string, edit func(doc *Document) error) error {
+	doc, err := readDocument(file)
+	if err != nil {
+		return err
+	}
+
+	err = edit(doc)
+	if err != nil {
+		return err
+	}
+
+	err = doc.Check()
+	if err != nil {
+		return fmt.Errorf("not updating %v: %v", file, err)
+	}
+
+	return doc.WriteFile(file)
+}
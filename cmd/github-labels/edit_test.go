@@ -0,0 +1,221 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDocument(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		edit       func(doc *Document) error
+		expectFail bool
+
+		// Names of the labels (or the categories if categories is
+		// set) after the edit.
+		expectedNames []string
+
+		// Part of the document after the edit.
+		expected string
+
+		categories bool
+	}
+
+	newLabel := Label{Name: "needs-review", Description: "Needs a review", CategoryName: "type", Colour: defaultColourVariable, Keywords: []string{"review"}}
+	newCategory := Category{Name: "help", Description: "Help wanted.", URL: "https://example.com/help"}
+
+	data := []testData{
+		// Added in order, separated by blank lines like the others
+		{func(doc *Document) error { return doc.AddLabel(newLabel) }, false,
+			[]string{"api-change", "bug", "needs-review", "question"},
+			"    color: ee0701\n\n  - name: needs-review\n    description: Needs a review\n    category: type\n    color: DEFAULT_COLOUR\n    keywords:\n      - review\n\n  - name: question\n", false},
+		{func(doc *Document) error { return doc.AddLabel(Label{Name: "bug"}) }, true, nil, "", false},
+
+		{func(doc *Document) error { return doc.RemoveLabel("bug") }, false,
+			[]string{"api-change", "question"},
+			"    color: DEFAULT_COLOUR\n\n  - name: question\n", false},
+		{func(doc *Document) error { return doc.RemoveLabel("does-not-exist") }, true, nil, "", false},
+
+		// The old name is recorded and comments move with the label
+		{func(doc *Document) error { return doc.RenameLabel("api-change", "api-update") }, false,
+			[]string{"api-update", "bug", "question"},
+			"  # Comment about the api-change label.\n  - name: api-update\n    description: API change\n    category: api\n    color: DEFAULT_COLOUR\n    from: api-change\n", false},
+		{func(doc *Document) error { return doc.RenameLabel("bug", "defect") }, false,
+			[]string{"api-change", "defect", "question"},
+			"  - name: defect # Trailing comment.\n    description: Something is broken\n    category: type\n    color: ee0701\n    from: bug\n\n  - name: question\n", false},
+		// The name in the repository is kept when renamed again
+		{func(doc *Document) error {
+			if err := doc.RenameLabel("bug", "defect"); err != nil {
+				return err
+			}
+
+			return doc.RenameLabel("defect", "kind/bug")
+		}, false,
+			[]string{"api-change", "kind/bug", "question"},
+			"  - name: kind/bug # Trailing comment.\n    description: Something is broken\n    category: type\n    color: ee0701\n    from: bug\n\n  - name: question\n", false},
+		{func(doc *Document) error {
+			if err := doc.RenameLabel("bug", "defect"); err != nil {
+				return err
+			}
+
+			return doc.RenameLabel("defect", "bug")
+		}, false,
+			[]string{"api-change", "bug", "question"},
+			"  - name: bug # Trailing comment.\n    description: Something is broken\n    category: type\n    color: ee0701\n\n  - name: question\n", false},
+		{func(doc *Document) error { return doc.RenameLabel("bug", "question") }, true, nil, "", false},
+		{func(doc *Document) error { return doc.RenameLabel("does-not-exist", "foo") }, true, nil, "", false},
+
+		{func(doc *Document) error { return doc.AddCategory(newCategory) }, false,
+			[]string{"api", "help", "type"},
+			"      Interface.\n\n  - name: help\n    description: Help wanted.\n    url: https://example.com/help\n\n  - name: type\n", true},
+		{func(doc *Document) error { return doc.AddCategory(Category{Name: "api"}) }, true, nil, "", false},
+
+		{func(doc *Document) error { return doc.RemoveCategory("type") }, true, nil, "", false},
+		{func(doc *Document) error { return doc.RemoveCategory("does-not-exist") }, true, nil, "", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]", i)
+
+		doc, err := parseDocument([]byte(testSortedTemplate))
+		assert.NoError(err, msg)
+
+		err = d.edit(doc)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.NoError(doc.Check(), msg)
+
+		bytes, err := doc.Bytes()
+		assert.NoError(err, msg)
+
+		output := string(bytes)
+
+		assert.True(strings.Contains(output, d.expected), "%s: %s", msg, output)
+
+		lf, err := parseYAML(bytes)
+		assert.NoError(err, msg)
+
+		var names []string
+
+		if d.categories {
+			for _, c := range lf.Categories {
+				names = append(names, c.Name)
+			}
+		} else {
+			for _, l := range lf.Labels {
+				names = append(names, l.Name)
+			}
+		}
+
+		assert.Equal(d.expectedNames, names, msg)
+
+		// The rest of the document is unchanged
+		assert.True(strings.HasPrefix(output, "# Header comment.\n#\n\n---\ndescription: |\n"), "%s: %s", msg, output)
+	}
+
+	// A category can be removed once it is no longer used
+	doc, err := parseDocument([]byte(testSortedTemplate))
+	assert.NoError(err)
+
+	err = doc.RemoveCategory("api")
+	if assert.Error(err) {
+		assert.Equal(`category "api" is used by labels: api-change`, err.Error())
+	}
+
+	assert.NoError(doc.RemoveLabel("api-change"))
+	assert.NoError(doc.RemoveCategory("api"))
+	assert.NoError(doc.Check())
+
+	// Labels and categories can be added to an empty document
+	doc, err = parseDocument([]byte("description: Empty.\nlabels:\n"))
+	assert.NoError(err)
+
+	assert.NoError(doc.AddCategory(Category{Name: "type", Description: "Type of issue."}))
+	assert.NoError(doc.AddLabel(newLabel))
+	assert.NoError(doc.Check())
+}
+
+func TestEditYAML(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, labelsTemplate)
+
+	err = ioutil.WriteFile(file, []byte(testSortedTemplate), fileMode)
+	assert.NoError(err)
+
+	type testData struct {
+		edit          func(doc *Document) error
+		expectedError string
+	}
+
+	data := []testData{
+		// Problems found by the checks
+		{func(doc *Document) error {
+			return doc.AddLabel(Label{Name: "Invalid", Description: "invalid", CategoryName: "type", Colour: "fff"})
+		}, "found 3 problems"},
+		{func(doc *Document) error {
+			return doc.AddLabel(Label{Name: "feature", Description: "New functionality", CategoryName: "does-not-exist", Colour: "84b6eb"})
+		}, "invalid category does-not-exist"},
+		{func(doc *Document) error { return doc.RenameLabel("question", "bug") }, `label "bug" already exists`},
+		{func(doc *Document) error {
+			return doc.AddCategory(Category{Name: "help", Description: "help wanted"})
+		}, "category description"},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]", i)
+
+		err = editYAML(file, d.edit)
+		if assert.Error(err, msg) {
+			assert.True(strings.Contains(err.Error(), d.expectedError), "%s: %v", msg, err)
+		}
+
+		// The file is not modified
+		bytes, err := ioutil.ReadFile(file)
+		assert.NoError(err, msg)
+		assert.Equal(testSortedTemplate, string(bytes), msg)
+	}
+
+	err = editYAML(file, func(doc *Document) error {
+		return doc.RenameLabel("question", "needs-answer")
+	})
+	assert.NoError(err)
+
+	lf, err := readTemplate(file, testRepo)
+	assert.NoError(err)
+	assert.Equal(Label{
+		Name:         "needs-answer",
+		Description:  "Needs\\tan answer",
+		CategoryName: "type",
+		Colour:       defaultColour,
+		From:         "question",
+	}, lf.Labels[2])
+
+	// The master template passes the checks
+	master, err := readDocument(labelsTemplate)
+	assert.NoError(err)
+	assert.NoError(master.Check())
+
+	err = editYAML(filepath.Join(dir, "does-not-exist"), func(doc *Document) error { return nil })
+	assert.Error(err)
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Header written to the start of the exported configuration files.
+const exportHeader = `# Generated from the labels database for %s by kata-github-labels.
+# Do not edit: update the labels database and run "kata-github-labels export".
+---
+`
+
+// Prefixes of the labels the Prow label plugin manages with dedicated
+// commands (for example "/kind bug"). Any other label can only be applied
+// using "/label" if it is listed in the plugin's additional labels.
+var prowLabelPrefixes = []string{
+	"area/",
+	"committee/",
+	"kind/",
+	"language/",
+	"priority/",
+	"sig/",
+	"triage/",
+	"wg/",
+}
+
+// ProwLabelConfig is the Prow label plugin configuration.
+type ProwLabelConfig struct {
+	Label struct {
+		AdditionalLabels []string `yaml:"additional_labels"`
+	}
+}
+
+// formatExport returns the configuration with the generated file header.
+func formatExport(lf *LabelsFile, config interface{}) ([]byte, error) {
+	bytes, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	header := fmt.Sprintf(exportHeader, lf.Repo)
+
+	return append([]byte(header), bytes...), nil
+}
+
+// formatLabelerConfig returns the actions/labeler (v5) configuration which
+// applies each label with paths to the PRs changing files matching them.
+func formatLabelerConfig(lf *LabelsFile) ([]byte, error) {
+	config := yaml.MapSlice{}
+
+	for _, l := range lf.Labels {
+		if len(l.Paths) == 0 {
+			continue
+		}
+
+		match := yaml.MapSlice{
+			{Key: "any-glob-to-any-file", Value: l.Paths},
+		}
+
+		config = append(config, yaml.MapItem{
+			Key: l.Name,
+			Value: []interface{}{
+				yaml.MapSlice{
+					{Key: "changed-files", Value: []interface{}{match}},
+				},
+			},
+		})
+	}
+
+	return formatExport(lf, config)
+}
+
+// caseInsensitive returns a regular expression matching the text exactly,
+// ignoring case. Character classes are used rather than a "(?i)" flag since
+// JavaScript regular expressions do not support inline flags.
+func caseInsensitive(text string) string {
+	var pattern strings.Builder
+
+	for _, r := range text {
+		lower := unicode.ToLower(r)
+		upper := unicode.ToUpper(r)
+
+		if lower == upper {
+			pattern.WriteString(regexp.QuoteMeta(string(r)))
+		} else {
+			fmt.Fprintf(&pattern, "[%c%c]", upper, lower)
+		}
+	}
+
+	return pattern.String()
+}
+
+// keywordPattern returns a regular expression (compatible with both Go and
+// JavaScript) matching any of the keywords as separate words, ignoring case.
+func keywordPattern(keywords []string) string {
+	var alternatives []string
+
+	for _, keyword := range keywords {
+		alternatives = append(alternatives, caseInsensitive(keyword))
+	}
+
+	return fmt.Sprintf(`(^|\W)(%s)(\W|$)`, strings.Join(alternatives, "|"))
+}
+
+// formatIssueLabelerConfig returns the github/issue-labeler configuration
+// which applies each label with keywords to the issues whose title (or
+// body, depending on the workflow) contains one of them.
+func formatIssueLabelerConfig(lf *LabelsFile) ([]byte, error) {
+	config := yaml.MapSlice{}
+
+	for _, l := range lf.Labels {
+		if len(l.Keywords) == 0 {
+			continue
+		}
+
+		config = append(config, yaml.MapItem{
+			Key:   l.Name,
+			Value: []string{keywordPattern(l.Keywords)},
+		})
+	}
+
+	return formatExport(lf, config)
+}
+
+// isProwLabel determines if the Prow label plugin provides a dedicated
+// command for the label.
+func isProwLabel(name string) bool {
+	for _, prefix := range prowLabelPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatProwConfig returns the Prow label plugin configuration allowing all
+// labels in the database to be applied using commands.
+func formatProwConfig(lf *LabelsFile) ([]byte, error) {
+	config := ProwLabelConfig{}
+
+	config.Label.AdditionalLabels = []string{}
+
+	for _, l := range lf.Labels {
+		if isProwLabel(l.Name) {
+			continue
+		}
+
+		config.Label.AdditionalLabels = append(config.Label.AdditionalLabels, l.Name)
+	}
+
+	return formatExport(lf, config)
+}
+
+func exportLabeler(lf *LabelsFile, file *os.File) error {
+	bytes, err := formatLabelerConfig(lf)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(bytes)
+	return err
+}
+
+func exportIssueLabeler(lf *LabelsFile, file *os.File) error {
+	bytes, err := formatIssueLabelerConfig(lf)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(bytes)
+	return err
+}
+
+func exportProw(lf *LabelsFile, file *os.File) error {
+	bytes, err := formatProwConfig(lf)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(bytes)
+	return err
+}
+
+func exportGuide(lf *LabelsFile, file *os.File) error {
+	return newDisplayMD(file).DisplayGuide(lf)
+}
+
+// exportLabels writes the labels database in the specified format to the
+// output file, or to stdout if no file is specified.
+func exportLabels(lf *LabelsFile, export func(lf *LabelsFile, file *os.File) error, outputFileName string) error {
+	if outputFileName == "" {
+		return export(lf, outputFile)
+	}
+
+	file, err := os.OpenFile(outputFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
+	if err != nil {
+		return err
+	}
+
+	err = export(lf, file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
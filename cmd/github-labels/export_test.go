@@ -0,0 +1,189 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newTestExportLabelsFile() *LabelsFile {
+	lf := newTestLabelsFile(
+		Label{Name: "area/api", Description: "Application Programming Interface", CategoryName: "area", Colour: "ededed", Paths: []string{"virtcontainers/api.go", "**/*.proto"}, Keywords: []string{"api"}},
+		Label{Name: "area/docs", Description: "Documentation", CategoryName: "area", Colour: "ededed", Paths: []string{"docs/**"}},
+		Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ee0701", Keywords: []string{"crash", "panic"}},
+		Label{Name: "kind/cleanup", Description: "Tidy up", CategoryName: "test", Colour: "ededed"},
+		Label{Name: "needs-review", Description: "Needs a review", CategoryName: "test", Colour: "ededed"},
+	)
+
+	lf.Categories = Categories{
+		{Name: "area", Description: "Part of the product affected.", URL: "https://example.com/areas"},
+		{Name: "test", Description: "Test category."},
+	}
+
+	return lf
+}
+
+func TestFormatLabelerConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestExportLabelsFile()
+
+	bytes, err := formatLabelerConfig(lf)
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(string(bytes), "# Generated from the labels database for kata-containers/tests"), string(bytes))
+
+	type match struct {
+		ChangedFiles []map[string][]string `yaml:"changed-files"`
+	}
+
+	var config map[string][]match
+
+	err = yaml.Unmarshal(bytes, &config)
+	assert.NoError(err)
+
+	// Only labels with paths are included
+	assert.Equal(map[string][]match{
+		"area/api": {{ChangedFiles: []map[string][]string{
+			{"any-glob-to-any-file": {"virtcontainers/api.go", "**/*.proto"}},
+		}}},
+		"area/docs": {{ChangedFiles: []map[string][]string{
+			{"any-glob-to-any-file": {"docs/**"}},
+		}}},
+	}, config)
+
+	// Labels are listed in database order
+	assert.True(strings.Index(string(bytes), "area/api:") < strings.Index(string(bytes), "area/docs:"))
+}
+
+func TestKeywordPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	pattern := keywordPattern([]string{"panic", "c++", "out of memory"})
+	assert.Equal(`(^|\W)([Pp][Aa][Nn][Ii][Cc]|[Cc]\+\+|[Oo][Uu][Tt] [Oo][Ff] [Mm][Ee][Mm][Oo][Rr][Yy])(\W|$)`, pattern)
+
+	re, err := regexp.Compile(pattern)
+	assert.NoError(err)
+
+	type testData struct {
+		title         string
+		expectMatched bool
+	}
+
+	data := []testData{
+		{"panic", true},
+		{"Runtime PANIC on start", true},
+		{"shim: panic: nil pointer", true},
+		{"Fix C++ build", true},
+		{"agent runs out of memory", true},
+
+		{"", false},
+		{"panicked", false},
+		{"no-panics", false},
+		{"c+ build", false},
+		{"out of  memory", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		assert.Equal(d.expectMatched, re.MatchString(d.title), msg)
+	}
+}
+
+func TestFormatIssueLabelerConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	bytes, err := formatIssueLabelerConfig(newTestExportLabelsFile())
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(string(bytes), "# Generated from the labels database for kata-containers/tests"), string(bytes))
+
+	var config map[string][]string
+
+	err = yaml.Unmarshal(bytes, &config)
+	assert.NoError(err)
+
+	// Only labels with keywords are included
+	assert.Equal(map[string][]string{
+		"area/api": {keywordPattern([]string{"api"})},
+		"bug":      {keywordPattern([]string{"crash", "panic"})},
+	}, config)
+
+	// Labels are listed in database order
+	assert.True(strings.Index(string(bytes), "area/api:") < strings.Index(string(bytes), "bug:"))
+}
+
+func TestFormatProwConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	bytes, err := formatProwConfig(newTestExportLabelsFile())
+	assert.NoError(err)
+
+	var config ProwLabelConfig
+
+	err = yaml.Unmarshal(bytes, &config)
+	assert.NoError(err)
+
+	// Labels with dedicated commands are not listed
+	assert.Equal([]string{"bug", "needs-review"}, config.Label.AdditionalLabels)
+
+	// An empty list rather than null
+	bytes, err = formatProwConfig(newTestLabelsFile())
+	assert.NoError(err)
+	assert.True(strings.Contains(string(bytes), "additional_labels: []"), string(bytes))
+}
+
+func TestExportGuide(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "LABELS.md")
+
+	err = exportLabels(newTestExportLabelsFile(), exportGuide, file)
+	assert.NoError(err)
+
+	bytes, err := ioutil.ReadFile(file)
+	assert.NoError(err)
+
+	guide := string(bytes)
+
+	expected := []string{
+		"# Labels for kata-containers/tests\n",
+		"## `area`\n\nPart of the product affected.\n\nSee https://example.com/areas.\n",
+		"| `area/api` ",
+		"| PRs changing `virtcontainers/api.go`, `**/*.proto`; titles containing `api` |",
+		"| PRs changing `docs/**` ",
+		"## `test`\n\nTest category.\n\n|",
+		"| titles containing `crash`, `panic` ",
+		"| `needs-review` ",
+	}
+
+	last := -1
+
+	// All sections are present and in order
+	for _, e := range expected {
+		i := strings.Index(guide, e)
+		assert.True(i > last, "%q not found after offset %d:\n%s", e, last, guide)
+		last = i
+	}
+
+	// Labels only appear in their own category
+	assert.Equal(1, strings.Count(guide, "`bug`"), guide)
+
+	err = exportLabels(newTestExportLabelsFile(), exportGuide, filepath.Join(dir, "does-not-exist", "LABELS.md"))
+	assert.Error(err)
+}
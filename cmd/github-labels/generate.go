@@ -0,0 +1,249 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type ConflictRule string
+
+const (
+	// A label or category defined in the repository template replaces
+	// the master definition.
+	conflictOverride ConflictRule = "override"
+
+	// Conflicting definitions are an error.
+	conflictError ConflictRule = "error"
+
+	// The master definition is used.
+	conflictKeepFirst ConflictRule = "keep-first"
+
+	defaultConflictRule = conflictError
+)
+
+const (
+	labelsTemplate = "labels.yaml.in"
+
+	// Repository containing the master labels template.
+	masterTemplateRepo = "github.com/kata-containers/tests/cmd/github-labels"
+
+	// Variables expanded in the templates.
+	repoSlugVariable      = "REPO_SLUG"
+	defaultColourVariable = "DEFAULT_COLOUR"
+
+	// The GitHub labels API requires a colour for each label so
+	// default to a white background.
+	defaultColour = "ffffff"
+)
+
+var conflictRules = []ConflictRule{
+	conflictOverride,
+	conflictError,
+	conflictKeepFirst,
+}
+
+// newConflictRule returns the conflict rule with the specified name.
+func newConflictRule(name string) (ConflictRule, error) {
+	for _, rule := range conflictRules {
+		if string(rule) == name {
+			return rule, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid conflict rule %q (expected one of %v)", name, conflictRules)
+}
+
+// getGOPATH returns the first directory in the GOPATH.
+func getGOPATH() string {
+	return filepath.SplitList(build.Default.GOPATH)[0]
+}
+
+// defaultMasterTemplate returns the path to the master labels template in
+// the GOPATH.
+func defaultMasterTemplate() string {
+	return filepath.Join(getGOPATH(), "src", masterTemplateRepo, labelsTemplate)
+}
+
+// defaultRepoTemplate returns the path to the repository-specific labels
+// template in the GOPATH.
+func defaultRepoTemplate(repo string) string {
+	return filepath.Join(getGOPATH(), "src", repo, labelsTemplate)
+}
+
+// expandTemplate replaces the variables in the template.
+func expandTemplate(template []byte, repoSlug string) []byte {
+	expanded := bytes.Replace(template, []byte(repoSlugVariable), []byte(repoSlug), -1)
+
+	return bytes.Replace(expanded, []byte(defaultColourVariable), []byte(defaultColour), -1)
+}
+
+// readTemplate reads and expands a labels template. The result is not
+// checked since a repository-specific template is not a complete labels
+// database.
+func readTemplate(file, repoSlug string) (*LabelsFile, error) {
+	template, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	lf, err := parseYAML(expandTemplate(template, repoSlug))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %v: %v", file, err)
+	}
+
+	return lf, nil
+}
+
+// mergeLabelsFiles returns a labels database containing the categories and
+// labels from both databases. Identical definitions in both are merged.
+// Otherwise, the rule determines what happens if a category or label is
+// defined in both.
+func mergeLabelsFiles(master, other *LabelsFile, rule ConflictRule) (*LabelsFile, error) {
+	merged := &LabelsFile{
+		Description: master.Description,
+		Repo:        master.Repo,
+	}
+
+	if merged.Description == "" {
+		merged.Description = other.Description
+	}
+
+	if merged.Repo == "" {
+		merged.Repo = other.Repo
+	}
+
+	// Key: category name
+	// Value: index in merged categories
+	categories := make(map[string]int)
+
+	for _, lf := range []*LabelsFile{master, other} {
+		for _, c := range lf.Categories {
+			i, ok := categories[c.Name]
+			if !ok {
+				categories[c.Name] = len(merged.Categories)
+				merged.Categories = append(merged.Categories, c)
+				continue
+			}
+
+			if merged.Categories[i] == c {
+				continue
+			}
+
+			switch rule {
+			case conflictOverride:
+				merged.Categories[i] = c
+			case conflictError:
+				return nil, fmt.Errorf("conflicting definitions of category %q: %+v and %+v",
+					c.Name, merged.Categories[i], c)
+			}
+		}
+	}
+
+	// Key: label name
+	// Value: index in merged labels
+	labels := make(map[string]int)
+
+	for _, lf := range []*LabelsFile{master, other} {
+		for _, l := range lf.Labels {
+			i, ok := labels[l.Name]
+			if !ok {
+				labels[l.Name] = len(merged.Labels)
+				merged.Labels = append(merged.Labels, l)
+				continue
+			}
+
+			if reflect.DeepEqual(merged.Labels[i], l) {
+				continue
+			}
+
+			switch rule {
+			case conflictOverride:
+				merged.Labels[i] = l
+			case conflictError:
+				return nil, fmt.Errorf("conflicting definitions of label %q: %+v and %+v",
+					l.Name, merged.Labels[i], l)
+			}
+		}
+	}
+
+	sort.Sort(merged.Labels)
+	sort.Sort(merged.Categories)
+
+	return merged, nil
+}
+
+// generateLabels creates the combined labels database for the repository
+// from the master template and the (optional) repository-specific template,
+// and returns the problems found with it.
+func generateLabels(repo, masterTemplate, repoTemplate string, rule ConflictRule) (*LabelsFile, *Problems, error) {
+	repoSlug := strings.Trim(strings.TrimPrefix(repo, "github.com/"), "/")
+
+	lf, err := readTemplate(masterTemplate, repoSlug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if repoTemplate != "" {
+		repoLabels, err := readTemplate(repoTemplate, repoSlug)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		lf, err = mergeLabelsFiles(lf, repoLabels, rule)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return lf, checkAll(lf), nil
+}
+
+// generateYAML creates the combined labels database for the repository,
+// checks it and writes it to the output file.
+func generateYAML(repo, masterTemplate, repoTemplate, outputFile string, rule ConflictRule) error {
+	lf, problems, err := generateLabels(repo, masterTemplate, repoTemplate, rule)
+	if err != nil {
+		return err
+	}
+
+	displayWarnings(problems)
+
+	err = problems.Err()
+	if err != nil {
+		return fmt.Errorf("generated labels database is invalid: %v", err)
+	}
+
+	return writeYAML(lf, outputFile)
+}
+
+// findRepoTemplate returns the repository-specific template to use. If
+// no template is specified, the default is used if it exists.
+func findRepoTemplate(repo, template string) (string, error) {
+	if template != "" {
+		return template, nil
+	}
+
+	template = defaultRepoTemplate(repo)
+
+	if _, err := os.Stat(template); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	return template, nil
+}
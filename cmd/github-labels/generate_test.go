@@ -0,0 +1,206 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRepoTemplate = `---
+description: Repository-specific labels.
+repo: REPO_SLUG
+
+categories:
+  - name: repo
+    description: Repository-specific category.
+
+labels:
+  - name: repo-label
+    description: |
+      A label only used by
+      REPO_SLUG.
+    category: repo
+    color: DEFAULT_COLOUR
+`
+
+func TestNewConflictRule(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{"override", "error", "keep-first"} {
+		rule, err := newConflictRule(name)
+		assert.NoError(err)
+		assert.Equal(name, string(rule))
+	}
+
+	for _, name := range []string{"", "Override", "merge"} {
+		_, err := newConflictRule(name)
+		assert.Error(err, name)
+	}
+}
+
+func TestExpandTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	expanded := expandTemplate([]byte("repo: REPO_SLUG\ncolor: DEFAULT_COLOUR\nurl: https://github.com/REPO_SLUG\n"), "org/repo")
+	assert.Equal("repo: org/repo\ncolor: ffffff\nurl: https://github.com/org/repo\n", string(expanded))
+}
+
+func TestMergeLabelsFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	category := Category{Name: "test", Description: "Test category."}
+	label := Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ee0701"}
+
+	changedCategory := category
+	changedCategory.URL = "https://example.com"
+
+	changedLabel := label
+	changedLabel.Colour = "d73a4a"
+
+	master := &LabelsFile{
+		Description: "Master labels.",
+		Repo:        testRepo,
+		Categories:  Categories{category},
+		Labels:      Labels{label},
+	}
+
+	type testData struct {
+		other              *LabelsFile
+		rule               ConflictRule
+		expectFail         bool
+		expectedCategories Categories
+		expectedLabels     Labels
+	}
+
+	extraLabel := Label{Name: "api", Description: "API change", CategoryName: "test", Colour: "ffffff"}
+
+	data := []testData{
+		{&LabelsFile{}, conflictError, false, Categories{category}, Labels{label}},
+
+		// Identical definitions are not conflicts
+		{&LabelsFile{Categories: Categories{category}, Labels: Labels{label, extraLabel}}, conflictError, false,
+			Categories{category}, Labels{extraLabel, label}},
+
+		{&LabelsFile{Labels: Labels{changedLabel}}, conflictError, true, nil, nil},
+		{&LabelsFile{Categories: Categories{changedCategory}}, conflictError, true, nil, nil},
+
+		{&LabelsFile{Categories: Categories{changedCategory}, Labels: Labels{changedLabel}}, conflictOverride, false,
+			Categories{changedCategory}, Labels{changedLabel}},
+		{&LabelsFile{Categories: Categories{changedCategory}, Labels: Labels{changedLabel}}, conflictKeepFirst, false,
+			Categories{category}, Labels{label}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		merged, err := mergeLabelsFiles(master, d.other, d.rule)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(master.Description, merged.Description, msg)
+		assert.Equal(master.Repo, merged.Repo, msg)
+		assert.Equal(d.expectedCategories, merged.Categories, msg)
+		assert.Equal(d.expectedLabels, merged.Labels, msg)
+	}
+
+	// The master is not modified
+	assert.Equal(Labels{label}, master.Labels)
+}
+
+func TestGenerateYAML(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	repoTemplate := filepath.Join(dir, labelsTemplate)
+
+	err = ioutil.WriteFile(repoTemplate, []byte(testRepoTemplate), fileMode)
+	assert.NoError(err)
+
+	master, err := readTemplate(labelsTemplate, testRepo)
+	assert.NoError(err)
+
+	// The master template alone is a complete database
+	file := filepath.Join(dir, "master.yaml")
+
+	err = generateYAML("github.com/"+testRepo, labelsTemplate, "", file, conflictError)
+	assert.NoError(err)
+
+	lf, err := readYAML(file)
+	assert.NoError(err)
+	assert.Equal(master, lf)
+	assert.Equal(testRepo, lf.Repo)
+
+	file = filepath.Join(dir, "combined.yaml")
+
+	err = generateYAML(testRepo, labelsTemplate, repoTemplate, file, conflictError)
+	assert.NoError(err)
+
+	lf, err = readYAML(file)
+	assert.NoError(err)
+	assert.Equal(master.Description, lf.Description)
+	assert.Len(lf.Labels, len(master.Labels)+1)
+	assert.Len(lf.Categories, len(master.Categories)+1)
+
+	labels, err := getLabelsByCategory("repo", lf)
+	assert.NoError(err)
+	assert.Equal([]Label{{
+		Name:         "repo-label",
+		Description:  "A label only used by " + testRepo + ".",
+		CategoryName: "repo",
+		Colour:       defaultColour,
+	}}, labels)
+
+	// A conflict with the master template
+	conflict := testRepoTemplate + fmt.Sprintf(`
+  - name: %s
+    description: Conflicting definition.
+    category: repo
+    color: 000000
+`, master.Labels[0].Name)
+
+	err = ioutil.WriteFile(repoTemplate, []byte(conflict), fileMode)
+	assert.NoError(err)
+
+	err = generateYAML(testRepo, labelsTemplate, repoTemplate, file, conflictError)
+	assert.Error(err)
+
+	err = generateYAML(testRepo, labelsTemplate, repoTemplate, file, conflictKeepFirst)
+	assert.NoError(err)
+
+	// The result must pass the checks
+	invalid := strings.Replace(testRepoTemplate, "category: repo", "category: does-not-exist", 1)
+
+	err = ioutil.WriteFile(repoTemplate, []byte(invalid), fileMode)
+	assert.NoError(err)
+
+	err = generateYAML(testRepo, labelsTemplate, repoTemplate, file, conflictError)
+	assert.Error(err)
+
+	err = generateYAML(testRepo, labelsTemplate, filepath.Join(dir, "does-not-exist"), file, conflictError)
+	assert.Error(err)
+
+	// Only an existing default repository template is used
+	template, err := findRepoTemplate("github.com/kata-containers/does-not-exist", "")
+	assert.NoError(err)
+	assert.Empty(template)
+
+	template, err = findRepoTemplate(testRepo, repoTemplate)
+	assert.NoError(err)
+	assert.Equal(repoTemplate, template)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	defaultGiteaAPIURL = "https://gitea.com/api/v1"
+
+	// Environment variable containing the token used to authenticate
+	// with Gitea.
+	giteaTokenEnvVar = "GITEA_TOKEN"
+
+	// Maximum number of items Gitea returns per page by default.
+	giteaPageSize = 50
+)
+
+// giteaLabel is the part of a Gitea REST API label object used. Depending
+// on the version, the colour may start with "#".
+type giteaLabel struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Colour      string `json:"color"`
+	Description string `json:"description"`
+}
+
+// giteaLabelUpdate is the body of a request to create or update a label.
+type giteaLabelUpdate struct {
+	Name        string `json:"name"`
+	Colour      string `json:"color"`
+	Description string `json:"description"`
+}
+
+// giteaClient uses the Gitea REST API to manage repository labels.
+type giteaClient struct {
+	restClient
+}
+
+// newGiteaClient creates a client for the Gitea instance with the
+// specified API URL. If token is not blank, it is used to authenticate.
+func newGiteaClient(apiURL, token string) *giteaClient {
+	if apiURL == "" {
+		apiURL = defaultGiteaAPIURL
+	}
+
+	headers := http.Header{}
+	headers.Set("Accept", "application/json")
+
+	if token != "" {
+		headers.Set("Authorization", "token "+token)
+	}
+
+	return &giteaClient{
+		restClient: newRESTClient(apiURL, headers),
+	}
+}
+
+// RepoSlug returns the "owner/repo" form of the specified repository, which
+// may also be prefixed by the host name.
+func (g *giteaClient) RepoSlug(repo string) (string, error) {
+	return ownerRepoSlug("Gitea", g.host(), repo)
+}
+
+func (g *giteaClient) labelsURL(repo string) string {
+	return fmt.Sprintf("%s/repos/%s/labels", g.apiURL, repo)
+}
+
+// listLabels returns all the labels in the specified repository.
+func (g *giteaClient) listLabels(repo string) ([]giteaLabel, error) {
+	var labels []giteaLabel
+	var page []giteaLabel
+
+	url := fmt.Sprintf("%s?limit=%d", g.labelsURL(repo), giteaPageSize)
+
+	err := g.getPages(url, &page, func() {
+		labels = append(labels, page...)
+		page = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+// labelURL returns the URL of the label called name. Gitea identifies
+// labels by their ID rather than their name, so the labels are listed to
+// find it.
+func (g *giteaClient) labelURL(repo, name string) (string, error) {
+	labels, err := g.listLabels(repo)
+	if err != nil {
+		return "", err
+	}
+
+	for _, l := range labels {
+		if l.Name == name {
+			return fmt.Sprintf("%s/%d", g.labelsURL(repo), l.ID), nil
+		}
+	}
+
+	return "", fmt.Errorf("label %q does not exist in %s", name, repo)
+}
+
+// ListLabels returns all the labels in the specified repository.
+func (g *giteaClient) ListLabels(repo string) ([]RemoteLabel, error) {
+	labels, err := g.listLabels(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []RemoteLabel
+
+	for _, l := range labels {
+		result = append(result, RemoteLabel{
+			Name:        l.Name,
+			Colour:      normaliseColour(l.Colour),
+			Description: l.Description,
+		})
+	}
+
+	return result, nil
+}
+
+// CreateLabel adds a new label to the specified repository.
+func (g *giteaClient) CreateLabel(repo string, label RemoteLabel) error {
+	_, err := g.do(http.MethodPost, g.labelsURL(repo), giteaLabelUpdate{
+		Name:        label.Name,
+		Colour:      "#" + normaliseColour(label.Colour),
+		Description: label.Description,
+	}, nil)
+
+	return err
+}
+
+// UpdateLabel changes the existing label called name in the specified
+// repository to match label. If the names differ, the label is renamed,
+// which retains the issues and pull requests using the label.
+func (g *giteaClient) UpdateLabel(repo, name string, label RemoteLabel) error {
+	url, err := g.labelURL(repo, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.do(http.MethodPatch, url, giteaLabelUpdate{
+		Name:        label.Name,
+		Colour:      "#" + normaliseColour(label.Colour),
+		Description: label.Description,
+	}, nil)
+
+	return err
+}
+
+// DeleteLabel removes the specified label from the repository.
+func (g *giteaClient) DeleteLabel(repo, name string) error {
+	url, err := g.labelURL(repo, name)
+	if err != nil {
+		return err
+	}
+
+	_, err = g.do(http.MethodDelete, url, nil, nil)
+
+	return err
+}
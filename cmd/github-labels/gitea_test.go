@@ -0,0 +1,255 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeGitea is a minimal implementation of the Gitea labels REST API.
+type fakeGitea struct {
+	sync.Mutex
+
+	repo   string
+	token  string
+	labels []giteaLabel
+	nextID int64
+
+	// Requests which modified labels ("METHOD name").
+	changes []string
+}
+
+func newFakeGitea(repo, token string, labels ...RemoteLabel) *fakeGitea {
+	f := &fakeGitea{
+		repo:  repo,
+		token: token,
+	}
+
+	for _, l := range labels {
+		f.add(l.Name, l.Colour, l.Description)
+	}
+
+	return f
+}
+
+func (f *fakeGitea) add(name, colour, description string) giteaLabel {
+	f.nextID++
+
+	label := giteaLabel{
+		ID:          f.nextID,
+		Name:        name,
+		Colour:      strings.TrimPrefix(colour, "#"),
+		Description: description,
+	}
+
+	f.labels = append(f.labels, label)
+
+	return label
+}
+
+func (f *fakeGitea) find(id int64) int {
+	for i, l := range f.labels {
+		if l.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (f *fakeGitea) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	prefix := "/repos/" + f.repo + "/labels"
+
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeFakeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Header.Get("Authorization") != "token "+f.token {
+		writeFakeError(w, http.StatusUnauthorized, "token is required")
+		return
+	}
+
+	var update giteaLabelUpdate
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeFakeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			writeFakePage(w, r, len(f.labels), func(start, end int) {
+				_ = json.NewEncoder(w).Encode(f.labels[start:end])
+			})
+
+		case http.MethodPost:
+			label := f.add(update.Name, update.Colour, update.Description)
+
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(label)
+
+			f.changes = append(f.changes, "POST "+update.Name)
+
+		default:
+			writeFakeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+		return
+	}
+
+	// Labels are identified by their ID
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		writeFakeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	i := f.find(n)
+	if i < 0 {
+		writeFakeError(w, http.StatusNotFound, "label not found")
+		return
+	}
+
+	name := f.labels[i].Name
+
+	switch r.Method {
+	case http.MethodPatch:
+		f.labels[i].Name = update.Name
+		f.labels[i].Colour = strings.TrimPrefix(update.Colour, "#")
+		f.labels[i].Description = update.Description
+
+		_ = json.NewEncoder(w).Encode(f.labels[i])
+
+	case http.MethodDelete:
+		f.labels = append(f.labels[:i], f.labels[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeFakeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	f.changes = append(f.changes, r.Method+" "+name)
+}
+
+func TestGiteaRepoSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		apiURL       string
+		repo         string
+		expectedSlug string
+		expectFail   bool
+	}
+
+	data := []testData{
+		{"", "", "", true},
+		{"", "kata-containers", "", true},
+		{"", "a/b/c", "", true},
+		{"https://git.example.com/api/v1", "gitea.com/kata-containers/tests", "", true},
+
+		{"", "kata-containers/tests", "kata-containers/tests", false},
+		{"", "gitea.com/kata-containers/tests", "kata-containers/tests", false},
+		{"https://git.example.com/api/v1", "git.example.com/kata-containers/tests/", "kata-containers/tests", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		slug, err := newGiteaClient(d.apiURL, "").RepoSlug(d.repo)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedSlug, slug, msg)
+	}
+}
+
+func TestGiteaClient(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := []RemoteLabel{
+		{"bug", "ee0701", "Something is broken"},
+		{"good first issue", "7057ff", ""},
+		{"needs-review", "ededed", "Needs a review"},
+	}
+
+	fake := newFakeGitea(testRepo, testToken, labels...)
+
+	// Older versions of Gitea return colours starting with "#"
+	fake.labels[0].Colour = "#EE0701"
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := newGiteaClient(server.URL+"/", testToken)
+
+	// All pages are returned
+	existing, err := client.ListLabels(testRepo)
+	assert.NoError(err)
+	assert.Equal(labels, existing)
+
+	_, err = client.ListLabels("kata-containers/other")
+	assert.Error(err)
+
+	err = client.CreateLabel(testRepo, RemoteLabel{"feature", "84b6eb", "New functionality"})
+	assert.NoError(err)
+
+	err = client.UpdateLabel(testRepo, "good first issue", RemoteLabel{"good-first-issue", "7057ff", "Suitable for a new contributor"})
+	assert.NoError(err)
+
+	err = client.UpdateLabel(testRepo, "bug", RemoteLabel{"bug", "d73a4a", "Something is broken"})
+	assert.NoError(err)
+
+	err = client.UpdateLabel(testRepo, "does-not-exist", RemoteLabel{"foo", "ffffff", ""})
+	if assert.Error(err) {
+		assert.True(strings.Contains(err.Error(), `label "does-not-exist" does not exist`), err)
+	}
+
+	err = client.DeleteLabel(testRepo, "needs-review")
+	assert.NoError(err)
+
+	existing, err = client.ListLabels(testRepo)
+	assert.NoError(err)
+	assert.Equal([]RemoteLabel{
+		{"bug", "d73a4a", "Something is broken"},
+		{"good-first-issue", "7057ff", "Suitable for a new contributor"},
+		{"feature", "84b6eb", "New functionality"},
+	}, existing)
+
+	assert.Equal([]string{
+		"POST feature",
+		"PATCH good first issue",
+		"PATCH bug",
+		"DELETE needs-review",
+	}, fake.changes)
+
+	// Modifying labels requires authentication
+	err = newGiteaClient(server.URL, "").DeleteLabel(testRepo, "bug")
+	if assert.Error(err) {
+		assert.True(strings.Contains(err.Error(), "token is required"), err)
+	}
+}
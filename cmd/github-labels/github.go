@@ -0,0 +1,169 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	// Environment variable containing the token used to authenticate
+	// with GitHub.
+	githubTokenEnvVar = "GITHUB_TOKEN"
+
+	// Maximum number of items GitHub returns per page.
+	githubPageSize = 100
+)
+
+// RemoteLabel is a label as stored in a repository. The colour is in the
+// form used by GitHub (six hex digits without a leading "#").
+type RemoteLabel struct {
+	Name        string `json:"name" yaml:"name"`
+	Colour      string `json:"color" yaml:"color"`
+	Description string `json:"description" yaml:"description,omitempty"`
+}
+
+// Issue is the part of a GitHub REST API issue (or pull request) object
+// used.
+type Issue struct {
+	Number int    `json:"number"`
+	State  string `json:"state"`
+
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+
+	// Only set for pull requests.
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// githubLabelUpdate is the body of a request to create or update a label.
+type githubLabelUpdate struct {
+	Name        string `json:"name,omitempty"`
+	NewName     string `json:"new_name,omitempty"`
+	Colour      string `json:"color"`
+	Description string `json:"description"`
+}
+
+// githubClient uses the GitHub REST API to manage repository labels.
+type githubClient struct {
+	restClient
+}
+
+// newGitHubClient creates a client for the GitHub instance with the
+// specified API URL. If token is not blank, it is used to authenticate.
+func newGitHubClient(apiURL, token string) *githubClient {
+	if apiURL == "" {
+		apiURL = defaultGitHubAPIURL
+	}
+
+	headers := http.Header{}
+	headers.Set("Accept", "application/vnd.github+json")
+
+	if token != "" {
+		headers.Set("Authorization", "Bearer "+token)
+	}
+
+	return &githubClient{
+		restClient: newRESTClient(apiURL, headers),
+	}
+}
+
+// githubRepoSlug returns the "org/repo" form of the specified repository,
+// which may also be specified as "github.com/org/repo".
+func githubRepoSlug(repo string) (string, error) {
+	return ownerRepoSlug("GitHub", "github.com", repo)
+}
+
+// RepoSlug returns the "org/repo" form of the specified repository.
+func (g *githubClient) RepoSlug(repo string) (string, error) {
+	return githubRepoSlug(repo)
+}
+
+func (g *githubClient) labelsURL(repo string) string {
+	return fmt.Sprintf("%s/repos/%s/labels", g.apiURL, repo)
+}
+
+func (g *githubClient) labelURL(repo, name string) string {
+	return fmt.Sprintf("%s/%s", g.labelsURL(repo), url.PathEscape(name))
+}
+
+// ListLabels returns all the labels in the specified repository.
+func (g *githubClient) ListLabels(repo string) ([]RemoteLabel, error) {
+	var labels []RemoteLabel
+	var page []RemoteLabel
+
+	url := fmt.Sprintf("%s?per_page=%d", g.labelsURL(repo), githubPageSize)
+
+	err := g.getPages(url, &page, func() {
+		labels = append(labels, page...)
+		page = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+// ListIssues returns all the open and closed issues and pull requests in
+// the specified repository.
+func (g *githubClient) ListIssues(repo string) ([]Issue, error) {
+	var issues []Issue
+	var page []Issue
+
+	url := fmt.Sprintf("%s/repos/%s/issues?state=all&per_page=%d", g.apiURL, repo, githubPageSize)
+
+	err := g.getPages(url, &page, func() {
+		issues = append(issues, page...)
+		page = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return issues, nil
+}
+
+// CreateLabel adds a new label to the specified repository.
+func (g *githubClient) CreateLabel(repo string, label RemoteLabel) error {
+	_, err := g.do(http.MethodPost, g.labelsURL(repo), githubLabelUpdate{
+		Name:        label.Name,
+		Colour:      label.Colour,
+		Description: label.Description,
+	}, nil)
+
+	return err
+}
+
+// UpdateLabel changes the existing label called name in the specified
+// repository to match label. If the names differ, the label is renamed,
+// which retains the issues and pull requests using the label.
+func (g *githubClient) UpdateLabel(repo, name string, label RemoteLabel) error {
+	update := githubLabelUpdate{
+		Colour:      label.Colour,
+		Description: label.Description,
+	}
+
+	if label.Name != name {
+		update.NewName = label.Name
+	}
+
+	_, err := g.do(http.MethodPatch, g.labelURL(repo, name), update, nil)
+
+	return err
+}
+
+// DeleteLabel removes the specified label from the repository.
+func (g *githubClient) DeleteLabel(repo, name string) error {
+	_, err := g.do(http.MethodDelete, g.labelURL(repo, name), nil, nil)
+
+	return err
+}
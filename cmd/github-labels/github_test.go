@@ -0,0 +1,285 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRepo  = "kata-containers/tests"
+	testToken = "secret"
+
+	// Number of items the fake servers return per page, to ensure
+	// paging is handled.
+	fakePageSize = 2
+)
+
+// fakeGitHub is a minimal implementation of the GitHub labels and issues
+// REST API.
+type fakeGitHub struct {
+	sync.Mutex
+
+	repo   string
+	token  string
+	labels []RemoteLabel
+	issues []Issue
+
+	// Requests which modified labels ("METHOD name").
+	changes []string
+}
+
+func newFakeGitHub(repo, token string, labels ...RemoteLabel) *fakeGitHub {
+	return &fakeGitHub{
+		repo:   repo,
+		token:  token,
+		labels: labels,
+	}
+}
+
+func (f *fakeGitHub) find(name string) int {
+	for i, l := range f.labels {
+		if strings.EqualFold(l.Name, name) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// writeFakeError writes an error response in the form used by the forges.
+func writeFakeError(w http.ResponseWriter, status int, message string) {
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(restError{Message: message})
+}
+
+// writeFakePage writes the requested page of the specified number of items,
+// setting the link to the next page if there is one. encode writes the
+// items from start up to end.
+func writeFakePage(w http.ResponseWriter, r *http.Request, count int, encode func(start, end int)) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * fakePageSize
+	end := start + fakePageSize
+
+	if end < count {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page+1))
+
+		next := fmt.Sprintf("http://%s%s?%s", r.Host, r.URL.EscapedPath(), query.Encode())
+		w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+	} else {
+		end = count
+	}
+
+	if start > end {
+		start = end
+	}
+
+	encode(start, end)
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	if r.Method == http.MethodGet && r.URL.Path == "/repos/"+f.repo+"/issues" {
+		if r.URL.Query().Get("state") != "all" {
+			writeFakeError(w, http.StatusBadRequest, "state must be all")
+			return
+		}
+
+		writeFakePage(w, r, len(f.issues), func(start, end int) {
+			_ = json.NewEncoder(w).Encode(f.issues[start:end])
+		})
+
+		return
+	}
+
+	prefix := "/repos/" + f.repo + "/labels"
+
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, prefix) {
+		writeFakeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	name, err := url.PathUnescape(strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/"))
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Header.Get("Authorization") != "Bearer "+f.token {
+		writeFakeError(w, http.StatusUnauthorized, "Requires authentication")
+		return
+	}
+
+	var update githubLabelUpdate
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPatch {
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeFakeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	i := f.find(name)
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		writeFakePage(w, r, len(f.labels), func(start, end int) {
+			_ = json.NewEncoder(w).Encode(f.labels[start:end])
+		})
+
+		return
+
+	case r.Method == http.MethodPost && name == "":
+		if f.find(update.Name) >= 0 {
+			writeFakeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+			return
+		}
+
+		label := RemoteLabel{Name: update.Name, Colour: update.Colour, Description: update.Description}
+		f.labels = append(f.labels, label)
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(label)
+
+	case i < 0:
+		writeFakeError(w, http.StatusNotFound, "Not Found")
+		return
+
+	case r.Method == http.MethodPatch:
+		if update.NewName != "" {
+			if j := f.find(update.NewName); j >= 0 && j != i {
+				writeFakeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+				return
+			}
+
+			f.labels[i].Name = update.NewName
+		}
+
+		f.labels[i].Colour = update.Colour
+		f.labels[i].Description = update.Description
+
+		_ = json.NewEncoder(w).Encode(f.labels[i])
+
+	case r.Method == http.MethodDelete:
+		f.labels = append(f.labels[:i], f.labels[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeFakeError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	f.changes = append(f.changes, r.Method+" "+name)
+}
+
+func TestGitHubRepoSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		repo         string
+		expectedSlug string
+		expectFail   bool
+	}
+
+	data := []testData{
+		{"", "", true},
+		{"kata-containers", "", true},
+		{"kata-containers/", "", true},
+		{"a/b/c", "", true},
+
+		{"kata-containers/tests", "kata-containers/tests", false},
+		{"github.com/kata-containers/tests", "kata-containers/tests", false},
+		{"github.com/kata-containers/tests/", "kata-containers/tests", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		slug, err := githubRepoSlug(d.repo)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedSlug, slug, msg)
+	}
+}
+
+func TestGitHubClient(t *testing.T) {
+	assert := assert.New(t)
+
+	labels := []RemoteLabel{
+		{"bug", "ee0701", "Something is broken"},
+		{"good first issue", "7057ff", ""},
+		{"needs-review", "ededed", "Needs a review"},
+	}
+
+	fake := newFakeGitHub(testRepo, testToken, labels...)
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := newGitHubClient(server.URL+"/", testToken)
+
+	// All pages are returned
+	existing, err := client.ListLabels(testRepo)
+	assert.NoError(err)
+	assert.Equal(labels, existing)
+
+	_, err = client.ListLabels("kata-containers/other")
+	assert.Error(err)
+
+	err = client.CreateLabel(testRepo, RemoteLabel{"feature", "84b6eb", "New functionality"})
+	assert.NoError(err)
+
+	err = client.CreateLabel(testRepo, RemoteLabel{"BUG", "ee0701", ""})
+	assert.Error(err, "label exists")
+
+	// Names containing spaces must be escaped
+	err = client.UpdateLabel(testRepo, "good first issue", RemoteLabel{"good-first-issue", "7057ff", "Suitable for a new contributor"})
+	assert.NoError(err)
+
+	err = client.UpdateLabel(testRepo, "bug", RemoteLabel{"bug", "d73a4a", "Something is broken"})
+	assert.NoError(err)
+
+	err = client.UpdateLabel(testRepo, "does-not-exist", RemoteLabel{"foo", "ffffff", ""})
+	assert.Error(err)
+
+	err = client.DeleteLabel(testRepo, "needs-review")
+	assert.NoError(err)
+
+	existing, err = client.ListLabels(testRepo)
+	assert.NoError(err)
+	assert.Equal([]RemoteLabel{
+		{"bug", "d73a4a", "Something is broken"},
+		{"good-first-issue", "7057ff", "Suitable for a new contributor"},
+		{"feature", "84b6eb", "New functionality"},
+	}, existing)
+
+	// Modifying labels requires authentication
+	err = newGitHubClient(server.URL, "").DeleteLabel(testRepo, "bug")
+	assert.Error(err)
+	assert.True(strings.Contains(err.Error(), "Requires authentication"), err)
+}
@@ -0,0 +1,175 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	defaultGitLabAPIURL = "https://gitlab.com/api/v4"
+
+	// Environment variable containing the token used to authenticate
+	// with GitLab.
+	gitlabTokenEnvVar = "GITLAB_TOKEN"
+
+	// Maximum number of items GitLab returns per page.
+	gitlabPageSize = 100
+)
+
+// gitlabLabel is the part of a GitLab REST API label object used. The
+// colour starts with "#".
+type gitlabLabel struct {
+	Name        string `json:"name"`
+	Colour      string `json:"color"`
+	Description string `json:"description"`
+}
+
+// gitlabLabelUpdate is the body of a request to create or update a label.
+type gitlabLabelUpdate struct {
+	Name        string `json:"name,omitempty"`
+	NewName     string `json:"new_name,omitempty"`
+	Colour      string `json:"color"`
+	Description string `json:"description"`
+}
+
+// gitlabClient uses the GitLab REST API to manage project or group labels.
+type gitlabClient struct {
+	restClient
+
+	// If set, the labels of groups are managed rather than those of
+	// projects.
+	group bool
+}
+
+// newGitLabClient creates a client for the GitLab instance with the
+// specified API URL. If token is not blank, it is used to authenticate.
+func newGitLabClient(apiURL, token string, group bool) *gitlabClient {
+	if apiURL == "" {
+		apiURL = defaultGitLabAPIURL
+	}
+
+	headers := http.Header{}
+
+	if token != "" {
+		headers.Set("Private-Token", token)
+	}
+
+	return &gitlabClient{
+		restClient: newRESTClient(apiURL, headers),
+		group:      group,
+	}
+}
+
+// gitlabColour returns the colour in the form used by GitLab.
+func gitlabColour(colour string) string {
+	return "#" + normaliseColour(colour)
+}
+
+// RepoSlug returns the full path of the specified project ("group/project",
+// where the group may contain subgroups) or group, which may also be
+// prefixed by the host name.
+func (g *gitlabClient) RepoSlug(repo string) (string, error) {
+	slug := strings.Trim(repo, "/")
+
+	if host := g.host(); host != "" {
+		slug = strings.Trim(strings.TrimPrefix(slug, host+"/"), "/")
+	}
+
+	fields := strings.Split(slug, "/")
+
+	for _, field := range fields {
+		if field == "" {
+			return "", fmt.Errorf("invalid GitLab path %q", repo)
+		}
+	}
+
+	if !g.group && len(fields) < 2 {
+		return "", fmt.Errorf("invalid GitLab project %q (expected group/project)", repo)
+	}
+
+	return slug, nil
+}
+
+func (g *gitlabClient) labelsURL(repo string) string {
+	kind := "projects"
+	if g.group {
+		kind = "groups"
+	}
+
+	// The path is used as the ID of the project or group
+	return fmt.Sprintf("%s/%s/%s/labels", g.apiURL, kind, url.PathEscape(repo))
+}
+
+func (g *gitlabClient) labelURL(repo, name string) string {
+	return fmt.Sprintf("%s/%s", g.labelsURL(repo), url.PathEscape(name))
+}
+
+// ListLabels returns all the labels in the specified project or group.
+// Labels inherited from the parent groups are not included since they
+// cannot be modified using the project or group.
+func (g *gitlabClient) ListLabels(repo string) ([]RemoteLabel, error) {
+	var labels []RemoteLabel
+	var page []gitlabLabel
+
+	url := fmt.Sprintf("%s?per_page=%d&include_ancestor_groups=false", g.labelsURL(repo), gitlabPageSize)
+
+	err := g.getPages(url, &page, func() {
+		for _, l := range page {
+			labels = append(labels, RemoteLabel{
+				Name:        l.Name,
+				Colour:      normaliseColour(l.Colour),
+				Description: l.Description,
+			})
+		}
+
+		page = nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
+
+// CreateLabel adds a new label to the specified project or group.
+func (g *gitlabClient) CreateLabel(repo string, label RemoteLabel) error {
+	_, err := g.do(http.MethodPost, g.labelsURL(repo), gitlabLabelUpdate{
+		Name:        label.Name,
+		Colour:      gitlabColour(label.Colour),
+		Description: label.Description,
+	}, nil)
+
+	return err
+}
+
+// UpdateLabel changes the existing label called name in the specified
+// project or group to match label. If the names differ, the label is
+// renamed, which retains the issues and merge requests using the label.
+func (g *gitlabClient) UpdateLabel(repo, name string, label RemoteLabel) error {
+	update := gitlabLabelUpdate{
+		Colour:      gitlabColour(label.Colour),
+		Description: label.Description,
+	}
+
+	if label.Name != name {
+		update.NewName = label.Name
+	}
+
+	_, err := g.do(http.MethodPut, g.labelURL(repo, name), update, nil)
+
+	return err
+}
+
+// DeleteLabel removes the specified label from the project or group.
+func (g *gitlabClient) DeleteLabel(repo, name string) error {
+	_, err := g.do(http.MethodDelete, g.labelURL(repo, name), nil, nil)
+
+	return err
+}
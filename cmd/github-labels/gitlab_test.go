@@ -0,0 +1,305 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGitLabProject = "kata-containers/ci/tests"
+
+// fakeGitLab is a minimal implementation of the GitLab project or group
+// labels REST API.
+type fakeGitLab struct {
+	sync.Mutex
+
+	// Path of the project or group
+	path   string
+	group  bool
+	token  string
+	labels []gitlabLabel
+
+	// Requests which modified labels ("METHOD name").
+	changes []string
+}
+
+func newFakeGitLab(path string, group bool, token string, labels ...gitlabLabel) *fakeGitLab {
+	return &fakeGitLab{
+		path:   path,
+		group:  group,
+		token:  token,
+		labels: labels,
+	}
+}
+
+func (f *fakeGitLab) find(name string) int {
+	for i, l := range f.labels {
+		if l.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// checkColour writes an error if the colour is not in the form GitLab
+// requires.
+func (f *fakeGitLab) checkColour(w http.ResponseWriter, colour string) bool {
+	if strings.HasPrefix(colour, "#") && len(colour) == 7 {
+		return true
+	}
+
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(restError{Message: map[string][]string{
+		"color": {"must be a valid color code"},
+	}})
+
+	return false
+}
+
+func (f *fakeGitLab) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	kind := "projects"
+	if f.group {
+		kind = "groups"
+	}
+
+	prefix := fmt.Sprintf("/%s/%s/labels", kind, url.PathEscape(f.path))
+
+	path := r.URL.EscapedPath()
+	if !strings.HasPrefix(path, prefix) {
+		writeFakeError(w, http.StatusNotFound, "404 Project Not Found")
+		return
+	}
+
+	name, err := url.PathUnescape(strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/"))
+	if err != nil {
+		writeFakeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if r.Method != http.MethodGet && r.Header.Get("Private-Token") != f.token {
+		writeFakeError(w, http.StatusUnauthorized, "401 Unauthorized")
+		return
+	}
+
+	var update gitlabLabelUpdate
+
+	if r.Method == http.MethodPost || r.Method == http.MethodPut {
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			writeFakeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		if !f.checkColour(w, update.Colour) {
+			return
+		}
+	}
+
+	i := f.find(name)
+
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		if !f.group && r.URL.Query().Get("include_ancestor_groups") != "false" {
+			writeFakeError(w, http.StatusBadRequest, "group labels must not be included")
+			return
+		}
+
+		writeFakePage(w, r, len(f.labels), func(start, end int) {
+			_ = json.NewEncoder(w).Encode(f.labels[start:end])
+		})
+
+		return
+
+	case r.Method == http.MethodPost && name == "":
+		if f.find(update.Name) >= 0 {
+			writeFakeError(w, http.StatusConflict, "Label already exists")
+			return
+		}
+
+		label := gitlabLabel{Name: update.Name, Colour: update.Colour, Description: update.Description}
+		f.labels = append(f.labels, label)
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(label)
+
+	case i < 0:
+		writeFakeError(w, http.StatusNotFound, "404 Label Not Found")
+		return
+
+	case r.Method == http.MethodPut:
+		if update.NewName != "" {
+			f.labels[i].Name = update.NewName
+		}
+
+		f.labels[i].Colour = update.Colour
+		f.labels[i].Description = update.Description
+
+		_ = json.NewEncoder(w).Encode(f.labels[i])
+
+	case r.Method == http.MethodDelete:
+		f.labels = append(f.labels[:i], f.labels[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeFakeError(w, http.StatusMethodNotAllowed, "405 Method Not Allowed")
+		return
+	}
+
+	f.changes = append(f.changes, r.Method+" "+name)
+}
+
+func TestGitLabRepoSlug(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		repo         string
+		group        bool
+		expectedSlug string
+		expectFail   bool
+	}
+
+	data := []testData{
+		{"", false, "", true},
+		{"", true, "", true},
+		{"kata-containers", false, "", true},
+		{"kata-containers//tests", false, "", true},
+
+		{"kata-containers", true, "kata-containers", false},
+		{"kata-containers/ci", true, "kata-containers/ci", false},
+		{"kata-containers/tests", false, "kata-containers/tests", false},
+		{"kata-containers/ci/tests", false, "kata-containers/ci/tests", false},
+		{"gitlab.com/kata-containers/tests/", false, "kata-containers/tests", false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		slug, err := newGitLabClient("", "", d.group).RepoSlug(d.repo)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.Equal(d.expectedSlug, slug, msg)
+	}
+}
+
+func TestGitLabClient(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeGitLab(testGitLabProject, false, testToken,
+		gitlabLabel{"bug", "#EE0701", "Something is broken"},
+		gitlabLabel{"good first issue", "#7057ff", ""},
+		gitlabLabel{"needs-review", "#ededed", "Needs a review"},
+	)
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := newGitLabClient(server.URL+"/", testToken, false)
+
+	// All pages are returned and the colours are in the GitHub form
+	existing, err := client.ListLabels(testGitLabProject)
+	assert.NoError(err)
+	assert.Equal([]RemoteLabel{
+		{"bug", "ee0701", "Something is broken"},
+		{"good first issue", "7057ff", ""},
+		{"needs-review", "ededed", "Needs a review"},
+	}, existing)
+
+	_, err = client.ListLabels("kata-containers/other")
+	assert.Error(err)
+
+	err = client.CreateLabel(testGitLabProject, RemoteLabel{"feature", "84b6eb", "New functionality"})
+	assert.NoError(err)
+
+	err = client.CreateLabel(testGitLabProject, RemoteLabel{"bug", "ee0701", ""})
+	if assert.Error(err, "label exists") {
+		assert.True(strings.Contains(err.Error(), "Label already exists"), err)
+	}
+
+	// Names containing spaces must be escaped
+	err = client.UpdateLabel(testGitLabProject, "good first issue", RemoteLabel{"good-first-issue", "7057ff", "Suitable for a new contributor"})
+	assert.NoError(err)
+
+	err = client.UpdateLabel(testGitLabProject, "bug", RemoteLabel{"bug", "d73a4a", "Something is broken"})
+	assert.NoError(err)
+
+	err = client.UpdateLabel(testGitLabProject, "does-not-exist", RemoteLabel{"foo", "ffffff", ""})
+	assert.Error(err)
+
+	// An invalid colour is reported using the details GitLab returns
+	err = client.UpdateLabel(testGitLabProject, "bug", RemoteLabel{"bug", "red", ""})
+	if assert.Error(err) {
+		assert.True(strings.Contains(err.Error(), `{"color":["must be a valid color code"]}`), err)
+	}
+
+	err = client.DeleteLabel(testGitLabProject, "needs-review")
+	assert.NoError(err)
+
+	assert.Equal([]gitlabLabel{
+		{"bug", "#d73a4a", "Something is broken"},
+		{"good-first-issue", "#7057ff", "Suitable for a new contributor"},
+		{"feature", "#84b6eb", "New functionality"},
+	}, fake.labels)
+
+	assert.Equal([]string{
+		"POST ",
+		"PUT good first issue",
+		"PUT bug",
+		"DELETE needs-review",
+	}, fake.changes)
+
+	// Modifying labels requires authentication
+	err = newGitLabClient(server.URL, "", false).DeleteLabel(testGitLabProject, "bug")
+	if assert.Error(err) {
+		assert.True(strings.Contains(err.Error(), "401 Unauthorized"), err)
+	}
+}
+
+func TestGitLabGroupLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeGitLab("kata-containers", true, testToken,
+		gitlabLabel{"bug", "#ee0701", "Something is broken"},
+	)
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := newGitLabClient(server.URL, testToken, true)
+
+	existing, err := client.ListLabels("kata-containers")
+	assert.NoError(err)
+	assert.Equal([]RemoteLabel{{"bug", "ee0701", "Something is broken"}}, existing)
+
+	err = client.UpdateLabel("kata-containers", "bug", RemoteLabel{"kind/bug", "ee0701", "Something is broken"})
+	assert.NoError(err)
+
+	assert.Equal([]gitlabLabel{{"kind/bug", "#ee0701", "Something is broken"}}, fake.labels)
+
+	// Labels containing a slash must be escaped
+	err = client.DeleteLabel("kata-containers", "kind/bug")
+	assert.NoError(err)
+	assert.Empty(fake.labels)
+
+	// The project labels are not used
+	_, err = newGitLabClient(server.URL, testToken, false).ListLabels("kata-containers/tests")
+	assert.Error(err)
+}
@@ -11,7 +11,9 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/urfave/cli"
 )
@@ -43,7 +45,60 @@ var formatFlag = cli.StringFlag{
 	Value: defaultOutputFormat,
 }
 
-func commonHandler(context *cli.Context, what DataToShow, withLabels bool) error {
+var repoFlag = cli.StringFlag{
+	Name:  "repo",
+	Usage: "repository (org/repo) to use instead of the one in the labels database",
+}
+
+var githubAPIURLFlag = cli.StringFlag{
+	Name:  "github-api-url",
+	Usage: "GitHub REST API URL",
+	Value: defaultGitHubAPIURL,
+}
+
+// Flags selecting the forge hosting the repository.
+var providerFlags = []cli.Flag{
+	cli.StringFlag{
+		Name:  "forge",
+		Usage: fmt.Sprintf("forge hosting the repository (one of %v)", forges),
+		Value: defaultForge,
+	},
+	cli.StringFlag{
+		Name:  "api-url",
+		Usage: "REST API URL of the forge (default: the public instance)",
+	},
+	githubAPIURLFlag,
+	cli.BoolFlag{
+		Name:  "gitlab-group",
+		Usage: "manage the labels of a GitLab group rather than a project",
+	},
+}
+
+// getLabelProvider returns the provider for the forge specified on the
+// command line, authenticating using the forge's token environment variable
+// if it is set. If needToken is set, the variable must be set.
+func getLabelProvider(context *cli.Context, needToken bool) (LabelProvider, error) {
+	forge := context.String("forge")
+
+	tokenEnvVar := forgeTokenEnvVar(forge)
+
+	token := os.Getenv(tokenEnvVar)
+	if token == "" && needToken {
+		return nil, fmt.Errorf("need %s to modify labels", tokenEnvVar)
+	}
+
+	apiURL := context.String("api-url")
+	if apiURL == "" && forge == forgeGitHub {
+		apiURL = context.String("github-api-url")
+	}
+
+	return newLabelProvider(forge, apiURL, token, context.Bool("gitlab-group"))
+}
+
+// getDisplayHandler returns the display handler for the format specified
+// on the command line. If the format is "help", the available formats are
+// displayed and a nil handler is returned.
+func getDisplayHandler(context *cli.Context) (DisplayHandler, error) {
 	handlers := NewDisplayHandlers()
 
 	format := context.String("format")
@@ -54,12 +109,21 @@ func commonHandler(context *cli.Context, what DataToShow, withLabels bool) error
 			fmt.Fprintf(outputFile, "%s\n", format)
 		}
 
-		return nil
+		return nil, nil
 	}
 
 	handler := handlers.find(format)
 	if handler == nil {
-		return fmt.Errorf("no handler for format %q", format)
+		return nil, fmt.Errorf("no handler for format %q", format)
+	}
+
+	return handler, nil
+}
+
+func commonHandler(context *cli.Context, what DataToShow, withLabels bool) error {
+	handler, err := getDisplayHandler(context)
+	if handler == nil {
+		return err
 	}
 
 	if context.NArg() == 0 {
@@ -71,6 +135,340 @@ func commonHandler(context *cli.Context, what DataToShow, withLabels bool) error
 	return show(file, handler, what, withLabels)
 }
 
+func generateHandler(context *cli.Context) error {
+	if context.NArg() != 2 {
+		return errors.New("need repository and output file")
+	}
+
+	repo := context.Args().Get(0)
+	file := context.Args().Get(1)
+
+	rule, err := newConflictRule(context.String("conflict"))
+	if err != nil {
+		return err
+	}
+
+	masterTemplate := context.String("master-template")
+	if masterTemplate == "" {
+		masterTemplate = defaultMasterTemplate()
+	}
+
+	repoTemplate, err := findRepoTemplate(repo, context.String("repo-template"))
+	if err != nil {
+		return err
+	}
+
+	if repoTemplate == "" {
+		fmt.Printf("No repo-specific labels database\n")
+	} else {
+		fmt.Printf("Found repo-specific labels database %v\n", repoTemplate)
+	}
+
+	err = generateYAML(repo, masterTemplate, repoTemplate, file, rule)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Generated labels database %v\n", file)
+
+	return nil
+}
+
+func scanHandler(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return errors.New("need repository")
+	}
+
+	client, err := getLabelProvider(context, false)
+	if err != nil {
+		return err
+	}
+
+	repo, err := client.RepoSlug(context.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := scanRepo(client, repo)
+	if err != nil {
+		return err
+	}
+
+	bytes, err := formatSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+
+	if context.NArg() < 2 {
+		_, err = outputFile.Write(bytes)
+		return err
+	}
+
+	return ioutil.WriteFile(context.Args().Get(1), bytes, fileMode)
+}
+
+func diffHandler(context *cli.Context) error {
+	handler, err := getDisplayHandler(context)
+	if handler == nil {
+		return err
+	}
+
+	if context.NArg() != 2 {
+		return errors.New("need YAML file and snapshot file or repository")
+	}
+
+	lf, err := readYAML(context.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	source := context.Args().Get(1)
+
+	var existing []RemoteLabel
+
+	if _, err := os.Stat(source); err == nil {
+		snapshot, err := readSnapshot(source)
+		if err != nil {
+			return err
+		}
+
+		existing = snapshot.Labels
+	} else {
+		client, err := getLabelProvider(context, false)
+		if err != nil {
+			return err
+		}
+
+		source, err = client.RepoSlug(source)
+		if err != nil {
+			return err
+		}
+
+		existing, err = client.ListLabels(source)
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler.DisplayDiff(diffLabels(lf, source, existing))
+}
+
+func usageHandler(context *cli.Context) error {
+	handler, err := getDisplayHandler(context)
+	if handler == nil {
+		return err
+	}
+
+	if context.NArg() == 0 {
+		return errNeedYAMLFile
+	}
+
+	lf, err := readYAML(context.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	var issues []Issue
+
+	source := context.String("dump")
+
+	if source != "" {
+		issues, err = readIssuesDump(source)
+		if err != nil {
+			return err
+		}
+	} else {
+		source = context.Args().Get(1)
+		if source == "" {
+			source = lf.Repo
+		}
+
+		source, err = githubRepoSlug(source)
+		if err != nil {
+			return err
+		}
+
+		client := newGitHubClient(context.String("github-api-url"), os.Getenv(githubTokenEnvVar))
+
+		issues, err = client.ListIssues(source)
+		if err != nil {
+			return err
+		}
+	}
+
+	return handler.DisplayUsage(countUsage(lf, source, issues))
+}
+
+func exportHandler(context *cli.Context, export func(lf *LabelsFile, file *os.File) error) error {
+	if context.NArg() == 0 {
+		return errNeedYAMLFile
+	}
+
+	lf, err := readYAML(context.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	return exportLabels(lf, export, context.Args().Get(1))
+}
+
+var enforceCommonFlag = cli.BoolFlag{
+	Name:  "enforce-common",
+	Usage: "Require labels defined for more than one repository to be identical in all of them",
+}
+
+// orgLabelsHandler reads the manifest and creates and checks the labels
+// database for each repository.
+func orgLabelsHandler(context *cli.Context) ([]OrgLabels, error) {
+	if context.NArg() == 0 {
+		return nil, errors.New("need manifest file")
+	}
+
+	m, err := readManifest(context.Args().Get(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return readOrgLabels(m, context.Bool("enforce-common"))
+}
+
+func orgCheckHandler(context *cli.Context) error {
+	orgLabels, err := orgLabelsHandler(context)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Checked %d repositories\n", len(orgLabels))
+
+	return nil
+}
+
+func orgDiffHandler(context *cli.Context) error {
+	handler, err := getDisplayHandler(context)
+	if handler == nil {
+		return err
+	}
+
+	orgLabels, err := orgLabelsHandler(context)
+	if err != nil {
+		return err
+	}
+
+	client := newGitHubClient(context.String("github-api-url"), os.Getenv(githubTokenEnvVar))
+
+	drift := diffOrg(client, orgLabels)
+
+	err = handler.DisplayDrift(drift)
+	if err != nil {
+		return err
+	}
+
+	return driftErr(drift)
+}
+
+func orgSyncHandler(context *cli.Context) error {
+	dryRun := context.Bool("dry-run")
+
+	token := os.Getenv(githubTokenEnvVar)
+	if token == "" && !dryRun {
+		return fmt.Errorf("need %s to modify labels", githubTokenEnvVar)
+	}
+
+	orgLabels, err := orgLabelsHandler(context)
+	if err != nil {
+		return err
+	}
+
+	client := newGitHubClient(context.String("github-api-url"), token)
+
+	return syncOrg(client, orgLabels, context.Bool("prune"), dryRun, outputFile)
+}
+
+// editHandler applies the edit to the labels database or template specified
+// on the command line, after checking the arguments required after the file
+// are specified.
+func editHandler(context *cli.Context, args []string, edit func(doc *Document) error) error {
+	if context.NArg() != len(args)+1 {
+		return fmt.Errorf("need YAML file and %s", strings.Join(args, " and "))
+	}
+
+	return editYAML(context.Args().Get(0), edit)
+}
+
+func labelAddHandler(context *cli.Context) error {
+	return editHandler(context, []string{"label name"}, func(doc *Document) error {
+		return doc.AddLabel(Label{
+			Name:         context.Args().Get(1),
+			Description:  context.String("description"),
+			CategoryName: context.String("category"),
+			Colour:       context.String("colour"),
+			From:         context.String("from"),
+			Paths:        context.StringSlice("path"),
+			Keywords:     context.StringSlice("keyword"),
+		})
+	})
+}
+
+func labelRemoveHandler(context *cli.Context) error {
+	return editHandler(context, []string{"label name"}, func(doc *Document) error {
+		return doc.RemoveLabel(context.Args().Get(1))
+	})
+}
+
+func labelRenameHandler(context *cli.Context) error {
+	return editHandler(context, []string{"old label name", "new label name"}, func(doc *Document) error {
+		return doc.RenameLabel(context.Args().Get(1), context.Args().Get(2))
+	})
+}
+
+func categoryAddHandler(context *cli.Context) error {
+	return editHandler(context, []string{"category name"}, func(doc *Document) error {
+		return doc.AddCategory(Category{
+			Name:        context.Args().Get(1),
+			Description: context.String("description"),
+			URL:         context.String("url"),
+		})
+	})
+}
+
+func categoryRemoveHandler(context *cli.Context) error {
+	return editHandler(context, []string{"category name"}, func(doc *Document) error {
+		return doc.RemoveCategory(context.Args().Get(1))
+	})
+}
+
+func syncHandler(context *cli.Context) error {
+	if context.NArg() == 0 {
+		return errNeedYAMLFile
+	}
+
+	file := context.Args().Get(0)
+
+	lf, err := readYAML(file)
+	if err != nil {
+		return err
+	}
+
+	dryRun := context.Bool("dry-run")
+
+	client, err := getLabelProvider(context, !dryRun)
+	if err != nil {
+		return err
+	}
+
+	repo := context.String("repo")
+	if repo == "" {
+		repo = lf.Repo
+	}
+
+	repo, err = client.RepoSlug(repo)
+	if err != nil {
+		return err
+	}
+
+	return syncLabels(client, lf, repo, context.Bool("prune"), dryRun, outputFile)
+}
+
 func main() {
 	app := cli.NewApp()
 	app.Description = "tool to manipulate Kata GitHub labels"
@@ -135,7 +533,7 @@ func main() {
 		{
 			Name:        "sort",
 			Usage:       "Sort the specified YAML labels file and write to a new file",
-			Description: "Can be used to keep the master labels file sorted",
+			Description: "Can be used to keep the master labels file sorted (comments and formatting are preserved)",
 			ArgsUsage:   "<input-file> <output-file>",
 			Action: func(context *cli.Context) error {
 				if context.NArg() != 2 {
@@ -147,6 +545,270 @@ func main() {
 				return sortYAML(from, to)
 			},
 		},
+		{
+			Name:        "label",
+			Usage:       "Edit the labels in a labels database or template",
+			Description: "The file is modified in place if it passes the checks",
+			Subcommands: []cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Add a label",
+					ArgsUsage: "<labels-file> <name>",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "description",
+							Usage: "label description",
+						},
+						cli.StringFlag{
+							Name:  "category",
+							Usage: "label category",
+						},
+						cli.StringFlag{
+							Name:  "colour",
+							Usage: "label colour (6 hex digits)",
+							Value: defaultColourVariable,
+						},
+						cli.StringFlag{
+							Name:  "from",
+							Usage: "name of an existing label to rename",
+						},
+						cli.StringSliceFlag{
+							Name:  "path",
+							Usage: "glob matching the files changed by PRs to apply the label to (may be repeated)",
+						},
+						cli.StringSliceFlag{
+							Name:  "keyword",
+							Usage: "word in the titles of issues and PRs to apply the label to (may be repeated)",
+						},
+					},
+					Action: func(context *cli.Context) error {
+						return labelAddHandler(context)
+					},
+				},
+				{
+					Name:      "remove",
+					Usage:     "Remove a label",
+					ArgsUsage: "<labels-file> <name>",
+					Action: func(context *cli.Context) error {
+						return labelRemoveHandler(context)
+					},
+				},
+				{
+					Name:        "rename",
+					Usage:       "Rename a label",
+					Description: "The old name is recorded as the label's From value so the label is renamed in the repository",
+					ArgsUsage:   "<labels-file> <old-name> <new-name>",
+					Action: func(context *cli.Context) error {
+						return labelRenameHandler(context)
+					},
+				},
+			},
+		},
+		{
+			Name:        "category",
+			Usage:       "Edit the categories in a labels database or template",
+			Description: "The file is modified in place if it passes the checks",
+			Subcommands: []cli.Command{
+				{
+					Name:      "add",
+					Usage:     "Add a category",
+					ArgsUsage: "<labels-file> <name>",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "description",
+							Usage: "category description",
+						},
+						cli.StringFlag{
+							Name:  "url",
+							Usage: "URL of a document describing the category",
+						},
+					},
+					Action: func(context *cli.Context) error {
+						return categoryAddHandler(context)
+					},
+				},
+				{
+					Name:        "remove",
+					Usage:       "Remove a category",
+					Description: "A category cannot be removed while any labels use it",
+					ArgsUsage:   "<labels-file> <name>",
+					Action: func(context *cli.Context) error {
+						return categoryRemoveHandler(context)
+					},
+				},
+			},
+		},
+		{
+			Name:        "generate",
+			Usage:       "Create the combined labels database for a repository",
+			Description: fmt.Sprintf("Expands the master template and the repository-specific template (%s in the repository, if it exists), merges them and checks the result", labelsTemplate),
+			ArgsUsage:   "<repo> <output-file>",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "master-template",
+					Usage: fmt.Sprintf("master labels template (default: %s)", defaultMasterTemplate()),
+				},
+				cli.StringFlag{
+					Name:  "repo-template",
+					Usage: "repository-specific labels template (default: found in GOPATH)",
+				},
+				cli.StringFlag{
+					Name:  "conflict",
+					Usage: fmt.Sprintf("how to handle a label or category defined in both templates (one of %v)", conflictRules),
+					Value: string(defaultConflictRule),
+				},
+			},
+			Action: func(context *cli.Context) error {
+				return generateHandler(context)
+			},
+		},
+		{
+			Name:        "scan",
+			Usage:       "Save the labels in a repository to a snapshot file",
+			Description: "The snapshot file uses the same format as the archived labels (writes to stdout if no file is specified)",
+			ArgsUsage:   "<repo> [<output-file>]",
+			Flags:       providerFlags,
+			Action: func(context *cli.Context) error {
+				return scanHandler(context)
+			},
+		},
+		{
+			Name:        "diff",
+			Usage:       "Compare the labels database with a repository or snapshot file",
+			Description: "Displays the labels which are missing, extra, renamed, recoloured or redescribed",
+			ArgsUsage:   "<labels-file> <snapshot-file-or-repo>",
+			Flags:       append([]cli.Flag{formatFlag}, providerFlags...),
+			Action: func(context *cli.Context) error {
+				return diffHandler(context)
+			},
+		},
+		{
+			Name:        "usage",
+			Usage:       "Display how many issues and PRs use each label",
+			Description: "Counts the open and closed issues and PRs using each label and category, and identifies unused labels",
+			ArgsUsage:   "<labels-file> [<repo>]",
+			Flags: []cli.Flag{
+				formatFlag,
+				githubAPIURLFlag,
+				cli.StringFlag{
+					Name:  "dump",
+					Usage: "read issues and PRs from a `file` containing the JSON returned by the GitHub issues API rather than the repository",
+				},
+			},
+			Action: func(context *cli.Context) error {
+				return usageHandler(context)
+			},
+		},
+		{
+			Name:        "export",
+			Usage:       "Create configuration for other tools from the labels database",
+			Description: "Uses the label paths and keywords (writes to stdout if no file is specified)",
+			Subcommands: []cli.Command{
+				{
+					Name:      "guide",
+					Usage:     "Create a markdown guide to the labels in each category",
+					ArgsUsage: "<labels-file> [<output-file>]",
+					Action: func(context *cli.Context) error {
+						return exportHandler(context, exportGuide)
+					},
+				},
+				{
+					Name:      "issue-labeler",
+					Usage:     "Create the github/issue-labeler configuration (.github/issue-labeler.yml) from the label keywords",
+					ArgsUsage: "<labels-file> [<output-file>]",
+					Action: func(context *cli.Context) error {
+						return exportHandler(context, exportIssueLabeler)
+					},
+				},
+				{
+					Name:      "labeler",
+					Usage:     "Create the actions/labeler configuration (.github/labeler.yml) from the label paths",
+					ArgsUsage: "<labels-file> [<output-file>]",
+					Action: func(context *cli.Context) error {
+						return exportHandler(context, exportLabeler)
+					},
+				},
+				{
+					Name:      "prow",
+					Usage:     "Create the Prow label plugin configuration",
+					ArgsUsage: "<labels-file> [<output-file>]",
+					Action: func(context *cli.Context) error {
+						return exportHandler(context, exportProw)
+					},
+				},
+			},
+		},
+		{
+			Name:        "sync",
+			Usage:       "Apply the labels database to a repository",
+			Description: fmt.Sprintf("Creates, updates and renames labels using the forge's API (set %s, %s or %s to authenticate)", githubTokenEnvVar, gitlabTokenEnvVar, giteaTokenEnvVar),
+			ArgsUsage:   "<labels-file>",
+			Flags: append([]cli.Flag{
+				repoFlag,
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Display the changes required without making them",
+				},
+				cli.BoolFlag{
+					Name:  "prune",
+					Usage: "Delete labels which are not in the labels database",
+				},
+			}, providerFlags...),
+			Action: func(context *cli.Context) error {
+				return syncHandler(context)
+			},
+		},
+		{
+			Name:        "org",
+			Usage:       "Manage the labels of all repositories in an organisation",
+			Description: "The manifest lists the repositories and their repository-specific labels templates",
+			Subcommands: []cli.Command{
+				{
+					Name:      "check",
+					Usage:     "Create and check the labels database for each repository",
+					ArgsUsage: "<manifest-file>",
+					Flags: []cli.Flag{
+						enforceCommonFlag,
+					},
+					Action: func(context *cli.Context) error {
+						return orgCheckHandler(context)
+					},
+				},
+				{
+					Name:      "diff",
+					Usage:     "Summarise the differences between the labels database and each repository",
+					ArgsUsage: "<manifest-file>",
+					Flags: []cli.Flag{
+						enforceCommonFlag,
+						formatFlag,
+						githubAPIURLFlag,
+					},
+					Action: func(context *cli.Context) error {
+						return orgDiffHandler(context)
+					},
+				},
+				{
+					Name:      "sync",
+					Usage:     "Apply the labels database to each repository",
+					ArgsUsage: "<manifest-file>",
+					Flags: []cli.Flag{
+						enforceCommonFlag,
+						githubAPIURLFlag,
+						cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "Display the changes required without making them",
+						},
+						cli.BoolFlag{
+							Name:  "prune",
+							Usage: "Delete labels which are not in the labels database",
+						},
+					},
+					Action: func(context *cli.Context) error {
+						return orgSyncHandler(context)
+					},
+				},
+			},
+		},
 	}
 
 	err := app.Run(os.Args)
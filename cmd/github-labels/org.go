@@ -0,0 +1,381 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Maximum number of repositories to operate on at the same time.
+const maxConcurrentRepos = 4
+
+// OrgRepo is a repository listed in an organisation manifest.
+type OrgRepo struct {
+	Repo string
+
+	// Repository-specific labels template (optional). If not specified,
+	// the template in the repository is used if it exists in the GOPATH.
+	Labels string `yaml:",omitempty"`
+}
+
+// Manifest lists the repositories in an organisation whose labels are
+// managed by this tool.
+type Manifest struct {
+	Description string
+
+	// Master labels template (default: found in the GOPATH).
+	MasterTemplate string `yaml:"master-template,omitempty"`
+
+	// How to handle a label or category defined in both the master and a
+	// repository-specific template (default: error).
+	Conflict ConflictRule `yaml:",omitempty"`
+
+	// If set, a label defined for more than one repository must be
+	// identical in all of them.
+	EnforceCommon bool `yaml:"enforce-common,omitempty"`
+
+	Repos []OrgRepo
+}
+
+// OrgLabels is the labels database for a repository in an organisation.
+type OrgLabels struct {
+	Repo     string
+	Labels   *LabelsFile
+	Problems *Problems
+}
+
+// RepoDrift summarises the differences between the labels database and the
+// labels in a repository.
+type RepoDrift struct {
+	Repo        string `json:"repo"`
+	Missing     int    `json:"missing"`
+	Extra       int    `json:"extra"`
+	Renamed     int    `json:"renamed"`
+	Recoloured  int    `json:"recoloured"`
+	Redescribed int    `json:"redescribed"`
+
+	// Set if the repository could not be compared.
+	Error string `json:"error,omitempty"`
+}
+
+// readManifest reads and checks an organisation manifest. Files are
+// relative to the directory containing the manifest.
+func readManifest(file string) (*Manifest, error) {
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Manifest{}
+
+	err = yaml.UnmarshalStrict(bytes, &m)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest %v: %v", file, err)
+	}
+
+	if len(m.Repos) == 0 {
+		return nil, fmt.Errorf("invalid manifest %v: no repositories", file)
+	}
+
+	if m.Conflict == "" {
+		m.Conflict = defaultConflictRule
+	}
+
+	if _, err := newConflictRule(string(m.Conflict)); err != nil {
+		return nil, fmt.Errorf("invalid manifest %v: %v", file, err)
+	}
+
+	dir := filepath.Dir(file)
+
+	relative := func(path string) string {
+		if path == "" || filepath.IsAbs(path) {
+			return path
+		}
+
+		return filepath.Join(dir, path)
+	}
+
+	m.MasterTemplate = relative(m.MasterTemplate)
+
+	seen := make(map[string]bool)
+
+	for i := range m.Repos {
+		r := &m.Repos[i]
+
+		r.Repo, err = githubRepoSlug(r.Repo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %v: %v", file, err)
+		}
+
+		if seen[r.Repo] {
+			return nil, fmt.Errorf("invalid manifest %v: duplicate repository %v", file, r.Repo)
+		}
+
+		seen[r.Repo] = true
+
+		r.Labels = relative(r.Labels)
+	}
+
+	return &m, nil
+}
+
+// forEachRepo calls fn for each repository, operating on several at the
+// same time. The errors are returned in the same order as the repositories.
+func forEachRepo(repos []string, fn func(i int, repo string) error) []error {
+	errs := make([]error, len(repos))
+
+	var wg sync.WaitGroup
+
+	tokens := make(chan struct{}, maxConcurrentRepos)
+
+	for i, repo := range repos {
+		wg.Add(1)
+
+		go func(i int, repo string) {
+			defer wg.Done()
+
+			tokens <- struct{}{}
+			defer func() { <-tokens }()
+
+			errs[i] = fn(i, repo)
+		}(i, repo)
+	}
+
+	wg.Wait()
+
+	return errs
+}
+
+// generateOrgLabels creates the labels database for each repository in the
+// manifest.
+func generateOrgLabels(m *Manifest) ([]OrgLabels, error) {
+	result := make([]OrgLabels, len(m.Repos))
+
+	masterTemplate := m.MasterTemplate
+	if masterTemplate == "" {
+		masterTemplate = defaultMasterTemplate()
+	}
+
+	var repos []string
+
+	for _, r := range m.Repos {
+		repos = append(repos, r.Repo)
+	}
+
+	errs := forEachRepo(repos, func(i int, repo string) error {
+		repoTemplate, err := findRepoTemplate(repo, m.Repos[i].Labels)
+		if err != nil {
+			return err
+		}
+
+		lf, problems, err := generateLabels(repo, masterTemplate, repoTemplate, m.Conflict)
+		if err != nil {
+			return err
+		}
+
+		result[i] = OrgLabels{
+			Repo:     repo,
+			Labels:   lf,
+			Problems: problems,
+		}
+
+		return nil
+	})
+
+	p := &Problems{}
+
+	for i, err := range errs {
+		if err != nil {
+			p.errorf("%s: %v", repos[i], err)
+		}
+	}
+
+	return result, p.Err()
+}
+
+// checkCommonLabels checks that each label defined for more than one
+// repository is identical in all of them.
+func checkCommonLabels(p *Problems, orgLabels []OrgLabels) {
+	type definition struct {
+		repo  string
+		label Label
+	}
+
+	// Key: label name
+	// Value: first definition of the label
+	first := make(map[string]definition)
+
+	for _, o := range orgLabels {
+		for _, l := range o.Labels.Labels {
+			d, ok := first[l.Name]
+			if !ok {
+				first[l.Name] = definition{o.Repo, l}
+				continue
+			}
+
+			if !reflect.DeepEqual(d.label, l) {
+				p.errorf("label %q differs between %s and %s: %+v and %+v",
+					l.Name, d.repo, o.Repo, d.label, l)
+			}
+		}
+	}
+}
+
+// checkOrgProblems returns all the problems found with the labels databases
+// for the organisation.
+func checkOrgProblems(m *Manifest, orgLabels []OrgLabels, enforceCommon bool) *Problems {
+	p := &Problems{}
+
+	for _, o := range orgLabels {
+		for _, e := range o.Problems.Errors {
+			p.errorf("%s: %s", o.Repo, e)
+		}
+
+		for _, w := range o.Problems.Warnings {
+			p.warnf("%s: %s", o.Repo, w)
+		}
+	}
+
+	if enforceCommon || m.EnforceCommon {
+		checkCommonLabels(p, orgLabels)
+	}
+
+	return p
+}
+
+// readOrgLabels creates and checks the labels database for each
+// repository in the manifest.
+func readOrgLabels(m *Manifest, enforceCommon bool) ([]OrgLabels, error) {
+	orgLabels, err := generateOrgLabels(m)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := checkOrgProblems(m, orgLabels, enforceCommon)
+
+	displayWarnings(problems)
+
+	err = problems.Err()
+	if err != nil {
+		return nil, fmt.Errorf("labels databases are invalid: %v", err)
+	}
+
+	return orgLabels, nil
+}
+
+// orgRepos returns the names of the repositories.
+func orgRepos(orgLabels []OrgLabels) []string {
+	var repos []string
+
+	for _, o := range orgLabels {
+		repos = append(repos, o.Repo)
+	}
+
+	return repos
+}
+
+// newRepoDrift summarises the differences.
+func newRepoDrift(diff *LabelsDiff) RepoDrift {
+	drift := RepoDrift{
+		Repo: diff.Repo,
+	}
+
+	for _, d := range diff.Differences {
+		switch d.Kind {
+		case diffMissing:
+			drift.Missing++
+		case diffExtra:
+			drift.Extra++
+		case diffRenamed:
+			drift.Renamed++
+		case diffRecoloured:
+			drift.Recoloured++
+		case diffRedescribed:
+			drift.Redescribed++
+		}
+	}
+
+	return drift
+}
+
+// diffOrg compares the labels database for each repository with the labels
+// in the repository. A repository which cannot be compared is reported in
+// its drift summary.
+func diffOrg(client LabelProvider, orgLabels []OrgLabels) []RepoDrift {
+	drift := make([]RepoDrift, len(orgLabels))
+
+	errs := forEachRepo(orgRepos(orgLabels), func(i int, repo string) error {
+		existing, err := client.ListLabels(repo)
+		if err != nil {
+			return err
+		}
+
+		drift[i] = newRepoDrift(diffLabels(orgLabels[i].Labels, repo, existing))
+
+		return nil
+	})
+
+	for i, err := range errs {
+		if err != nil {
+			drift[i] = RepoDrift{
+				Repo:  orgLabels[i].Repo,
+				Error: err.Error(),
+			}
+		}
+	}
+
+	return drift
+}
+
+// syncOrg makes the labels in each repository match its labels database.
+// The output for each repository is written in manifest order once all
+// repositories have been synchronised.
+func syncOrg(client LabelProvider, orgLabels []OrgLabels, prune, dryRun bool, w io.Writer) error {
+	output := make([]bytes.Buffer, len(orgLabels))
+
+	errs := forEachRepo(orgRepos(orgLabels), func(i int, repo string) error {
+		return syncLabels(client, orgLabels[i].Labels, repo, prune, dryRun, &output[i])
+	})
+
+	p := &Problems{}
+
+	for i, err := range errs {
+		if _, err := output[i].WriteTo(w); err != nil {
+			return err
+		}
+
+		if err != nil {
+			p.errorf("failed to sync %s: %v", orgLabels[i].Repo, err)
+		}
+	}
+
+	return p.Err()
+}
+
+// driftErr returns an error if any repository could not be compared.
+func driftErr(drift []RepoDrift) error {
+	count := 0
+
+	for _, d := range drift {
+		if d.Error != "" {
+			count++
+		}
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("failed to compare %d of %d repositories", count, len(drift))
+}
@@ -0,0 +1,313 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeGitHubOrg passes requests for each repository to its fake server.
+type fakeGitHubOrg map[string]*fakeGitHub
+
+func (f fakeGitHubOrg) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for repo, fake := range f {
+		if strings.HasPrefix(r.URL.Path, "/repos/"+repo+"/") {
+			fake.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// writeTestManifest creates a manifest using the master template and the
+// specified repository templates.
+func writeTestManifest(t *testing.T, dir string, enforceCommon bool, repoTemplates map[string]string) string {
+	assert := assert.New(t)
+
+	master, err := filepath.Abs(labelsTemplate)
+	assert.NoError(err)
+
+	manifest := fmt.Sprintf("---\nmaster-template: %s\nenforce-common: %v\nrepos:\n", master, enforceCommon)
+
+	for _, repo := range []string{"example/one", "example/two"} {
+		manifest += fmt.Sprintf("  - repo: %s\n", repo)
+
+		template, ok := repoTemplates[repo]
+		if !ok {
+			continue
+		}
+
+		name := strings.Replace(repo, "/", "-", -1) + ".yaml.in"
+
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(template), fileMode)
+		assert.NoError(err)
+
+		// Relative to the manifest
+		manifest += fmt.Sprintf("    labels: %s\n", name)
+	}
+
+	file := filepath.Join(dir, "org.yaml")
+
+	err = ioutil.WriteFile(file, []byte(manifest), fileMode)
+	assert.NoError(err)
+
+	return file
+}
+
+func TestReadManifest(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := writeTestManifest(t, dir, false, map[string]string{"example/two": testRepoTemplate})
+
+	m, err := readManifest(file)
+	assert.NoError(err)
+	assert.Equal(defaultConflictRule, m.Conflict)
+	assert.False(m.EnforceCommon)
+	assert.Equal([]OrgRepo{
+		{Repo: "example/one"},
+		{Repo: "example/two", Labels: filepath.Join(dir, "example-two.yaml.in")},
+	}, m.Repos)
+
+	type testData struct {
+		manifest string
+		expected string
+	}
+
+	data := []testData{
+		{"", "no repositories"},
+		{"repos: []", "no repositories"},
+		{"repos:\n  - repo: example", "org/repo"},
+		{"repos:\n  - repo: example/one\n  - repo: github.com/example/one", "duplicate repository"},
+		{"conflict: merge\nrepos:\n  - repo: example/one", "invalid conflict rule"},
+		{"unknown: true\nrepos:\n  - repo: example/one", "not found"},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		err = ioutil.WriteFile(file, []byte(d.manifest), fileMode)
+		assert.NoError(err, msg)
+
+		_, err = readManifest(file)
+		if assert.Error(err, msg) {
+			assert.True(strings.Contains(err.Error(), d.expected), "%s: %v", msg, err)
+		}
+	}
+
+	_, err = readManifest(filepath.Join(dir, "does-not-exist"))
+	assert.Error(err)
+
+	// The Kata Containers manifest is valid
+	m, err = readManifest("org.yaml")
+	assert.NoError(err)
+	assert.Equal("labels.yaml.in", m.MasterTemplate)
+	assert.True(m.EnforceCommon)
+}
+
+func TestReadOrgLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	master, err := readTemplate(labelsTemplate, "example/one")
+	assert.NoError(err)
+
+	// Redefine a label from the master template
+	override := testRepoTemplate + fmt.Sprintf(`
+  - name: %s
+    description: Overridden definition.
+    category: repo
+    color: 000000
+`, master.Labels[0].Name)
+
+	file := writeTestManifest(t, dir, false, map[string]string{"example/two": override})
+
+	m, err := readManifest(file)
+	assert.NoError(err)
+
+	_, err = readOrgLabels(m, false)
+	assert.Error(err, "conflict")
+
+	m.Conflict = conflictOverride
+
+	orgLabels, err := readOrgLabels(m, false)
+	assert.NoError(err)
+
+	if assert.Len(orgLabels, 2) {
+		assert.Equal("example/one", orgLabels[0].Repo)
+		assert.Equal(master.Labels, orgLabels[0].Labels.Labels)
+
+		assert.Equal("example/two", orgLabels[1].Repo)
+		assert.Equal("example/two", orgLabels[1].Labels.Repo)
+		assert.Len(orgLabels[1].Labels.Labels, len(master.Labels)+1)
+	}
+
+	// The overridden label is not identical in all repositories
+	_, err = readOrgLabels(m, true)
+	if assert.Error(err) {
+		assert.True(strings.Contains(err.Error(), fmt.Sprintf("label %q differs between example/one and example/two", master.Labels[0].Name)), err)
+	}
+
+	m.EnforceCommon = true
+
+	_, err = readOrgLabels(m, false)
+	assert.Error(err)
+
+	// Repository-specific labels are not common labels
+	file = writeTestManifest(t, dir, true, map[string]string{"example/two": testRepoTemplate})
+
+	m, err = readManifest(file)
+	assert.NoError(err)
+
+	_, err = readOrgLabels(m, false)
+	assert.NoError(err)
+
+	// All invalid databases are reported
+	invalid := strings.Replace(testRepoTemplate, "category: repo", "category: does-not-exist", 1)
+
+	file = writeTestManifest(t, dir, false, map[string]string{"example/one": invalid, "example/two": invalid})
+
+	m, err = readManifest(file)
+	assert.NoError(err)
+
+	_, err = readOrgLabels(m, false)
+	if assert.Error(err) {
+		assert.True(strings.Contains(err.Error(), "example/one: invalid category"), err)
+		assert.True(strings.Contains(err.Error(), "example/two: invalid category"), err)
+	}
+}
+
+func TestDiffAndSyncOrg(t *testing.T) {
+	assert := assert.New(t)
+
+	bug := Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ee0701"}
+	review := Label{Name: "needs-review", Description: "Needs a review", CategoryName: "test", Colour: "ededed"}
+
+	orgLabels := []OrgLabels{
+		{Repo: "example/one", Labels: newTestLabelsFile(bug, review)},
+		{Repo: "example/two", Labels: newTestLabelsFile(bug, review)},
+		{Repo: "example/three", Labels: newTestLabelsFile(bug)},
+	}
+
+	fakes := fakeGitHubOrg{
+		"example/one": newFakeGitHub("example/one", testToken,
+			RemoteLabel{"bug", "ee0701", "Something is broken"},
+			RemoteLabel{"needs-review", "ededed", "Needs a review"},
+		),
+		"example/two": newFakeGitHub("example/two", testToken,
+			RemoteLabel{"bug", "000000", "Something is broken"},
+			RemoteLabel{"question", "cc317c", ""},
+		),
+	}
+
+	server := httptest.NewServer(fakes)
+	defer server.Close()
+
+	client := newGitHubClient(server.URL, testToken)
+
+	drift := diffOrg(client, orgLabels)
+
+	if assert.Len(drift, 3) {
+		assert.Equal(RepoDrift{Repo: "example/one"}, drift[0])
+		assert.Equal(RepoDrift{Repo: "example/two", Missing: 1, Extra: 1, Recoloured: 1}, drift[1])
+		assert.Equal("example/three", drift[2].Repo)
+		assert.NotEmpty(drift[2].Error)
+	}
+
+	err := driftErr(drift)
+	if assert.Error(err) {
+		assert.Equal("failed to compare 1 of 3 repositories", err.Error())
+	}
+
+	assert.NoError(driftErr(drift[:2]))
+
+	// Nothing is changed by a dry run
+	var output bytes.Buffer
+
+	err = syncOrg(client, orgLabels[:2], false, true, &output)
+	assert.NoError(err)
+	assert.Empty(fakes["example/one"].changes)
+	assert.Empty(fakes["example/two"].changes)
+
+	// The output is in manifest order
+	lines := strings.Split(strings.TrimSuffix(output.String(), "\n"), "\n")
+	assert.Equal([]string{
+		"Labels in example/one are up to date",
+		"Would apply 2 changes to example/two:",
+		`    update label "bug": colour "000000" -> "ee0701"`,
+		`    create label "needs-review" (colour "ededed", description "Needs a review")`,
+	}, lines)
+
+	output.Reset()
+
+	// All repositories are synchronised even if one fails
+	err = syncOrg(client, orgLabels, true, false, &output)
+	if assert.Error(err) {
+		assert.True(strings.Contains(err.Error(), "failed to sync example/three"), err)
+	}
+
+	assert.Empty(fakes["example/one"].changes)
+	assert.Equal([]string{"PATCH bug", "POST ", "DELETE question"}, fakes["example/two"].changes)
+
+	drift = diffOrg(client, orgLabels[:2])
+	assert.Equal([]RepoDrift{{Repo: "example/one"}, {Repo: "example/two"}}, drift)
+}
+
+func TestDisplayDrift(t *testing.T) {
+	assert := assert.New(t)
+
+	drift := []RepoDrift{
+		{Repo: "example/one"},
+		{Repo: "example/two", Missing: 1, Extra: 2, Recoloured: 3},
+		{Repo: "example/three", Error: "Not Found"},
+	}
+
+	type testData struct {
+		newHandler    func(file *os.File) DisplayHandler
+		expectedLines []string
+	}
+
+	data := []testData{
+		{NewDisplayText, []string{
+			"Drift (repositories: 3):",
+			"    example/one: up to date (missing: 0, extra: 0, renamed: 0, recoloured: 0, redescribed: 0)",
+			"    example/two: drifted (missing: 1, extra: 2, renamed: 0, recoloured: 3, redescribed: 0)",
+			"    example/three: error: Not Found",
+		}},
+		{NewDisplayTSV, []string{
+			"Repository\tMissing\tExtra\tRenamed\tRecoloured\tRedescribed\tStatus",
+			"example/one\t0\t0\t0\t0\t0\tup to date",
+			"example/two\t1\t2\t0\t3\t0\tdrifted",
+			"example/three\t0\t0\t0\t0\t0\terror: Not Found",
+		}},
+	}
+
+	for _, d := range data {
+		output := displayToString(t, d.newHandler, func(handler DisplayHandler) error {
+			return handler.DisplayDrift(drift)
+		})
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		assert.Equal(d.expectedLines, lines)
+	}
+}
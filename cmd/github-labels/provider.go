@@ -0,0 +1,78 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Forges hosting the repositories whose labels can be managed.
+const (
+	forgeGitHub = "github"
+	forgeGitLab = "gitlab"
+	forgeGitea  = "gitea"
+
+	defaultForge = forgeGitHub
+)
+
+var forges = []string{forgeGitHub, forgeGitLab, forgeGitea}
+
+// LabelProvider reads and modifies the labels in the repositories on a
+// forge. Label colours are always in the form used by GitHub: each provider
+// converts them to and from the form used by its forge.
+type LabelProvider interface {
+	// RepoSlug checks the name of the repository and returns it in the
+	// form used by the other methods.
+	RepoSlug(repo string) (string, error)
+
+	// ListLabels returns all the labels in the repository.
+	ListLabels(repo string) ([]RemoteLabel, error)
+
+	// CreateLabel adds a new label to the repository.
+	CreateLabel(repo string, label RemoteLabel) error
+
+	// UpdateLabel changes the existing label called name to match label,
+	// renaming it if the names differ.
+	UpdateLabel(repo, name string, label RemoteLabel) error
+
+	// DeleteLabel removes the label from the repository.
+	DeleteLabel(repo, name string) error
+}
+
+// forgeTokenEnvVar returns the environment variable containing the token
+// used to authenticate with the forge.
+func forgeTokenEnvVar(forge string) string {
+	switch forge {
+	case forgeGitLab:
+		return gitlabTokenEnvVar
+	case forgeGitea:
+		return giteaTokenEnvVar
+	}
+
+	return githubTokenEnvVar
+}
+
+// newLabelProvider creates a provider for the forge with the specified API
+// URL (the forge's public instance if blank). If token is not blank, it is
+// used to authenticate. If gitlabGroup is set, the GitLab provider manages
+// the labels of groups rather than projects.
+func newLabelProvider(forge, apiURL, token string, gitlabGroup bool) (LabelProvider, error) {
+	if gitlabGroup && forge != forgeGitLab {
+		return nil, errors.New("group labels are only supported by GitLab")
+	}
+
+	switch forge {
+	case forgeGitHub:
+		return newGitHubClient(apiURL, token), nil
+	case forgeGitLab:
+		return newGitLabClient(apiURL, token, gitlabGroup), nil
+	case forgeGitea:
+		return newGiteaClient(apiURL, token), nil
+	}
+
+	return nil, fmt.Errorf("invalid forge %q (expected one of %v)", forge, forges)
+}
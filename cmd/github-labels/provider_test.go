@@ -0,0 +1,119 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLabelProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	type testData struct {
+		forge               string
+		gitlabGroup         bool
+		expectedProvider    LabelProvider
+		expectedTokenEnvVar string
+		expectFail          bool
+	}
+
+	data := []testData{
+		{"", false, nil, githubTokenEnvVar, true},
+		{"bitbucket", false, nil, githubTokenEnvVar, true},
+		{forgeGitHub, true, nil, githubTokenEnvVar, true},
+		{forgeGitea, true, nil, giteaTokenEnvVar, true},
+
+		{forgeGitHub, false, &githubClient{}, githubTokenEnvVar, false},
+		{forgeGitLab, false, &gitlabClient{}, gitlabTokenEnvVar, false},
+		{forgeGitLab, true, &gitlabClient{group: true}, gitlabTokenEnvVar, false},
+		{forgeGitea, false, &giteaClient{}, giteaTokenEnvVar, false},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		assert.Equal(d.expectedTokenEnvVar, forgeTokenEnvVar(d.forge), msg)
+
+		provider, err := newLabelProvider(d.forge, "", testToken, d.gitlabGroup)
+		if d.expectFail {
+			assert.Error(err, msg)
+			continue
+		}
+
+		assert.NoError(err, msg)
+		assert.IsType(d.expectedProvider, provider, msg)
+
+		if client, ok := provider.(*gitlabClient); ok {
+			assert.Equal(d.gitlabGroup, client.group, msg)
+		}
+	}
+}
+
+func TestSyncProviders(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestLabelsFile(
+		Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ee0701"},
+		Label{Name: "good-first-issue", Description: "Suitable for a new contributor", CategoryName: "test", Colour: "7057ff", From: "good first issue"},
+		Label{Name: "needs-review", Description: "Needs a review", CategoryName: "test", Colour: "ededed"},
+	)
+
+	existing := []RemoteLabel{
+		{"bug", "000000", "Something is broken"},
+		{"good first issue", "7057ff", ""},
+		{"question", "cc317c", ""},
+	}
+
+	var gitlabLabels []gitlabLabel
+
+	for _, l := range existing {
+		gitlabLabels = append(gitlabLabels, gitlabLabel{l.Name, "#" + l.Colour, l.Description})
+	}
+
+	type testData struct {
+		forge string
+		fake  http.Handler
+	}
+
+	data := []testData{
+		{forgeGitHub, newFakeGitHub(testRepo, testToken, existing...)},
+		{forgeGitLab, newFakeGitLab(testRepo, false, testToken, gitlabLabels...)},
+		{forgeGitea, newFakeGitea(testRepo, testToken, existing...)},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d.forge)
+
+		server := httptest.NewServer(d.fake)
+
+		provider, err := newLabelProvider(d.forge, server.URL, testToken, false)
+		assert.NoError(err, msg)
+
+		var output bytes.Buffer
+
+		err = syncLabels(provider, lf, testRepo, true, false, &output)
+		assert.NoError(err, msg)
+
+		labels, err := scanRepo(provider, testRepo)
+		assert.NoError(err, msg)
+		assert.Equal([]RemoteLabel{
+			{"bug", "ee0701", "Something is broken"},
+			{"good-first-issue", "7057ff", "Suitable for a new contributor"},
+			{"needs-review", "ededed", "Needs a review"},
+		}, labels.Labels, msg)
+
+		// The repository now matches the labels database
+		assert.Empty(diffLabels(lf, testRepo, labels.Labels).Differences, msg)
+
+		server.Close()
+	}
+}
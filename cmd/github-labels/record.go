@@ -48,6 +48,175 @@ func labelHeaderRecord() []string {
 	}
 }
 
+func guideHeaderRecord() []string {
+	return []string{
+		"Label",
+		"Description",
+		"Colour",
+		"Applied automatically",
+	}
+}
+
+// quoteList returns the strings as a quoted markdown list.
+func quoteList(strs []string) string {
+	var quoted []string
+
+	for _, s := range strs {
+		quoted = append(quoted, fmt.Sprintf("`%s`", s))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func labelToGuideRecord(l Label) (record []string) {
+	var auto []string
+
+	if len(l.Paths) > 0 {
+		auto = append(auto, fmt.Sprintf("PRs changing %s", quoteList(l.Paths)))
+	}
+
+	if len(l.Keywords) > 0 {
+		auto = append(auto, fmt.Sprintf("titles containing %s", quoteList(l.Keywords)))
+	}
+
+	record = append(record, fmt.Sprintf("`%s`", l.Name))
+	record = append(record, l.Description)
+	record = append(record, fmt.Sprintf("`%s`", l.Colour))
+	record = append(record, strings.Join(auto, "; "))
+
+	return record
+}
+
+func diffHeaderRecord() []string {
+	return []string{
+		"Label",
+		"Difference",
+		"Current",
+		"Expected",
+	}
+}
+
+func diffToRecord(d LabelDifference, quote bool) (record []string) {
+	label := d.Label
+	current := d.Current
+	expected := d.Expected
+
+	// Names and colours are quoted, but not descriptions
+	if quote {
+		label = fmt.Sprintf("`%s`", d.Label)
+
+		if d.Kind != diffRedescribed {
+			if current != "" {
+				current = fmt.Sprintf("`%s`", d.Current)
+			}
+
+			if expected != "" {
+				expected = fmt.Sprintf("`%s`", d.Expected)
+			}
+		}
+	}
+
+	record = append(record, label)
+	record = append(record, string(d.Kind))
+	record = append(record, current)
+	record = append(record, expected)
+
+	return record
+}
+
+// driftStatus summarises the drift of a repository.
+func driftStatus(d RepoDrift) string {
+	switch {
+	case d.Error != "":
+		return "error: " + d.Error
+	case d.Missing+d.Extra+d.Renamed+d.Recoloured+d.Redescribed == 0:
+		return "up to date"
+	}
+
+	return "drifted"
+}
+
+func driftHeaderRecord() []string {
+	return []string{
+		"Repository",
+		"Missing",
+		"Extra",
+		"Renamed",
+		"Recoloured",
+		"Redescribed",
+		"Status",
+	}
+}
+
+func driftToRecord(d RepoDrift) (record []string) {
+	record = append(record, d.Repo)
+	record = append(record, fmt.Sprintf("%d", d.Missing))
+	record = append(record, fmt.Sprintf("%d", d.Extra))
+	record = append(record, fmt.Sprintf("%d", d.Renamed))
+	record = append(record, fmt.Sprintf("%d", d.Recoloured))
+	record = append(record, fmt.Sprintf("%d", d.Redescribed))
+	record = append(record, driftStatus(d))
+
+	return record
+}
+
+// usageStatus describes a label that may need attention.
+func usageStatus(u LabelUsage) string {
+	switch {
+	case u.Unused:
+		return "unused"
+	case u.Unknown:
+		return "not in database"
+	}
+
+	return ""
+}
+
+func usageHeaderRecord() []string {
+	return []string{
+		"Type",
+		"Name",
+		"Category",
+		"Open",
+		"Closed",
+		"Status",
+	}
+}
+
+func usageToRecords(usage *Usage, quote bool) (records [][]string) {
+	q := func(s string) string {
+		if quote && s != "" {
+			return fmt.Sprintf("`%s`", s)
+		}
+
+		return s
+	}
+
+	for _, u := range usage.Labels {
+		records = append(records, []string{
+			"label",
+			q(u.Name),
+			q(u.Category),
+			fmt.Sprintf("%d", u.Open),
+			fmt.Sprintf("%d", u.Closed),
+			usageStatus(u),
+		})
+	}
+
+	for _, u := range usage.Categories {
+		records = append(records, []string{
+			"category",
+			q(u.Name),
+			q(u.Name),
+			fmt.Sprintf("%d", u.Open),
+			fmt.Sprintf("%d", u.Closed),
+			"",
+		})
+	}
+
+	return records
+}
+
 func categoryHeaderRecord(showLabels bool) []string {
 	var fields []string
 
@@ -0,0 +1,199 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Time to wait for the forge to respond to each request.
+const restTimeout = 30 * time.Second
+
+// Matches the URL of the next page in a "Link" header (used by GitHub,
+// GitLab and Gitea).
+var nextPagePattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// restError is the body of a REST API error response. GitLab sometimes
+// returns an object describing each invalid field as the message, and uses
+// "error" rather than "message" for some errors.
+type restError struct {
+	Message interface{} `json:"message,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// String returns the error details, or "" if there are none.
+func (e restError) String() string {
+	switch message := e.Message.(type) {
+	case nil:
+		return e.Error
+	case string:
+		return message
+	default:
+		details, err := json.Marshal(message)
+		if err != nil {
+			return fmt.Sprint(message)
+		}
+
+		return string(details)
+	}
+}
+
+// restClient sends requests to a forge's REST API.
+type restClient struct {
+	apiURL string
+	client *http.Client
+
+	// Added to every request, including those used to authenticate.
+	headers http.Header
+}
+
+func newRESTClient(apiURL string, headers http.Header) restClient {
+	return restClient{
+		apiURL:  strings.TrimSuffix(apiURL, "/"),
+		client:  &http.Client{Timeout: restTimeout},
+		headers: headers,
+	}
+}
+
+// host returns the host name of the API URL, or "" if it is invalid.
+func (c *restClient) host() string {
+	u, err := url.Parse(c.apiURL)
+	if err != nil {
+		return ""
+	}
+
+	return u.Hostname()
+}
+
+// do sends a request to the API and checks the response status. If result
+// is not nil, the response body is decoded into it. The response is
+// returned so the caller can inspect the headers.
+func (c *restClient) do(method, url string, body interface{}, result interface{}) (*http.Response, error) {
+	var reader io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var details restError
+
+		if err := json.NewDecoder(resp.Body).Decode(&details); err == nil && details.String() != "" {
+			return nil, fmt.Errorf("%s %s failed: %v: %s", method, url, resp.Status, details)
+		}
+
+		return nil, fmt.Errorf("%s %s failed: %v", method, url, resp.Status)
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return nil, fmt.Errorf("invalid response for %s %s: %v", method, url, err)
+		}
+	}
+
+	return resp, nil
+}
+
+// getPages retrieves every page of results starting at the specified URL.
+// Each page is decoded into page, then added is called.
+func (c *restClient) getPages(url string, page interface{}, added func()) error {
+	next := url
+
+	for next != "" {
+		resp, err := c.do(http.MethodGet, next, nil, page)
+		if err != nil {
+			return err
+		}
+
+		added()
+
+		next, err = c.nextPageURL(resp)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nextPageURL returns the URL of the next page of results from the "Link"
+// header of the specified response, or "" if there are no more pages. Since
+// the token is sent with every request, only links to the API (with the same
+// scheme and host) are followed.
+func (c *restClient) nextPageURL(resp *http.Response) (string, error) {
+	matches := nextPagePattern.FindStringSubmatch(resp.Header.Get("Link"))
+	if matches == nil {
+		return "", nil
+	}
+
+	base, err := url.Parse(c.apiURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Relative links are relative to the page
+	next, err := resp.Request.URL.Parse(matches[1])
+	if err != nil {
+		return "", fmt.Errorf("invalid next page URL %q: %v", matches[1], err)
+	}
+
+	if next.Scheme != base.Scheme || !strings.EqualFold(next.Host, base.Host) {
+		return "", fmt.Errorf("not following next page URL %q: not on %s://%s", matches[1], base.Scheme, base.Host)
+	}
+
+	return next.String(), nil
+}
+
+// ownerRepoSlug returns the "owner/repo" form of the specified repository,
+// which may also be prefixed by the host name. forge is the name of the
+// forge used in errors.
+func ownerRepoSlug(forge, host, repo string) (string, error) {
+	slug := strings.Trim(repo, "/")
+
+	if host != "" {
+		slug = strings.Trim(strings.TrimPrefix(slug, host+"/"), "/")
+	}
+
+	fields := strings.Split(slug, "/")
+	if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+		return "", fmt.Errorf("invalid %s repository %q (expected org/repo)", forge, repo)
+	}
+
+	return slug, nil
+}
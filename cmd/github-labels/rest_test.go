@@ -0,0 +1,84 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRESTClientPagination(t *testing.T) {
+	assert := assert.New(t)
+
+	// Records the authorization sent to another host
+	var leaked []string
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked = append(leaked, r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("[]"))
+	}))
+	defer other.Close()
+
+	var next string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next))
+		}
+
+		_, _ = w.Write([]byte(`["label"]`))
+	}))
+	defer server.Close()
+
+	headers := http.Header{}
+	headers.Set("Authorization", "token "+testToken)
+
+	client := newRESTClient(server.URL+"/", headers)
+
+	type testData struct {
+		next          string
+		expectedPages int
+		expectFail    bool
+	}
+
+	data := []testData{
+		{server.URL + "/labels?page=2", 2, false},
+		{"/labels?page=2", 2, false},
+		{"?page=2", 2, false},
+
+		{other.URL + "/labels?page=2", 1, true},
+		{strings.Replace(server.URL, "http:", "https:", 1) + "/labels?page=2", 1, true},
+		{"//example.com/labels?page=2", 1, true},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		next = d.next
+
+		var page []string
+		pages := 0
+
+		err := client.getPages(server.URL+"/labels", &page, func() {
+			pages++
+		})
+
+		if d.expectFail {
+			assert.Error(err, msg)
+		} else {
+			assert.NoError(err, msg)
+		}
+
+		assert.Equal(d.expectedPages, pages, msg)
+	}
+
+	assert.Empty(leaked)
+}
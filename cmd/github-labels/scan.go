@@ -0,0 +1,79 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Header written to the start of a snapshot file.
+const snapshotHeader = "# Scanned and autogenerated by kata-github-labels (labeler format)\n---\n"
+
+// Snapshot is the set of labels in a repository at a point in time, in the
+// format used by the labeler tool (see the archive directory).
+type Snapshot struct {
+	Repo   string
+	Labels []RemoteLabel
+}
+
+// readSnapshot reads a snapshot file.
+func readSnapshot(file string) (*Snapshot, error) {
+	bytes, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := Snapshot{}
+
+	err = yaml.Unmarshal(bytes, &snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot file %v: %v", file, err)
+	}
+
+	if snapshot.Repo == "" {
+		return nil, fmt.Errorf("invalid snapshot file %v: repo cannot be blank", file)
+	}
+
+	for i, l := range snapshot.Labels {
+		if l.Name == "" {
+			return nil, fmt.Errorf("invalid snapshot file %v: label %d has no name", file, i)
+		}
+	}
+
+	return &snapshot, nil
+}
+
+// formatSnapshot returns the snapshot in the labeler file format.
+func formatSnapshot(snapshot *Snapshot) ([]byte, error) {
+	bytes, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(snapshotHeader), bytes...), nil
+}
+
+// scanRepo returns a snapshot of the labels in the specified repository,
+// sorted by name.
+func scanRepo(client LabelProvider, repo string) (*Snapshot, error) {
+	labels, err := client.ListLabels(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+
+	return &Snapshot{
+		Repo:   repo,
+		Labels: labels,
+	}, nil
+}
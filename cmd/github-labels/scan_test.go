@@ -0,0 +1,108 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadSnapshot(t *testing.T) {
+	assert := assert.New(t)
+
+	// The archived files were created by the labeler tool
+	files, err := filepath.Glob("archive/*.yaml")
+	assert.NoError(err)
+	assert.NotEmpty(files)
+
+	for _, file := range files {
+		snapshot, err := readSnapshot(file)
+		assert.NoError(err, file)
+		assert.NotEmpty(snapshot.Labels, file)
+	}
+
+	snapshot, err := readSnapshot("archive/labeler-original-labels-kata-containers-tests.yaml")
+	assert.NoError(err)
+	assert.Equal("kata-containers/tests", snapshot.Repo)
+	assert.Equal(RemoteLabel{"CI", "0052cc", "Continuous Integration"}, snapshot.Labels[0])
+	assert.Equal(RemoteLabel{"backlog", "ededed", ""}, snapshot.Labels[5])
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	_, err = readSnapshot(filepath.Join(dir, "does-not-exist"))
+	assert.Error(err)
+
+	for _, invalid := range []string{
+		"not: [valid",
+		"labels:\n  - name: bug\n",
+		"repo: org/repo\nlabels:\n  - color: ffffff\n",
+	} {
+		file := filepath.Join(dir, "invalid.yaml")
+
+		err = ioutil.WriteFile(file, []byte(invalid), fileMode)
+		assert.NoError(err)
+
+		_, err = readSnapshot(file)
+		assert.Error(err, invalid)
+	}
+}
+
+func TestScanRepo(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeGitHub(testRepo, testToken,
+		RemoteLabel{"wontfix", "ffffff", ""},
+		RemoteLabel{"bug", "ee0701", "Something is broken"},
+		RemoteLabel{"CI", "0052cc", "Continuous Integration"},
+		RemoteLabel{"black", "000000", "All digits"},
+	)
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	snapshot, err := scanRepo(newGitHubClient(server.URL, ""), testRepo)
+	assert.NoError(err)
+
+	// Sorted as the labeler tool does
+	expected := &Snapshot{
+		Repo: testRepo,
+		Labels: []RemoteLabel{
+			{"CI", "0052cc", "Continuous Integration"},
+			{"black", "000000", "All digits"},
+			{"bug", "ee0701", "Something is broken"},
+			{"wontfix", "ffffff", ""},
+		},
+	}
+
+	assert.Equal(expected, snapshot)
+
+	_, err = scanRepo(newGitHubClient(server.URL, ""), "kata-containers/other")
+	assert.Error(err)
+
+	// The snapshot can be read back
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	bytes, err := formatSnapshot(snapshot)
+	assert.NoError(err)
+
+	file := filepath.Join(dir, "snapshot.yaml")
+
+	err = ioutil.WriteFile(file, bytes, fileMode)
+	assert.NoError(err)
+
+	snapshot, err = readSnapshot(file)
+	assert.NoError(err)
+	assert.Equal(expected, snapshot)
+}
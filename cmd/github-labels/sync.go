@@ -0,0 +1,231 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+type SyncAction string
+
+const (
+	syncRename SyncAction = "rename"
+	syncUpdate SyncAction = "update"
+	syncCreate SyncAction = "create"
+	syncDelete SyncAction = "delete"
+)
+
+// SyncChange describes a change required to make the labels in a
+// repository match the labels database.
+type SyncChange struct {
+	Action SyncAction
+
+	// The existing label (unset when creating).
+	Old RemoteLabel
+
+	// The label required (unset when deleting).
+	New RemoteLabel
+}
+
+func (c SyncChange) String() string {
+	switch c.Action {
+	case syncCreate:
+		return fmt.Sprintf("create label %q (colour %q, description %q)",
+			c.New.Name, c.New.Colour, c.New.Description)
+	case syncDelete:
+		return fmt.Sprintf("delete label %q", c.Old.Name)
+	}
+
+	var details []string
+
+	if c.Action == syncRename || c.Old.Name != c.New.Name {
+		details = append(details, fmt.Sprintf("name %q -> %q", c.Old.Name, c.New.Name))
+	}
+
+	if normaliseColour(c.Old.Colour) != c.New.Colour {
+		details = append(details, fmt.Sprintf("colour %q -> %q", c.Old.Colour, c.New.Colour))
+	}
+
+	if c.Old.Description != c.New.Description {
+		details = append(details, fmt.Sprintf("description %q -> %q", c.Old.Description, c.New.Description))
+	}
+
+	return fmt.Sprintf("%s label %q: %s", c.Action, c.Old.Name, strings.Join(details, ", "))
+}
+
+// normaliseColour returns the colour in the form used by GitHub: lower-case
+// hex digits without a leading "#".
+func normaliseColour(colour string) string {
+	return strings.ToLower(strings.TrimPrefix(colour, "#"))
+}
+
+// toRemoteLabel converts a label in the database into the form stored in a
+// repository.
+func toRemoteLabel(l Label) RemoteLabel {
+	return RemoteLabel{
+		Name:        l.Name,
+		Colour:      normaliseColour(l.Colour),
+		Description: l.Description,
+	}
+}
+
+// labelDiffers returns true if the existing label does not match the
+// required label.
+func labelDiffers(existing, required RemoteLabel) bool {
+	return existing.Name != required.Name ||
+		normaliseColour(existing.Colour) != required.Colour ||
+		existing.Description != required.Description
+}
+
+// planSync returns the changes required to make the existing labels match
+// the labels database. Labels are matched by name, ignoring case as GitHub
+// does. A database label which does not exist is created unless its "From"
+// label exists, in which case that label is renamed so that issues and PRs
+// using it are retained. If prune is set, existing labels which are not in
+// the database are deleted.
+//
+// Renames are performed first so that a created label never clashes with
+// a label that is about to be renamed.
+func planSync(lf *LabelsFile, existing []RemoteLabel, prune bool) []SyncChange {
+	// Key: lower-case label name
+	// Value: existing label
+	byName := make(map[string]RemoteLabel)
+
+	for _, l := range existing {
+		byName[strings.ToLower(l.Name)] = l
+	}
+
+	// Existing labels that correspond to a database label
+	used := make(map[string]bool)
+
+	var renames, updates, creates, deletes []SyncChange
+
+	for _, l := range lf.Labels {
+		required := toRemoteLabel(l)
+
+		old, ok := byName[strings.ToLower(l.Name)]
+		if !ok {
+			continue
+		}
+
+		used[strings.ToLower(old.Name)] = true
+
+		if labelDiffers(old, required) {
+			updates = append(updates, SyncChange{Action: syncUpdate, Old: old, New: required})
+		}
+	}
+
+	for _, l := range lf.Labels {
+		if _, ok := byName[strings.ToLower(l.Name)]; ok {
+			continue
+		}
+
+		required := toRemoteLabel(l)
+
+		if l.From != "" {
+			from := strings.ToLower(l.From)
+
+			if old, ok := byName[from]; ok && !used[from] {
+				used[from] = true
+				renames = append(renames, SyncChange{Action: syncRename, Old: old, New: required})
+				continue
+			}
+		}
+
+		creates = append(creates, SyncChange{Action: syncCreate, New: required})
+	}
+
+	if prune {
+		for _, l := range existing {
+			if !used[strings.ToLower(l.Name)] {
+				deletes = append(deletes, SyncChange{Action: syncDelete, Old: l})
+			}
+		}
+	}
+
+	var changes []SyncChange
+
+	changes = append(changes, renames...)
+	changes = append(changes, updates...)
+	changes = append(changes, creates...)
+	changes = append(changes, deletes...)
+
+	return changes
+}
+
+// applySync makes the specified changes to the labels in the repository.
+func applySync(client LabelProvider, repo string, changes []SyncChange) error {
+	for _, change := range changes {
+		var err error
+
+		switch change.Action {
+		case syncRename, syncUpdate:
+			err = client.UpdateLabel(repo, change.Old.Name, change.New)
+		case syncCreate:
+			err = client.CreateLabel(repo, change.New)
+		case syncDelete:
+			err = client.DeleteLabel(repo, change.Old.Name)
+		default:
+			err = fmt.Errorf("invalid action %q", change.Action)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to %v: %v", change, err)
+		}
+	}
+
+	return nil
+}
+
+// displaySyncPlan writes a summary of the changes.
+func displaySyncPlan(w io.Writer, repo string, changes []SyncChange, dryRun bool) error {
+	if len(changes) == 0 {
+		_, err := fmt.Fprintf(w, "Labels in %s are up to date\n", repo)
+		return err
+	}
+
+	verb := "Applying"
+	if dryRun {
+		verb = "Would apply"
+	}
+
+	_, err := fmt.Fprintf(w, "%s %d changes to %s:\n", verb, len(changes), repo)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		_, err := fmt.Fprintf(w, "    %v\n", change)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncLabels makes the labels in the repository match the labels database.
+// If dryRun is set, the changes are displayed but not made.
+func syncLabels(client LabelProvider, lf *LabelsFile, repo string, prune, dryRun bool, w io.Writer) error {
+	existing, err := client.ListLabels(repo)
+	if err != nil {
+		return err
+	}
+
+	changes := planSync(lf, existing, prune)
+
+	if err := displaySyncPlan(w, repo, changes, dryRun); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	return applySync(client, repo, changes)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestLabelsFile returns a valid labels database containing the
+// specified labels.
+func newTestLabelsFile(labels ...Label) *LabelsFile {
+	return &LabelsFile{
+		Description: "Test labels.",
+		Repo:        testRepo,
+		Categories: Categories{
+			{Name: "test", Description: "Test category."},
+		},
+		Labels: labels,
+	}
+}
+
+func TestPlanSync(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestLabelsFile(
+		Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ee0701"},
+		Label{Name: "good-first-issue", Description: "Suitable for a new contributor", CategoryName: "test", Colour: "7057FF", From: "good first issue"},
+		Label{Name: "needs-review", Description: "Needs a review", CategoryName: "test", Colour: "#ededed"},
+		Label{Name: "question", Description: "Needs an answer", CategoryName: "test", Colour: "cc317c", From: "help"},
+	)
+
+	type testData struct {
+		existing        []RemoteLabel
+		prune           bool
+		expectedChanges []string
+	}
+
+	data := []testData{
+		// Already in sync (colours compared ignoring case)
+		{[]RemoteLabel{
+			{"bug", "EE0701", "Something is broken"},
+			{"good-first-issue", "7057ff", "Suitable for a new contributor"},
+			{"needs-review", "ededed", "Needs a review"},
+			{"question", "cc317c", "Needs an answer"},
+			{"wontfix", "ffffff", ""},
+		}, false, nil},
+
+		// Empty repository
+		{nil, true, []string{
+			`create label "bug"`,
+			`create label "good-first-issue"`,
+			`create label "needs-review"`,
+			`create label "question"`,
+		}},
+
+		{[]RemoteLabel{
+			{"Bug", "ee0701", "Something is broken"},
+			{"good first issue", "7057ff", ""},
+			{"needs-review", "000000", "Needs a review"},
+			{"help", "cc317c", "Needs an answer"},
+			{"wontfix", "ffffff", ""},
+		}, true, []string{
+			// Renames first
+			`rename label "good first issue": name "good first issue" -> "good-first-issue", description "" -> "Suitable for a new contributor"`,
+			`rename label "help": name "help" -> "question"`,
+			`update label "Bug": name "Bug" -> "bug"`,
+			`update label "needs-review": colour "000000" -> "ededed"`,
+			`delete label "wontfix"`,
+		}},
+
+		// The "From" label is only renamed if the label does not
+		// already exist.
+		{[]RemoteLabel{
+			{"bug", "ee0701", "Something is broken"},
+			{"good first issue", "7057ff", "Suitable for a new contributor"},
+			{"good-first-issue", "7057ff", "Suitable for a new contributor"},
+			{"needs-review", "ededed", "Needs a review"},
+		}, true, []string{
+			`create label "question"`,
+			`delete label "good first issue"`,
+		}},
+	}
+
+	for i, d := range data {
+		msg := fmt.Sprintf("test[%d]: %+v", i, d)
+
+		changes := planSync(lf, d.existing, d.prune)
+		if !assert.Len(changes, len(d.expectedChanges), msg) {
+			continue
+		}
+
+		for j, change := range changes {
+			assert.True(strings.HasPrefix(change.String(), d.expectedChanges[j]), "%s: %v", msg, change)
+		}
+	}
+}
+
+func TestSyncLabels(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestLabelsFile(
+		Label{Name: "bug", Description: "Something is broken", CategoryName: "test", Colour: "ee0701"},
+		Label{Name: "good-first-issue", Description: "Suitable for a new contributor", CategoryName: "test", Colour: "7057ff", From: "good first issue"},
+		Label{Name: "needs-review", Description: "Needs a review", CategoryName: "test", Colour: "ededed"},
+	)
+
+	existing := []RemoteLabel{
+		{"bug", "ee0701", "Something is broken"},
+		{"good first issue", "7057ff", ""},
+		{"wontfix", "ffffff", ""},
+	}
+
+	fake := newFakeGitHub(testRepo, testToken, existing...)
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := newGitHubClient(server.URL, testToken)
+
+	var out bytes.Buffer
+
+	// A dry run makes no changes
+	err := syncLabels(client, lf, testRepo, true, true, &out)
+	assert.NoError(err)
+	assert.Empty(fake.changes)
+	assert.Equal(existing, fake.labels)
+	assert.True(strings.HasPrefix(out.String(), "Would apply 3 changes to "+testRepo), out.String())
+
+	out.Reset()
+
+	err = syncLabels(client, lf, testRepo, true, false, &out)
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(out.String(), "Applying 3 changes to "+testRepo), out.String())
+
+	// The label is renamed rather than recreated
+	assert.Equal([]string{
+		"PATCH good first issue",
+		"POST ",
+		"DELETE wontfix",
+	}, fake.changes)
+
+	assert.Equal([]RemoteLabel{
+		{"bug", "ee0701", "Something is broken"},
+		{"good-first-issue", "7057ff", "Suitable for a new contributor"},
+		{"needs-review", "ededed", "Needs a review"},
+	}, fake.labels)
+
+	out.Reset()
+
+	err = syncLabels(client, lf, testRepo, true, false, &out)
+	assert.NoError(err)
+	assert.Equal("Labels in "+testRepo+" are up to date\n", out.String())
+
+	// Failures are reported
+	err = syncLabels(newGitHubClient(server.URL, ""), newTestLabelsFile(
+		Label{Name: "new", Description: "New label", CategoryName: "test", Colour: "ffffff"},
+	), testRepo, false, false, &out)
+	assert.Error(err)
+	assert.True(strings.Contains(err.Error(), `create label "new"`), err)
+}
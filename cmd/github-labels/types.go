@@ -6,17 +6,23 @@
 package main
 
 type Category struct {
-	Name        string
-	Description string
-	URL         string `yaml:",omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `yaml:",omitempty" json:"url,omitempty"`
 }
 
 type Label struct {
-	Name         string
-	Description  string
-	CategoryName string `yaml:"category"`
-	Colour       string `yaml:"color"`
-	From         string `yaml:",omitempty"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	CategoryName string `yaml:"category" json:"category"`
+	Colour       string `yaml:"color" json:"color"`
+	From         string `yaml:",omitempty" json:"from,omitempty"`
+
+	// Optional metadata used to apply the label automatically (see the
+	// "export" command): globs matching the files changed by a PR, and
+	// words found in issue and PR titles.
+	Paths    []string `yaml:",omitempty" json:"paths,omitempty"`
+	Keywords []string `yaml:",omitempty" json:"keywords,omitempty"`
 }
 
 type Categories []Category
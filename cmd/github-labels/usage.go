@@ -0,0 +1,192 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// LabelUsage records how many issues and PRs use a label.
+type LabelUsage struct {
+	Name string `json:"name"`
+
+	// Unset for labels which are not in the database.
+	Category string `json:"category,omitempty"`
+
+	Open   int `json:"open"`
+	Closed int `json:"closed"`
+
+	// Set if the label is in the database but no issue or PR uses it.
+	Unused bool `json:"unused"`
+
+	// Set if the label is used but is not in the database.
+	Unknown bool `json:"unknown"`
+}
+
+// CategoryUsage records how many issues and PRs use at least one label in
+// a category.
+type CategoryUsage struct {
+	Name   string `json:"name"`
+	Open   int    `json:"open"`
+	Closed int    `json:"closed"`
+}
+
+// Usage describes how the labels are used in a repository.
+type Usage struct {
+	// The repository (or dump file) the issues and PRs were read from.
+	Repo string `json:"repo"`
+
+	// Total number of issues and PRs.
+	Open   int `json:"open"`
+	Closed int `json:"closed"`
+
+	Labels     []LabelUsage    `json:"labels"`
+	Categories []CategoryUsage `json:"categories"`
+}
+
+// readIssuesDump reads issues and PRs from a file containing the JSON
+// returned by the GitHub REST API. The file may contain multiple arrays, as
+// created by "gh api --paginate".
+func readIssuesDump(file string) ([]Issue, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var issues []Issue
+
+	decoder := json.NewDecoder(f)
+
+	for {
+		var page []Issue
+
+		err := decoder.Decode(&page)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("invalid issues file %v: %v", file, err)
+		}
+
+		issues = append(issues, page...)
+	}
+
+	return issues, nil
+}
+
+// countUsage returns how the labels in the database are used by the issues
+// and PRs. Labels are matched ignoring case as GitHub does. A label which
+// has not yet been renamed is counted as the label it will be renamed to.
+func countUsage(lf *LabelsFile, repo string, issues []Issue) *Usage {
+	usage := &Usage{
+		Repo: repo,
+	}
+
+	// Key: lower-case label name
+	// Value: index in usage labels
+	labels := make(map[string]int)
+
+	for _, l := range lf.Labels {
+		labels[strings.ToLower(l.Name)] = len(usage.Labels)
+
+		usage.Labels = append(usage.Labels, LabelUsage{
+			Name:     l.Name,
+			Category: l.CategoryName,
+		})
+	}
+
+	for _, l := range lf.Labels {
+		from := strings.ToLower(l.From)
+
+		if _, ok := labels[from]; from != "" && !ok {
+			labels[from] = labels[strings.ToLower(l.Name)]
+		}
+	}
+
+	// Key: category name
+	// Value: index in usage categories
+	categories := make(map[string]int)
+
+	for _, c := range lf.Categories {
+		categories[c.Name] = len(usage.Categories)
+		usage.Categories = append(usage.Categories, CategoryUsage{Name: c.Name})
+	}
+
+	var unknown []LabelUsage
+
+	count := func(open *int, closed *int, isClosed bool) {
+		if isClosed {
+			*closed++
+		} else {
+			*open++
+		}
+	}
+
+	for _, issue := range issues {
+		closed := issue.State == "closed"
+
+		count(&usage.Open, &usage.Closed, closed)
+
+		// Categories already counted for this issue
+		seen := make(map[string]bool)
+
+		for _, l := range issue.Labels {
+			i, ok := labels[strings.ToLower(l.Name)]
+			if !ok {
+				i = len(usage.Labels)
+				labels[strings.ToLower(l.Name)] = i
+
+				usage.Labels = append(usage.Labels, LabelUsage{
+					Name:    l.Name,
+					Unknown: true,
+				})
+			}
+
+			label := &usage.Labels[i]
+
+			count(&label.Open, &label.Closed, closed)
+
+			if label.Category == "" || seen[label.Category] {
+				continue
+			}
+
+			seen[label.Category] = true
+
+			if j, ok := categories[label.Category]; ok {
+				category := &usage.Categories[j]
+				count(&category.Open, &category.Closed, closed)
+			}
+		}
+	}
+
+	for i := range usage.Labels {
+		label := &usage.Labels[i]
+
+		if label.Unknown {
+			unknown = append(unknown, *label)
+			continue
+		}
+
+		label.Unused = label.Open+label.Closed == 0
+	}
+
+	// Labels which are not in the database are listed last
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Name < unknown[j].Name
+	})
+
+	usage.Labels = append(usage.Labels[:len(lf.Labels)], unknown...)
+
+	return usage
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2019 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Two pages of issues as returned by "gh api --paginate" (abbreviated).
+const testIssuesDump = `[
+  {"number": 1, "state": "open", "labels": [{"name": "bug"}, {"name": "needs-review"}]},
+  {"number": 2, "state": "closed", "labels": [{"name": "Bug"}], "pull_request": {"url": "https://example.com"}}
+]
+[
+  {"number": 3, "state": "closed", "labels": [{"name": "good first issue"}, {"name": "hackathon"}]},
+  {"number": 4, "state": "open", "labels": []}
+]
+`
+
+// newTestIssue returns an issue using the specified labels.
+func newTestIssue(number int, state string, labels ...string) Issue {
+	issue := Issue{
+		Number: number,
+		State:  state,
+	}
+
+	for _, name := range labels {
+		issue.Labels = append(issue.Labels, struct {
+			Name string `json:"name"`
+		}{name})
+	}
+
+	return issue
+}
+
+func newTestUsageLabelsFile() *LabelsFile {
+	lf := newTestLabelsFile(
+		Label{Name: "bug", Description: "Something is broken", CategoryName: "type", Colour: "ee0701"},
+		Label{Name: "good-first-issue", Description: "Suitable for a new contributor", CategoryName: "help", Colour: "7057ff", From: "good first issue"},
+		Label{Name: "needs-review", Description: "Needs a review", CategoryName: "type", Colour: "ededed"},
+		Label{Name: "question", Description: "Needs an answer", CategoryName: "help", Colour: "cc317c"},
+	)
+
+	lf.Categories = Categories{
+		{Name: "help", Description: "Help wanted."},
+		{Name: "type", Description: "Type of issue."},
+	}
+
+	return lf
+}
+
+func TestReadIssuesDump(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "issues.json")
+
+	err = ioutil.WriteFile(file, []byte(testIssuesDump), fileMode)
+	assert.NoError(err)
+
+	issues, err := readIssuesDump(file)
+	assert.NoError(err)
+
+	if assert.Len(issues, 4) {
+		assert.Equal(newTestIssue(1, "open", "bug", "needs-review"), issues[0])
+		assert.NotNil(issues[1].PullRequest)
+		assert.Nil(issues[0].PullRequest)
+		assert.Equal(4, issues[3].Number)
+	}
+
+	err = ioutil.WriteFile(file, []byte(`[{"number": 1}] {"number": 2}`), fileMode)
+	assert.NoError(err)
+
+	_, err = readIssuesDump(file)
+	assert.Error(err)
+
+	_, err = readIssuesDump(filepath.Join(dir, "does-not-exist"))
+	assert.Error(err)
+}
+
+func TestCountUsage(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestUsageLabelsFile()
+
+	issues := []Issue{
+		newTestIssue(1, "open", "bug", "needs-review"),
+		newTestIssue(2, "closed", "Bug"),
+
+		// Counted as the label it will be renamed to
+		newTestIssue(3, "closed", "good first issue", "hackathon"),
+		newTestIssue(4, "open"),
+		newTestIssue(5, "open", "wontfix", "hackathon"),
+	}
+
+	usage := countUsage(lf, testRepo, issues)
+
+	expected := &Usage{
+		Repo:   testRepo,
+		Open:   3,
+		Closed: 2,
+		Labels: []LabelUsage{
+			{Name: "bug", Category: "type", Open: 1, Closed: 1},
+			{Name: "good-first-issue", Category: "help", Closed: 1},
+			{Name: "needs-review", Category: "type", Open: 1},
+			{Name: "question", Category: "help", Unused: true},
+			{Name: "hackathon", Open: 1, Closed: 1, Unknown: true},
+			{Name: "wontfix", Open: 1, Unknown: true},
+		},
+		Categories: []CategoryUsage{
+			{Name: "help", Closed: 1},
+
+			// Issue 1 has two labels in this category
+			{Name: "type", Open: 1, Closed: 1},
+		},
+	}
+
+	assert.Equal(expected, usage)
+
+	usage = countUsage(lf, testRepo, nil)
+	assert.Len(usage.Labels, len(lf.Labels))
+
+	for _, l := range usage.Labels {
+		assert.True(l.Unused, l)
+	}
+}
+
+func TestListIssues(t *testing.T) {
+	assert := assert.New(t)
+
+	fake := newFakeGitHub(testRepo, testToken)
+
+	for i := 1; i <= 5; i++ {
+		fake.issues = append(fake.issues, newTestIssue(i, "open", "bug"))
+	}
+
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := newGitHubClient(server.URL, "")
+
+	// All pages are returned
+	issues, err := client.ListIssues(testRepo)
+	assert.NoError(err)
+	assert.Equal(fake.issues, issues)
+
+	_, err = client.ListIssues("kata-containers/other")
+	assert.Error(err)
+}
+
+// displayToString returns the output of the display function when using
+// the handler.
+func displayToString(t *testing.T, newHandler func(file *os.File) DisplayHandler, display func(handler DisplayHandler) error) string {
+	assert := assert.New(t)
+
+	file, err := ioutil.TempFile("", "")
+	assert.NoError(err)
+	defer os.Remove(file.Name())
+
+	err = display(newHandler(file))
+	assert.NoError(err)
+	assert.NoError(file.Close())
+
+	bytes, err := ioutil.ReadFile(file.Name())
+	assert.NoError(err)
+
+	return string(bytes)
+}
+
+func TestDisplayUsage(t *testing.T) {
+	assert := assert.New(t)
+
+	usage := countUsage(newTestUsageLabelsFile(), testRepo, []Issue{
+		newTestIssue(1, "open", "bug", "hackathon"),
+	})
+
+	type testData struct {
+		newHandler    func(file *os.File) DisplayHandler
+		expectedLines []string
+	}
+
+	data := []testData{
+		{NewDisplayText, []string{
+			"Usage in kata-containers/tests (open: 1, closed: 0):",
+			"Labels (count: 5):",
+			`    bug (category "type", open: 1, closed: 0)`,
+			`    good-first-issue (category "help", open: 0, closed: 0) [unused]`,
+			`    needs-review (category "type", open: 0, closed: 0) [unused]`,
+			`    question (category "help", open: 0, closed: 0) [unused]`,
+			`    hackathon (category "", open: 1, closed: 0) [not in database]`,
+			"Categories (count: 2):",
+			"    help (open: 0, closed: 0)",
+			"    type (open: 1, closed: 0)",
+		}},
+		{NewDisplayTSV, []string{
+			"Type\tName\tCategory\tOpen\tClosed\tStatus",
+			"label\tbug\ttype\t1\t0\t",
+			"label\tgood-first-issue\thelp\t0\t0\tunused",
+			"label\tneeds-review\ttype\t0\t0\tunused",
+			"label\tquestion\thelp\t0\t0\tunused",
+			"label\thackathon\t\t1\t0\tnot in database",
+			"category\thelp\thelp\t0\t0\t",
+			"category\ttype\ttype\t1\t0\t",
+		}},
+	}
+
+	for _, d := range data {
+		output := displayToString(t, d.newHandler, func(handler DisplayHandler) error {
+			return handler.DisplayUsage(usage)
+		})
+
+		lines := strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+		assert.Equal(d.expectedLines, lines)
+	}
+
+	output := displayToString(t, NewDisplayJSON, func(handler DisplayHandler) error {
+		return handler.DisplayUsage(usage)
+	})
+
+	var decoded Usage
+
+	err := json.Unmarshal([]byte(output), &decoded)
+	assert.NoError(err)
+	assert.Equal(*usage, decoded)
+}
+
+func TestDisplayJSON(t *testing.T) {
+	assert := assert.New(t)
+
+	lf := newTestUsageLabelsFile()
+
+	output := displayToString(t, NewDisplayJSON, func(handler DisplayHandler) error {
+		return handler.DisplayLabels(lf)
+	})
+
+	var labels Labels
+
+	err := json.Unmarshal([]byte(output), &labels)
+	assert.NoError(err)
+	assert.Equal(lf.Labels, labels)
+
+	output = displayToString(t, NewDisplayJSON, func(handler DisplayHandler) error {
+		return handler.DisplayCategories(lf, true)
+	})
+
+	var categories []jsonCategory
+
+	err = json.Unmarshal([]byte(output), &categories)
+	assert.NoError(err)
+
+	if assert.Len(categories, 2) {
+		assert.Equal(lf.Categories[0], categories[0].Category)
+		assert.Equal([]string{"good-first-issue", "question"}, categories[0].Labels)
+		assert.Equal([]string{"bug", "needs-review"}, categories[1].Labels)
+	}
+
+	// Empty lists are not displayed as null
+	output = displayToString(t, NewDisplayJSON, func(handler DisplayHandler) error {
+		return handler.DisplayLabels(&LabelsFile{})
+	})
+	assert.Equal("[]\n", output)
+
+	output = displayToString(t, NewDisplayJSON, func(handler DisplayHandler) error {
+		return handler.DisplayDiff(&LabelsDiff{Repo: testRepo})
+	})
+	assert.True(strings.Contains(output, `"differences": []`), output)
+}
@@ -16,15 +16,12 @@ import (
 
 const fileMode os.FileMode = 0600
 
-func readYAML(file string) (*LabelsFile, error) {
-	bytes, err := ioutil.ReadFile(file)
-	if err != nil {
-		return nil, err
-	}
-
+// parseYAML decodes, sorts and cleans a labels database without checking
+// it.
+func parseYAML(bytes []byte) (*LabelsFile, error) {
 	lf := LabelsFile{}
 
-	err = yaml.Unmarshal(bytes, &lf)
+	err := yaml.Unmarshal(bytes, &lf)
 	if err != nil {
 		return nil, err
 	}
@@ -34,12 +31,42 @@ func readYAML(file string) (*LabelsFile, error) {
 
 	clean(&lf)
 
-	err = check(&lf)
+	return &lf, nil
+}
+
+// readYAMLProblems reads the labels database and returns all the problems
+// found with it.
+func readYAMLProblems(file string) (*LabelsFile, *Problems, error) {
+	bytes, err := ioutil.ReadFile(file)
 	if err != nil {
-		return nil, fmt.Errorf("file was not in expected format: %v", err)
+		return nil, nil, err
 	}
 
-	return &lf, nil
+	lf, err := parseYAML(bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	problems := checkAll(lf)
+
+	err = problems.Err()
+	if err != nil {
+		return nil, problems, fmt.Errorf("file was not in expected format: %v", err)
+	}
+
+	return lf, problems, nil
+}
+
+func readYAML(file string) (*LabelsFile, error) {
+	lf, _, err := readYAMLProblems(file)
+
+	return lf, err
+}
+
+func displayWarnings(problems *Problems) {
+	for _, warning := range problems.Warnings {
+		fmt.Printf("WARNING: %s\n", warning)
+	}
 }
 
 func writeYAML(lf *LabelsFile, file string) error {
@@ -53,7 +80,11 @@ func writeYAML(lf *LabelsFile, file string) error {
 
 func checkYAML(file string) error {
 	// read and check
-	_, err := readYAML(file)
+	_, problems, err := readYAMLProblems(file)
+
+	if problems != nil {
+		displayWarnings(problems)
+	}
 
 	if err == nil {
 		fmt.Printf("Checked file %v\n", file)
@@ -62,12 +93,15 @@ func checkYAML(file string) error {
 	return err
 }
 
+// sortYAML sorts the categories and labels in a labels database or
+// template, preserving its comments and formatting.
 func sortYAML(fromFile, toFile string) error {
-	// read and sort
-	lf, err := readYAML(fromFile)
+	doc, err := readDocument(fromFile)
 	if err != nil {
 		return err
 	}
 
-	return writeYAML(lf, toFile)
+	doc.Sort()
+
+	return doc.WriteFile(toFile)
 }